@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"filippo.io/age"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"github.com/yonasyiheyis/rdv/internal/bundle"
+	"github.com/yonasyiheyis/rdv/internal/cli"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/logger"
+	iprint "github.com/yonasyiheyis/rdv/internal/print"
+	"github.com/yonasyiheyis/rdv/internal/shared"
+	"github.com/yonasyiheyis/rdv/internal/ui"
+)
+
+// passphraseEnv lets scripts supply the backup passphrase without a prompt.
+const passphraseEnv = "RDV_BACKUP_PASSPHRASE"
+
+func newBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up saved profiles to a passphrase-encrypted file, and restore them",
+		Long: `Bundle every plugin's saved profiles, with the files they own (such as
+copied GCP keys and embedded SSH keys) and which of them came from a shared
+bundle, into one age-encrypted tar, and merge it back into the local stores
+later or on another machine.
+
+The passphrase is read from $` + passphraseEnv + ` when set, otherwise
+prompted for.`,
+	}
+	cmd.AddCommand(newBackupCreateCmd(), newBackupRestoreCmd())
+	return cmd
+}
+
+func newBackupCreateCmd() *cobra.Command {
+	var out string
+	var plugins []string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Write every saved profile to an encrypted backup file",
+		Example: `  rdv backup create --out rdv-backup.tar.age
+  RDV_BACKUP_PASSPHRASE=... rdv backup create --plugin db --force`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			targets, err := listTargets(len(plugins) == 0, plugins)
+			if err != nil {
+				return err
+			}
+			return runBackupCreate(targets, out, force)
+		},
+	}
+
+	cmd.Flags().StringVar(&out, "out", "rdv-backup.tar.age", "file to write the backup to")
+	cmd.Flags().StringArrayVar(&plugins, "plugin", nil, "only this plugin (e.g. aws, db.postgres, or db for all engines; repeatable)")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite --out if it already exists")
+	return cmd
+}
+
+func runBackupCreate(targets []string, out string, force bool) error {
+	var entries []bundle.Entry
+	counts := map[string]int{}
+	for _, t := range targets {
+		es, err := bundle.Collect(t)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, es...)
+		if len(es) > 0 {
+			counts[t] = len(es)
+		}
+	}
+	if len(entries) == 0 {
+		return exitcodes.New(exitcodes.ProfileNotFound, "no saved profiles to back up")
+	}
+	if err := addSharedMarks(entries); err != nil {
+		return err
+	}
+	if _, err := os.Stat(out); err == nil && !force {
+		return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("%s already exists (use --force to overwrite)", out))
+	}
+
+	pass, err := backupPassphrase(true)
+	if err != nil {
+		return err
+	}
+	r, err := age.NewScryptRecipient(pass)
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.InvalidArgs, err)
+	}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, entries, r); err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, buf.Bytes(), 0o600); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	logger.L.Infow("backup created", "path", out, "profiles", len(entries))
+
+	if iprint.JSON {
+		if err := iprint.Out(map[string]any{"path": out, "profiles": len(entries), "plugins": counts}); err != nil {
+			return exitcodes.Wrap(exitcodes.JSONError, err)
+		}
+		return nil
+	}
+	fmt.Printf("✅ Backed up %d profile(s) from %d plugin(s) to %s\n", len(entries), len(counts), out)
+	return nil
+}
+
+// addSharedMarks records on each entry whether its profile was imported
+// from a shared bundle, so a restore keeps it marked.
+func addSharedMarks(entries []bundle.Entry) error {
+	marks, err := shared.Load()
+	if err != nil {
+		return err
+	}
+	for i, e := range entries {
+		if mk, ok := marks.Get(e.Target, e.Profile); ok {
+			entries[i].Shared = &mk
+		}
+	}
+	return nil
+}
+
+// restoreSharedMarks marks the restored profiles that were shared when
+// backed up, and unmarks local shared profiles a restore overwrote with
+// one of the user's own.
+func restoreSharedMarks(steps []bundle.Step) error {
+	marks, err := shared.Load()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, st := range steps {
+		switch {
+		case st.Action == bundle.ActionSkip:
+		case st.Entry.Shared != nil:
+			marks.Set(st.Target, st.As, st.Entry.Shared.Bundle, st.Entry.Shared.Imported)
+			changed = true
+		default:
+			changed = marks.Remove(st.Target, st.As) || changed
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return marks.Save()
+}
+
+func newBackupRestoreCmd() *cobra.Command {
+	var onConflict string
+	var plugins []string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Merge the profiles of a backup file into the local stores",
+		Long: `Merge the profiles of a backup file into the local stores. A profile whose
+name is already saved is kept (--on-conflict skip, the default), replaced
+(overwrite) or restored as <name>-restored (rename).`,
+		Example: `  rdv backup restore rdv-backup.tar.age --dry-run
+  rdv backup restore rdv-backup.tar.age --on-conflict rename
+  rdv backup restore rdv-backup.tar.age --plugin gcp --on-conflict overwrite`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			policy, err := bundle.ParsePolicy(onConflict)
+			if err != nil {
+				return err
+			}
+			var only []string
+			if len(plugins) > 0 {
+				if only, err = listTargets(false, plugins); err != nil {
+					return err
+				}
+			}
+			return runBackupRestore(args[0], policy, only, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&onConflict, "on-conflict", string(bundle.Skip), "when a profile already exists: skip, overwrite or rename")
+	cmd.Flags().StringArrayVar(&plugins, "plugin", nil, "only this plugin (e.g. aws, db.postgres, or db for all engines; repeatable)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list what would be restored without writing anything")
+	return cmd
+}
+
+func runBackupRestore(file string, policy bundle.Policy, only []string, dryRun bool) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	defer f.Close()
+
+	pass, err := backupPassphrase(false)
+	if err != nil {
+		return err
+	}
+	id, err := age.NewScryptIdentity(pass)
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.InvalidArgs, err)
+	}
+	m, entries, err := bundle.Read(f, id)
+	if err != nil {
+		return err
+	}
+	if only != nil {
+		entries = slices.DeleteFunc(entries, func(e bundle.Entry) bool { return !slices.Contains(only, e.Target) })
+	}
+
+	steps, err := bundle.Plan(entries, policy, nil)
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		if err := bundle.Apply(steps); err != nil {
+			return err
+		}
+		if err := restoreSharedMarks(steps); err != nil {
+			return err
+		}
+		forgetReplaced(steps)
+		logger.L.Infow("backup restored", "path", file, "profiles", len(steps))
+	}
+	return printSteps(steps, m, dryRun, "Restored")
+}
+
+// printSteps reports what a restore or import did, or would do with
+// --dry-run; verb is the summary's past tense, e.g. "Restored".
+func printSteps(steps []bundle.Step, m bundle.Manifest, dryRun bool, verb string) error {
+	written := 0
+	for _, st := range steps {
+		if st.Action != bundle.ActionSkip {
+			written++
+		}
+	}
+	if dryRun {
+		bundle.Preview(steps)
+	}
+
+	if iprint.JSON {
+		payload := map[string]any{"dry_run": dryRun, "created": m.Created, strings.ToLower(verb): written,
+			"skipped": len(steps) - written, "profiles": steps}
+		if err := iprint.Out(payload); err != nil {
+			return exitcodes.Wrap(exitcodes.JSONError, err)
+		}
+		return nil
+	}
+
+	if len(steps) > 0 {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		header := "PLUGIN\tPROFILE\tACTION"
+		if dryRun {
+			header += "\tFIELDS"
+		}
+		_, _ = fmt.Fprintln(tw, header)
+		for _, st := range steps {
+			action := st.Action
+			switch {
+			case st.Action == bundle.ActionRename:
+				action = "rename -> " + st.As
+			case st.Detail != "":
+				action += " (" + st.Detail + ")"
+			}
+			if len(st.Dropped) > 0 {
+				action += " (dropped " + strings.Join(st.Dropped, ", ") + ")"
+			}
+			row := fmt.Sprintf("%s\t%s\t%s", st.Target, st.Profile, action)
+			if dryRun {
+				var kv []string
+				for _, k := range slices.Sorted(maps.Keys(st.Preview)) {
+					kv = append(kv, k+"="+st.Preview[k])
+				}
+				row += "\t" + orDash(strings.Join(kv, " "))
+			}
+			_, _ = fmt.Fprintln(tw, row)
+		}
+		_ = tw.Flush()
+	}
+	if dryRun {
+		fmt.Printf("Dry run: %d profile(s) would be %s, %d skipped\n", written, strings.ToLower(verb), len(steps)-written)
+		return nil
+	}
+	fmt.Printf("✅ %s %d profile(s), skipped %d\n", verb, written, len(steps)-written)
+	return nil
+}
+
+// backupPassphrase reads the passphrase from $RDV_BACKUP_PASSPHRASE or asks
+// for it, twice when confirm is set so a typo can't lock a new backup away.
+func backupPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return p, nil
+	}
+	if !cli.IsInteractive() {
+		return "", exitcodes.New(exitcodes.InvalidArgs, "no passphrase: set "+passphraseEnv+" or run in a terminal")
+	}
+
+	var pass, again string
+	fields := []huh.Field{
+		huh.NewInput().Title("Backup passphrase").EchoMode(huh.EchoModePassword).Value(&pass).Validate(huh.ValidateNotEmpty()),
+	}
+	if confirm {
+		fields = append(fields, huh.NewInput().Title("Repeat passphrase").EchoMode(huh.EchoModePassword).Value(&again))
+	}
+	if err := ui.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+		return "", err
+	}
+	if confirm && pass != again {
+		return "", exitcodes.New(exitcodes.InvalidArgs, "passphrases don't match")
+	}
+	return pass, nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/lasttest"
+	"github.com/yonasyiheyis/rdv/internal/logger"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	iprint "github.com/yonasyiheyis/rdv/internal/print"
+)
+
+// Doctor check statuses; only fail affects the exit code.
+const (
+	statusPass = "pass"
+	statusFail = "fail"
+	statusWarn = "warn"
+)
+
+// doctorResult is one line of the `rdv doctor` report.
+type doctorResult struct {
+	Plugin     string `json:"plugin"`
+	Profile    string `json:"profile,omitempty"`
+	Check      string `json:"check"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+}
+
+// doctorJob is one connection test to run.
+type doctorJob struct {
+	target  string
+	profile string
+	test    plugin.TestFunc
+}
+
+func newDoctorCmd() *cobra.Command {
+	var plugins []string
+	var profile string
+	var parallel int
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Test every saved profile and check config file health",
+		Long: `Run the connection test of every saved profile (like each plugin's
+test-conn), a few at a time, and check that config files aren't readable
+by other users and that files profiles point at (GCP keys, gcloud) exist.
+Exits with code 5 if anything fails.`,
+		Example: `  rdv doctor
+  rdv doctor --plugin db --profile dev
+  rdv doctor --parallel 8 --timeout 10s --json`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if parallel < 1 {
+				return exitcodes.New(exitcodes.InvalidArgs, "--parallel must be at least 1")
+			}
+			if timeout <= 0 {
+				return exitcodes.New(exitcodes.InvalidArgs, "--timeout must be positive")
+			}
+			targets, err := listTargets(len(plugins) == 0, plugins)
+			if err != nil {
+				return err
+			}
+			return runDoctor(targets, profile, parallel, timeout)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&plugins, "plugin", nil, "only this plugin (e.g. aws, db.postgres, or db for all engines; repeatable)")
+	cmd.Flags().StringVarP(&profile, "profile", "p", "", "only profiles with this name")
+	cmd.Flags().IntVar(&parallel, "parallel", 4, "connection tests to run at once")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "give up on a single connection test after this long")
+	return cmd
+}
+
+func runDoctor(targets []string, profile string, parallel int, timeout time.Duration) error {
+	var results []doctorResult
+	var jobs []doctorJob
+	for _, t := range targets {
+		e, _ := plugin.LookupExporter(t)
+		if profile == "" && e.Files != nil {
+			results = append(results, checkPermissions(t, e.Files())...)
+		}
+		if e.Check != nil {
+			issues, err := e.Check()
+			if err != nil {
+				results = append(results, doctorResult{Plugin: t, Check: "config", Status: statusFail, Detail: exitcodes.Message(err)})
+			}
+			for _, is := range issues {
+				if profile == "" || is.Profile == "" || is.Profile == profile {
+					results = append(results, doctorResult{Plugin: t, Profile: is.Profile, Check: is.Check, Status: statusFail, Detail: is.Detail})
+				}
+			}
+		}
+		if e.Test == nil || e.List == nil {
+			continue
+		}
+		sums, err := e.List()
+		if err != nil {
+			results = append(results, doctorResult{Plugin: t, Check: "config", Status: statusFail, Detail: exitcodes.Message(err)})
+			continue
+		}
+		for _, s := range sums {
+			if profile == "" || s.Profile == profile {
+				jobs = append(jobs, doctorJob{target: t, profile: s.Profile, test: e.Test})
+			}
+		}
+	}
+	if profile != "" && len(jobs) == 0 {
+		return exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("no profile %q to test", profile))
+	}
+
+	results = append(results, runDoctorJobs(jobs, parallel, timeout)...)
+	slices.SortStableFunc(results, func(a, b doctorResult) int {
+		return cmp.Or(cmp.Compare(a.Plugin, b.Plugin), cmp.Compare(a.Profile, b.Profile), cmp.Compare(a.Check, b.Check))
+	})
+
+	failed, warned := 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case statusFail:
+			failed++
+		case statusWarn:
+			warned++
+		}
+	}
+	var result error
+	if failed > 0 {
+		result = exitcodes.New(exitcodes.ConnectionFailed, fmt.Sprintf("%d check(s) failed", failed))
+	}
+
+	if iprint.JSON {
+		if err := iprint.Out(map[string]any{"ok": failed == 0, "failed": failed, "warnings": warned, "results": results}); err != nil {
+			return exitcodes.Wrap(exitcodes.JSONError, err)
+		}
+		return result
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PLUGIN\tPROFILE\tCHECK\tSTATUS\tDETAIL")
+	for _, r := range results {
+		detail := r.Detail
+		if r.DurationMS > 0 && detail == "" {
+			detail = (time.Duration(r.DurationMS) * time.Millisecond).String()
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Plugin, orDash(r.Profile), r.Check, r.Status, detail)
+	}
+	_ = tw.Flush()
+	fmt.Printf("%d passed, %d failed, %d warning(s)\n", len(results)-failed-warned, failed, warned)
+	return result
+}
+
+// runDoctorJobs runs the connection tests at most parallel at a time and
+// records the ones that pass for `rdv list`.
+func runDoctorJobs(jobs []doctorJob, parallel int, timeout time.Duration) []doctorResult {
+	results := make([]doctorResult, len(jobs))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+	for i, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			// Testers print their own success line; the report replaces it.
+			// The slot is freed once the test returns, even if that's after
+			// it was reported as timed out.
+			err := withTimeout(func(ctx context.Context) error { return j.test(ctx, j.profile, io.Discard) }, timeout,
+				func() { <-sem })
+			results[i] = doctorResult{Plugin: j.target, Profile: j.profile, Check: "test-conn", Status: statusPass,
+				DurationMS: max(time.Since(start).Milliseconds(), 1)}
+			if err != nil {
+				results[i].Status, results[i].Detail = statusFail, exitcodes.Message(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	tested, err := lasttest.Load()
+	if err != nil {
+		logger.L.Warnw("failed to record test-conn", "err", err)
+		return results
+	}
+	for _, r := range results {
+		if r.Status == statusPass {
+			tested.Set(r.Plugin, r.Profile, time.Now())
+		}
+	}
+	if err := tested.Save(); err != nil {
+		logger.L.Warnw("failed to record test-conn", "err", err)
+	}
+	return results
+}
+
+// withTimeout runs fn with a context cancelled after d, calling finished
+// once fn has returned. Testers stop when ctx is; should one be stuck where
+// it can't notice, it is reported as timed out anyway and left to finish on
+// its own, and finished waits for it, so a stuck test keeps its --parallel
+// slot rather than letting more tests pile up behind it.
+func withTimeout(fn func(ctx context.Context) error, d time.Duration, finished func()) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	done := make(chan error, 1)
+	go func() {
+		defer finished()
+		defer cancel()
+		done <- fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		// fn cancels ctx itself once it returns; only the deadline counts.
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s", d)
+		}
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", d)
+	}
+}
+
+// checkPermissions warns about store files other users can read or write.
+func checkPermissions(target string, files []string) []doctorResult {
+	if runtime.GOOS == "windows" { // modes don't reflect ACLs there
+		return nil
+	}
+	var out []doctorResult
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if mode := fi.Mode().Perm(); mode&0o077 != 0 {
+			out = append(out, doctorResult{Plugin: target, Check: "permissions", Status: statusWarn,
+				Detail: fmt.Sprintf("%s is mode %04o; run chmod 600 %s", f, mode, f)})
+		}
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoctorStuckTestKeepsItsSlot(t *testing.T) {
+	t.Setenv("RDV_STATE_DIR", t.TempDir())
+	release := make(chan struct{})
+	var stuckDone, startedEarly atomic.Bool
+	jobs := []doctorJob{
+		{target: "test.stuck", profile: "dev", test: func(ctx context.Context, _ string, _ io.Writer) error {
+			<-release // ignores ctx
+			stuckDone.Store(true)
+			return nil
+		}},
+		{target: "test.next", profile: "dev", test: func(ctx context.Context, _ string, _ io.Writer) error {
+			startedEarly.Store(!stuckDone.Load())
+			return nil
+		}},
+	}
+
+	out := make(chan []doctorResult)
+	go func() { out <- runDoctorJobs(jobs, 1, 10*time.Millisecond) }()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	results := <-out
+
+	require.False(t, startedEarly.Load(), "second test ran while the stuck one held the only slot")
+	require.Equal(t, statusFail, results[0].Status)
+	require.Equal(t, "timed out after 10ms", results[0].Detail)
+	require.Equal(t, statusPass, results[1].Status)
+}
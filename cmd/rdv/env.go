@@ -8,9 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/yonasyiheyis/rdv/internal/envfile"
-	awsp "github.com/yonasyiheyis/rdv/internal/plugins/aws"
-	dbp "github.com/yonasyiheyis/rdv/internal/plugins/db"
-	ghp "github.com/yonasyiheyis/rdv/internal/plugins/github"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
 	iprint "github.com/yonasyiheyis/rdv/internal/print"
 )
 
@@ -90,23 +88,17 @@ func newEnvExportCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringSliceVar(&sets, "set", nil, "profile spec: aws:<name> | db.postgres:<name> | db.mysql:<name> | github:<name> (repeatable)")
+	cmd.Flags().StringSliceVar(&sets, "set", nil, "profile spec: aws:<name> | gcp:<name> | db.<engine>:<name> | github:<name> | custom:<name> (repeatable)")
 	cmd.Flags().StringVarP(&envPath, "env-file", "o", "", "write/merge result to this .env file instead of printing")
 	return cmd
 }
 
-// exportFor resolves a <plugin>[:subplugin] + profile into a map of env vars.
+// exportFor resolves a <plugin>[:subplugin] + profile into a map of env vars
+// using the exporters plugins registered at init time.
 func exportFor(target, profile string) (map[string]string, error) {
-	switch target {
-	case "aws":
-		return awsp.ExportVars(profile)
-	case "db.postgres", "postgres", "pg":
-		return dbp.PGExportVars(profile)
-	case "db.mysql", "mysql":
-		return dbp.MySQLExportVars(profile)
-	case "github", "gh":
-		return ghp.ExportVars(profile)
-	default:
-		return nil, fmt.Errorf("unknown target %q (expected aws|db.postgres|db.mysql|github)", target)
+	e, ok := plugin.LookupExporter(target)
+	if !ok {
+		return nil, fmt.Errorf("unknown target %q (expected %s)", target, strings.Join(plugin.ExporterNames(), "|"))
 	}
+	return e.Export(profile)
 }
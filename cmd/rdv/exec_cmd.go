@@ -2,8 +2,10 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -12,8 +14,11 @@ import (
 )
 
 func newExecCmd() *cobra.Command {
-	var awsProf, pgProf, mysqlProf, ghProf string
-	var noInherit bool
+	var profiles func() map[string]string
+	var noInherit, keepAmbient, replace, processGroup, secretsFD, mask bool
+	var secretsDir string
+	var inherit, inheritPrefix, drop, setEnv, matrix []string
+	var parallel int
 
 	cmd := &cobra.Command{
 		Use:   "exec [-- command [args...]]",
@@ -24,7 +29,29 @@ Examples:
   rdv exec --aws dev -- env | grep AWS_
   rdv exec --pg dev -- psql -c '\conninfo'
   rdv exec --aws dev --pg dev -- make test
-  rdv exec --no-inherit --mysql ci -- /bin/sh -lc 'echo $MYSQL_DATABASE_URL'`,
+  rdv exec --redis dev -- /bin/sh -c 'redis-cli -u "$REDIS_URL" ping'
+  rdv exec --registry ghcr -- docker push ghcr.io/acme/app:dev
+  rdv exec --k8s staging -- kubectl get pods
+  rdv exec --ssh deploy -- git clone git@github.com:acme/infra.git
+  rdv exec --pkg ci -- npm publish
+  rdv exec --custom stripe-test -- npm test
+  rdv exec --no-inherit --mysql ci -- /bin/sh -lc 'echo $MYSQL_DATABASE_URL'
+  rdv exec --inherit EDITOR --inherit-prefix GO --pg dev -- go test ./...
+  rdv exec --drop 'AWS_*' --aws dev --set-env AWS_REGION=eu-west-1 -- aws s3 ls
+  rdv exec --replace --aws prod -- terraform apply
+  rdv exec --matrix pg=dev,staging,prod --parallel 3 -- ./smoke.sh
+  rdv exec --pg dev --secrets-as-files tmpfs -- sh -c 'PGPASSWORD=$(cat "$PGPASSWORD_FILE") psql'
+  rdv exec --mask --github bot -- ./ci/debug-env.sh
+
+Signals (HUP, INT, QUIT, TERM, USR1, USR2, WINCH, ALRM) are forwarded to the
+child, or to its whole process group with --process-group. Without it the
+child shares rdv's process group, so when that group is in the terminal's
+foreground Ctrl-C / Ctrl-\ already reach it and INT and QUIT aren't relayed
+again. A child killed by signal N makes rdv exit with 128+N, like a shell.
+
+--mask replaces every secret value of the selected profiles (verbatim,
+URL-encoded or base64) with *** in the command's stdout and stderr. The
+command then writes to a pipe rather than the terminal.`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(c *cobra.Command, args []string) error {
 			// Support separating flags from the command using "--"
@@ -35,56 +62,192 @@ Examples:
 				return exitcodes.New(exitcodes.InvalidArgs, "provide a command to run after --, e.g., rdv exec --aws dev -- env")
 			}
 
+			axes, err := execenv.ParseMatrix(matrix)
+			if err != nil {
+				return err
+			}
+
 			// Require at least one source; otherwise it's a no-op.
-			if awsProf == "" && pgProf == "" && mysqlProf == "" && ghProf == "" {
-				return fmt.Errorf("nothing to inject: pass one of --aws PROFILE, --pg PROFILE, --mysql PROFILE, or --github PROFILE")
+			selected := profiles()
+			if len(selected) == 0 && len(axes) == 0 {
+				return fmt.Errorf("nothing to inject: pass one of %s, or --matrix FLAG=PROFILES", strings.Join(asFlags(execenv.ProfileFlagNames(), " PROFILE"), ", "))
 			}
 
-			envMap, err := execenv.BuildEnv(execenv.Options{
-				AWS:       awsProf,
-				Postgres:  pgProf,
-				MySQL:     mysqlProf,
-				GitHub:    ghProf,
-				NoInherit: noInherit,
-			})
+			if secretsDir != "" && secretsFD {
+				return exitcodes.New(exitcodes.InvalidArgs, "--secrets-as-files and --secrets-fd are mutually exclusive")
+			}
+			// --replace gives up the rdv process, so nothing could remove
+			// temporary files or stop the ssh-agent afterwards.
+			cleanupFlags := execenv.CleanupFlags()
+			needsRdv := slices.ContainsFunc(cleanupFlags, func(f string) bool { return selected[f] != "" })
+			if replace && (needsRdv || secretsDir != "" || secretsFD || mask || len(axes) > 0) {
+				return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("--replace cannot be combined with %s, --secrets-as-files, --secrets-fd, --mask or --matrix (they need rdv to outlive the command)",
+					strings.Join(asFlags(cleanupFlags, ""), ", ")))
+			}
+
+			extra, err := execenv.ParseSetEnv(setEnv)
 			if err != nil {
 				return err
 			}
 
-			// Convert map to []string form
-			childEnv := make([]string, 0, len(envMap))
-			for k, v := range envMap {
-				childEnv = append(childEnv, k+"="+v)
+			opts := execenv.Options{
+				Profiles:  selected,
+				NoInherit: noInherit,
+
+				Inherit:       inherit,
+				InheritPrefix: inheritPrefix,
+				Drop:          drop,
+				SetEnv:        extra,
+				KeepAmbient:   keepAmbient,
 			}
+			if secretsDir == "tmpfs" {
+				secretsDir = execenv.TmpfsDir()
+			}
+			handling := secretHandling{dir: secretsDir, fd: secretsFD, mask: mask}
 
-			// Spawn child process with inherited stdio
-			child := exec.CommandContext(c.Context(), args[0], args[1:]...)
-			child.Env = childEnv
-			child.Stdin = os.Stdin
-			child.Stdout = os.Stdout
-			child.Stderr = os.Stderr
-
-			// Run and propagate error (exit code behavior will be refined in the exit-codes task)
-			if err := child.Run(); err != nil {
-				// If the child ran and failed, propagate its exit code exactly.
-				if ee, ok := err.(*exec.ExitError); ok {
-					return exitcodes.WithCode(ee.ExitCode())
+			if len(axes) > 0 {
+				combos, err := execenv.Combinations(opts, axes)
+				if err != nil {
+					return err
 				}
-				// Otherwise: spawn failure (binary not found, permission, etc.)
+				return runMatrix(args, combos, handling, parallel, processGroup)
+			}
+
+			childEnv, runOpts, cleanup, err := prepareChild(opts, handling, execenv.RunOptions{})
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			if replace {
+				err := execenv.Replace(args, childEnv)
+				return exitcodes.Wrap(exitcodes.ChildSpawnFailed, err)
+			}
+
+			// Run the child with inherited stdio; it owns the exit code.
+			runOpts.ProcessGroup = processGroup
+			code, err := execenv.Run(args, childEnv, runOpts)
+			if err != nil {
+				// spawn failure (binary not found, permission, etc.)
 				return exitcodes.Wrap(exitcodes.ChildSpawnFailed, err)
 			}
+			if code != 0 {
+				return exitcodes.WithCode(code)
+			}
 			return nil
 		},
 	}
 
 	// Profile selectors
-	cmd.Flags().StringVar(&awsProf, "aws", "", "AWS profile to inject")
-	cmd.Flags().StringVar(&pgProf, "pg", "", "Postgres profile to inject")
-	cmd.Flags().StringVar(&mysqlProf, "mysql", "", "MySQL profile to inject")
-	cmd.Flags().StringVar(&ghProf, "github", "", "GitHub profile to inject")
+	profiles = addProfileFlags(cmd, "inject")
 
 	// Env behavior
-	cmd.Flags().BoolVar(&noInherit, "no-inherit", false, "do not inherit current environment")
+	cmd.Flags().BoolVar(&noInherit, "no-inherit", false, "inherit only a minimal allowlist (PATH, HOME, USER, SHELL, TERM, LANG, LC_*, TZ, TMPDIR, ...)")
+	cmd.Flags().StringSliceVar(&inherit, "inherit", nil, "extra variables to keep; implies --no-inherit (repeatable, comma-separated)")
+	cmd.Flags().StringSliceVar(&inheritPrefix, "inherit-prefix", nil, "keep variables with this prefix, e.g. GO; implies --no-inherit (repeatable)")
+	cmd.Flags().StringSliceVar(&drop, "drop", nil, "remove ambient variables matching this glob, e.g. 'AWS_*' (repeatable)")
+	cmd.Flags().BoolVar(&keepAmbient, "keep-ambient", false, "keep inherited variables related to the selected plugins (e.g. AWS_PROFILE with --aws); --debug lists what is removed otherwise")
+	cmd.Flags().StringArrayVar(&setEnv, "set-env", nil, "set KEY=VALUE in the child, overriding profiles (repeatable)")
+
+	// Secret delivery
+	cmd.Flags().StringVar(&secretsDir, "secrets-as-files", "", "write each secret to a 0400 file under DIR (\"tmpfs\" = /dev/shm) and export KEY_FILE instead of KEY")
+	cmd.Flags().BoolVar(&secretsFD, "secrets-fd", false, "pass secrets as dotenv lines on inherited fd 3 (RDV_SECRETS_FD) instead of env vars")
+	cmd.Flags().BoolVar(&mask, "mask", false, "replace secret values (and their URL-encoded/base64 forms) with *** in the command's output")
+
+	// Matrix mode
+	cmd.Flags().StringArrayVar(&matrix, "matrix", nil, "run once per profile, e.g. pg=dev,staging,prod; repeat for a cartesian product")
+	cmd.Flags().IntVar(&parallel, "parallel", 1, "matrix runs to execute at once")
+
+	// Process behavior
+	cmd.Flags().BoolVar(&replace, "replace", false, "replace rdv with the command via exec(2) instead of supervising it (not on Windows)")
+	cmd.Flags().BoolVar(&processGroup, "process-group", false, "run the command in its own process group and signal the whole group")
 
 	return cmd
 }
+
+// secretHandling is how --secrets-as-files / --secrets-fd hand credentials
+// to the child instead of the environment, and whether --mask filters them
+// out of its output.
+type secretHandling struct {
+	dir  string
+	fd   bool
+	mask bool
+}
+
+// prepareChild builds the child env for o (as KEY=VALUE pairs) and applies
+// secret handling to it and to ro. cleanup must run once the child has
+// exited.
+func prepareChild(o execenv.Options, sh secretHandling, ro execenv.RunOptions) (env []string, _ execenv.RunOptions, cleanup func(), err error) {
+	envMap, done, err := execenv.BuildEnv(o)
+	if err != nil {
+		return nil, ro, nil, err
+	}
+	cleanups := []func(){done}
+	runCleanups := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+	defer func() {
+		if err != nil {
+			runCleanups()
+		}
+	}()
+
+	if sh.dir == "" && !sh.fd && !sh.mask {
+		return envSlice(envMap), ro, runCleanups, nil
+	}
+	keys, err := execenv.SecretKeys(o)
+	if err != nil {
+		return nil, ro, nil, err
+	}
+
+	// Collect values to mask before delivery moves them out of envMap.
+	if sh.mask {
+		var values []string
+		for _, k := range keys {
+			if v, ok := envMap[k]; ok {
+				values = append(values, v)
+			}
+		}
+		patterns := execenv.MaskPatterns(values)
+		stdout := execenv.NewMasker(orDefault(ro.Stdout, os.Stdout), patterns)
+		stderr := execenv.NewMasker(orDefault(ro.Stderr, os.Stderr), patterns)
+		ro.Stdout, ro.Stderr = stdout, stderr
+		cleanups = append(cleanups, func() { _ = stdout.Flush(); _ = stderr.Flush() })
+	}
+
+	// Move credentials out of the environment if asked to.
+	if sh.fd {
+		r, done, err := execenv.SecretsToPipe(envMap, keys)
+		if err != nil {
+			return nil, ro, nil, exitcodes.Wrap(exitcodes.ChildSpawnFailed, err)
+		}
+		cleanups = append(cleanups, done)
+		ro.ExtraFiles = append(ro.ExtraFiles, r)
+	} else if sh.dir != "" {
+		done, err := execenv.SecretsToFiles(envMap, keys, sh.dir)
+		if err != nil {
+			return nil, ro, nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		cleanups = append(cleanups, done)
+	}
+
+	return envSlice(envMap), ro, runCleanups, nil
+}
+
+func orDefault(w, def io.Writer) io.Writer {
+	if w == nil {
+		return def
+	}
+	return w
+}
+
+// envSlice converts an env map to KEY=VALUE form.
+func envSlice(envMap map[string]string) []string {
+	env := make([]string, 0, len(envMap))
+	for k, v := range envMap {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	execenv "github.com/yonasyiheyis/rdv/internal/exec"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+func TestProfileFlagsResolveToExporters(t *testing.T) {
+	for _, f := range execenv.ProfileFlags {
+		_, ok := plugin.LookupExporter(f.Name)
+		require.True(t, ok, "--%s names no registered target", f.Name)
+	}
+	require.Equal(t, []string{"registry", "k8s", "ssh", "pkg"}, execenv.CleanupFlags())
+}
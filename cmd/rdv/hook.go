@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/hook"
+)
+
+func newHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook bash|zsh|fish",
+		Short: "Print a shell hook that loads .rdv.envrc per directory",
+		Long: `Print a prompt hook that loads profiles listed in a project's .rdv.envrc
+when you cd into it (or any subdirectory) and restores your env when you
+leave. Add it to your shell's rc file:
+
+  eval "$(rdv hook bash)"        # ~/.bashrc
+  eval "$(rdv hook zsh)"         # ~/.zshrc
+  rdv hook fish | source         # ~/.config/fish/config.fish
+
+.rdv.envrc lists one <target>:<profile> per line, as for env export --set:
+
+  aws:dev
+  db.postgres:dev
+  custom:stripe-test
+
+The first time a file (or a changed version of it) is seen, rdv asks
+whether to trust it and remembers the answer in ~/.config/rdv/trust.yaml.`,
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: hook.Shells,
+		RunE: func(c *cobra.Command, args []string) error {
+			exe, err := os.Executable()
+			if err != nil {
+				exe = "rdv"
+			}
+			s, err := hook.Script(args[0], exe)
+			if err != nil {
+				return err
+			}
+			fmt.Print(s)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(newHookEnvCmd(), newHookTrustCmd(), newHookUntrustCmd())
+	return cmd
+}
+
+// newHookEnvCmd is what the installed hook runs at every prompt; its
+// stdout is evaluated by the shell.
+func newHookEnvCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:       "env bash|zsh|fish",
+		Short:     "Print the statements that sync the shell with .rdv.envrc (run by the hook)",
+		Hidden:    true,
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: hook.Shells,
+		RunE: func(c *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+			}
+			trust, err := hook.LoadTrust()
+			if err != nil {
+				return err
+			}
+			l := hook.Loader{
+				Getenv: os.LookupEnv,
+				Export: func(s hook.Spec) (map[string]string, error) { return exportFor(s.Target, s.Profile) },
+				Warn:   func(msg string) { fmt.Fprintln(os.Stderr, "rdv:", msg) },
+			}
+			// stdout goes to the shell's eval, so ask on stderr.
+			if term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd())) {
+				l.Ask = askTrust
+			}
+
+			change, err := l.Update(cwd, trust)
+			if err != nil {
+				return err
+			}
+			out, err := hook.Render(args[0], change)
+			if err != nil {
+				return err
+			}
+			fmt.Print(out)
+			return nil
+		},
+	}
+}
+
+func askTrust(path string, specs []hook.Spec) (bool, error) {
+	names := make([]string, len(specs))
+	for i, s := range specs {
+		names[i] = s.String()
+	}
+	fmt.Fprintf(os.Stderr, "rdv: %s wants to load %s. Trust it? [y/N] ", path, strings.Join(names, ", "))
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return false, nil
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
+func newHookTrustCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "trust [dir]",
+		Short: "Trust the .rdv.envrc for dir (default: the current directory)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			path, err := envrcFor(args)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+			}
+			if _, err := hook.Parse(path, data); err != nil {
+				return err
+			}
+			trust, err := hook.LoadTrust()
+			if err != nil {
+				return err
+			}
+			trust.Allow(path, hook.Hash(data))
+			if err := trust.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("✅ trusted %s\n", path)
+			return nil
+		},
+	}
+}
+
+func newHookUntrustCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "untrust [dir]",
+		Short: "Forget the trust decision for dir's .rdv.envrc, so the hook asks again",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			path, err := envrcFor(args)
+			if err != nil {
+				return err
+			}
+			trust, err := hook.LoadTrust()
+			if err != nil {
+				return err
+			}
+			if !trust.Forget(path) {
+				fmt.Printf("%s was not trusted or denied\n", path)
+				return nil
+			}
+			if err := trust.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("🗑️  forgot %s\n", path)
+			return nil
+		},
+	}
+}
+
+// envrcFor finds the .rdv.envrc governing args[0] (or the working dir).
+func envrcFor(args []string) (string, error) {
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", exitcodes.Wrap(exitcodes.InvalidArgs, err)
+	}
+	path := hook.Find(abs)
+	if path == "" {
+		return "", exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("no %s in %s or its parents", hook.EnvrcName, abs))
+	}
+	return path, nil
+}
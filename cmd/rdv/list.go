@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yonasyiheyis/rdv/internal/bundle"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/lasttest"
+	"github.com/yonasyiheyis/rdv/internal/logger"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	iprint "github.com/yonasyiheyis/rdv/internal/print"
+)
+
+// listRow is one profile in `rdv list` output.
+type listRow struct {
+	Plugin       string            `json:"plugin"`
+	Profile      string            `json:"profile"`
+	Meta         map[string]string `json:"meta"`
+	Modified     time.Time         `json:"modified,omitzero"`
+	LastTestConn time.Time         `json:"last_test_conn,omitzero"`
+}
+
+func newListCmd() *cobra.Command {
+	var all bool
+	var plugins []string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List saved profiles across plugins",
+		Example: `  rdv list --all
+  rdv list --plugin db --plugin aws   # db matches every db.<engine>
+  rdv list --all --json`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if all == (len(plugins) > 0) {
+				return exitcodes.New(exitcodes.InvalidArgs, "use either --all or --plugin")
+			}
+			targets, err := listTargets(all, plugins)
+			if err != nil {
+				return err
+			}
+			return runList(targets)
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "list the profiles of every plugin")
+	cmd.Flags().StringArrayVar(&plugins, "plugin", nil, "only this plugin (e.g. aws, db.postgres, or db for all engines; repeatable)")
+	return cmd
+}
+
+// listTargets resolves --all / --plugin to canonical exporter names.
+func listTargets(all bool, plugins []string) ([]string, error) {
+	names := plugin.ExporterNames()
+	if all {
+		return names, nil
+	}
+	seen := map[string]bool{}
+	var out []string
+	add := func(n string) {
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	for _, p := range plugins {
+		if e, ok := plugin.LookupExporter(p); ok {
+			add(e.Name)
+			continue
+		}
+		found := false
+		for _, n := range names {
+			if strings.HasPrefix(n, p+".") {
+				add(n)
+				found = true
+			}
+		}
+		if !found {
+			return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("unknown plugin %q", p))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func runList(targets []string) error {
+	tested, err := lasttest.Load()
+	if err != nil {
+		return err
+	}
+
+	rows := []listRow{}
+	errs := map[string]string{}
+	for _, t := range targets {
+		e, _ := plugin.LookupExporter(t)
+		if e.List == nil {
+			continue
+		}
+		sums, err := e.List()
+		if err != nil {
+			// One unreadable store shouldn't hide the others.
+			errs[t] = exitcodes.Message(err)
+			continue
+		}
+		for _, s := range sums {
+			meta := map[string]string{}
+			for k, v := range s.Meta {
+				if v != "" {
+					meta[k] = v
+				}
+			}
+			rows = append(rows, listRow{
+				Plugin:       t,
+				Profile:      s.Profile,
+				Meta:         meta,
+				Modified:     s.Modified,
+				LastTestConn: tested.Get(t, s.Profile),
+			})
+		}
+	}
+
+	if iprint.JSON {
+		payload := map[string]any{"profiles": rows}
+		if len(errs) > 0 {
+			payload["errors"] = errs
+		}
+		if err := iprint.Out(payload); err != nil {
+			return exitcodes.Wrap(exitcodes.JSONError, err)
+		}
+		return nil
+	}
+
+	for _, t := range slices.Sorted(maps.Keys(errs)) {
+		fmt.Fprintf(os.Stderr, "rdv: %s: %s\n", t, errs[t])
+	}
+	if len(rows) == 0 {
+		fmt.Println("(no profiles)")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PLUGIN\tPROFILE\tDETAILS\tMODIFIED\tLAST TEST-CONN")
+	for _, r := range rows {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Plugin, r.Profile, formatMeta(r.Meta),
+			shortTime(r.Modified), shortTime(r.LastTestConn))
+	}
+	return tw.Flush()
+}
+
+// formatMeta renders meta as sorted key=value pairs.
+func formatMeta(meta map[string]string) string {
+	keys := slices.Sorted(maps.Keys(meta))
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + meta[k]
+	}
+	return orDash(strings.Join(parts, " "))
+}
+
+// shortTime renders t in local time for the table, or "-" if unknown.
+func shortTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// recordTestConn remembers a passed connection test for `rdv list`: it runs
+// after any plugin's `test-conn`, or `set-config` / `modify` with
+// --test-conn, returns successfully.
+func recordTestConn(c *cobra.Command) {
+	tested := c.Name() == "test-conn"
+	if f := c.Flags().Lookup("test-conn"); f != nil && f.Value.String() == "true" {
+		tested = true
+	}
+	profile := c.Flags().Lookup("profile")
+	if !tested || profile == nil {
+		return
+	}
+	e, ok := commandTarget(c)
+	if !ok {
+		return
+	}
+	if err := lasttest.Record(e.Name, profile.Value.String()); err != nil {
+		logger.L.Warnw("failed to record test-conn", "target", e.Name, "profile", profile.Value.String(), "err", err)
+	}
+}
+
+// forgetTested keeps last-test times with the profile that passed: a
+// deleted profile's time is dropped, a renamed one's moves to the new name,
+// and a profile overwritten by `copy --force` loses its own.
+func forgetTested(c *cobra.Command) {
+	e, ok := commandTarget(c)
+	if !ok {
+		return
+	}
+	times, err := lasttest.Load()
+	if err != nil {
+		return
+	}
+	var changed bool
+	switch c.Name() {
+	case "delete":
+		profile := flagValue(c, "profile")
+		// delete returns nil when the confirm is declined, too.
+		if saved, err := e.Saved(profile); err != nil || saved {
+			return
+		}
+		changed = times.Remove(e.Name, profile)
+	case "rename":
+		changed = times.Move(e.Name, flagValue(c, "from"), flagValue(c, "to"))
+	case "copy":
+		changed = times.Remove(e.Name, flagValue(c, "to"))
+	}
+	if !changed {
+		return
+	}
+	if err := times.Save(); err != nil {
+		logger.L.Warnw("failed to update test-conn times", "target", e.Name, "err", err)
+	}
+}
+
+// forgetReplaced drops the last-test times of profiles a restore or import
+// overwrote, since the time was earned by what they held before.
+func forgetReplaced(steps []bundle.Step) {
+	times, err := lasttest.Load()
+	if err != nil {
+		return
+	}
+	var changed bool
+	for _, st := range steps {
+		if st.Action == bundle.ActionOverwrite && times.Remove(st.Target, st.As) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := times.Save(); err != nil {
+		logger.L.Warnw("failed to update test-conn times", "err", err)
+	}
+}
+
+// commandTarget returns the exporter of the plugin a sub-command such as
+// `rdv db postgres modify` belongs to.
+func commandTarget(c *cobra.Command) (*plugin.Exporter, bool) {
+	if c.Parent() == nil || !c.Parent().HasParent() {
+		return nil, false
+	}
+	// rdv db postgres test-conn -> db.postgres
+	path := strings.Fields(c.Parent().CommandPath())
+	return plugin.LookupExporter(strings.Join(path[1:], "."))
+}
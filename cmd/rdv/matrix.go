@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	execenv "github.com/yonasyiheyis/rdv/internal/exec"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	iprint "github.com/yonasyiheyis/rdv/internal/print"
+)
+
+// matrixResult is one run in the `exec --matrix` summary.
+type matrixResult struct {
+	Label      string            `json:"label"`
+	Profiles   map[string]string `json:"profiles"`
+	ExitCode   int               `json:"exit_code"`
+	DurationMS int64             `json:"duration_ms"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// runMatrix runs args once per combo, at most parallel at a time, with
+// every output line prefixed by the combo's label, then prints a summary.
+// It fails with the exit code of the first failed run in matrix order.
+func runMatrix(args []string, combos []execenv.Combo, sh secretHandling, parallel int, processGroup bool) error {
+	if parallel < 1 {
+		return exitcodes.New(exitcodes.InvalidArgs, "--parallel must be at least 1")
+	}
+
+	// With --json, stdout carries only the summary; child stdout moves to stderr.
+	var out io.Writer = os.Stdout
+	if iprint.JSON {
+		out = os.Stderr
+	}
+	width := 0
+	for _, c := range combos {
+		width = max(width, len(c.Label))
+	}
+
+	results := make([]matrixResult, len(combos))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+	for i, c := range combos {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			prefix := fmt.Sprintf("[%-*s] ", width, c.Label)
+			stdout := execenv.NewPrefixWriter(out, &mu, prefix)
+			stderr := execenv.NewPrefixWriter(os.Stderr, &mu, prefix)
+			results[i] = runCombo(args, c, sh, execenv.RunOptions{
+				ProcessGroup: processGroup,
+				Stdin:        bytes.NewReader(nil), // runs must not fight over the terminal
+				Stdout:       stdout,
+				Stderr:       stderr,
+			})
+			_ = stdout.Flush()
+			_ = stderr.Flush()
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	var first error
+	for _, r := range results {
+		if r.ExitCode != 0 {
+			failed++
+			if first == nil {
+				first = exitcodes.WithCode(r.ExitCode)
+			}
+		}
+	}
+
+	if iprint.JSON {
+		if err := iprint.Out(map[string]any{"runs": results, "failed": failed}); err != nil {
+			return exitcodes.Wrap(exitcodes.JSONError, err)
+		}
+		return first
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "\nRUN\tEXIT\tDURATION")
+	for _, r := range results {
+		line := fmt.Sprintf("%s\t%d\t%s", r.Label, r.ExitCode, time.Duration(r.DurationMS)*time.Millisecond)
+		if r.Error != "" {
+			line += "\t" + r.Error
+		}
+		_, _ = fmt.Fprintln(tw, line)
+	}
+	_ = tw.Flush()
+	fmt.Printf("%d/%d runs succeeded\n", len(results)-failed, len(results))
+	return first
+}
+
+// runCombo builds the env for one combo and runs the command in it.
+func runCombo(args []string, c execenv.Combo, sh secretHandling, ro execenv.RunOptions) (res matrixResult) {
+	res = matrixResult{Label: c.Label, Profiles: c.Profiles}
+	start := time.Now()
+	defer func() { res.DurationMS = time.Since(start).Milliseconds() }()
+
+	env, ro, cleanup, err := prepareChild(c.Options, sh, ro)
+	if err != nil {
+		res.ExitCode, res.Error = exitcodes.FromError(err), exitcodes.Message(err)
+		return res
+	}
+	defer cleanup()
+
+	code, err := execenv.Run(args, env, ro)
+	if err != nil {
+		res.ExitCode, res.Error = exitcodes.ChildSpawnFailed, err.Error()
+		return res
+	}
+	res.ExitCode = code
+	return res
+}
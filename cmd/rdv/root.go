@@ -19,9 +19,18 @@ import (
 
 	// --- side‑effect plugin imports ---
 	_ "github.com/yonasyiheyis/rdv/internal/plugins/aws"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/azure"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/custom"
 	_ "github.com/yonasyiheyis/rdv/internal/plugins/db"
 	_ "github.com/yonasyiheyis/rdv/internal/plugins/gcp"
 	_ "github.com/yonasyiheyis/rdv/internal/plugins/github"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/k8s"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/kafka"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/mongo"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/pkg"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/redis"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/registry"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/ssh"
 )
 
 var (
@@ -36,7 +45,7 @@ func newRootCmd() *cobra.Command {
 		Use:   "rdv",
 		Short: "ReadyDev (rdv) – interactive dev‑env config manager",
 		Long: `rdv (readyDev) is a plugin‑based CLI that sets, modifies,
-deletes, and exports configuration for AWS, databases, GitHub, and more.`,
+deletes, and exports configuration for AWS, Azure, databases, Redis, Kafka, GitHub, and more.`,
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		Version:       version.Version,
@@ -53,6 +62,9 @@ deletes, and exports configuration for AWS, databases, GitHub, and more.`,
 
 	/* ---------- initialise logger AFTER flags parsed ---------- */
 	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := guardShared(cmd); err != nil {
+			return err
+		}
 		if log != nil { // already initialised (nested sub‑command)
 			return nil
 		}
@@ -73,6 +85,13 @@ deletes, and exports configuration for AWS, databases, GitHub, and more.`,
 		return nil
 	}
 
+	// Post-run: only reached when the command succeeded.
+	cmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		recordTestConn(cmd)
+		forgetTested(cmd)
+		forgetShared(cmd)
+	}
+
 	// Pre‑run: init Viper and (future) logger
 	cobra.OnInitialize(initConfig)
 
@@ -81,6 +100,13 @@ deletes, and exports configuration for AWS, databases, GitHub, and more.`,
 	cmd.AddCommand(newCompletionCmd())
 	cmd.AddCommand(newEnvCmd())
 	cmd.AddCommand(newExecCmd())
+	cmd.AddCommand(newShellCmd())
+	cmd.AddCommand(newStatusCmd())
+	cmd.AddCommand(newHookCmd())
+	cmd.AddCommand(newListCmd())
+	cmd.AddCommand(newDoctorCmd())
+	cmd.AddCommand(newBackupCmd())
+	cmd.AddCommand(newShareCmd())
 
 	// ----- Load plugin sub‑commands -----
 	plugin.LoadAll(cmd)
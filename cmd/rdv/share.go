@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"filippo.io/age"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/yonasyiheyis/rdv/internal/bundle"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/logger"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	iprint "github.com/yonasyiheyis/rdv/internal/print"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
+	"github.com/yonasyiheyis/rdv/internal/shared"
+)
+
+// identityPath returns ~/.config/rdv/share/identity.txt, the age key
+// `rdv share import` decrypts with by default.
+func identityPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv", "share", "identity.txt")
+}
+
+func newShareCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "share",
+		Short: "Share profiles with teammates, encrypted to their public keys",
+		Long: `Encrypt a few profiles to your teammates' age X25519 public keys, and
+import the bundles they send you. Imported profiles are marked as shared:
+rdv won't share them on (nor profiles extending them), copies of them stay
+marked, and set-config, modify, copy --to and rename can't change them until
+they are unmarked.
+
+Each teammate runs "rdv share keygen" once and adds the printed public key
+to the team's recipients file.`,
+	}
+	cmd.AddCommand(newShareKeygenCmd(), newShareExportCmd(), newShareImportCmd(), newShareListCmd(), newShareUnmarkCmd())
+	return cmd
+}
+
+func newShareKeygenCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "keygen",
+		Short: "Create your share key (once) and print its public key",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			path := identityPath()
+			created := false
+			ids, err := readIdentities(path)
+			if exitcodes.FromError(err) == exitcodes.InvalidArgs {
+				if ids, err = generateIdentity(path); err != nil {
+					return err
+				}
+				created = true
+			} else if err != nil {
+				return err
+			}
+			x, ok := ids[0].(*age.X25519Identity)
+			if !ok {
+				return exitcodes.New(exitcodes.ConfigReadWrite, fmt.Sprintf("%s doesn't hold an X25519 key", path))
+			}
+			pub := x.Recipient().String()
+
+			if iprint.JSON {
+				if err := iprint.Out(map[string]any{"identity": path, "public_key": pub, "created": created}); err != nil {
+					return exitcodes.Wrap(exitcodes.JSONError, err)
+				}
+				return nil
+			}
+			if created {
+				fmt.Printf("✅ Created %s (keep it private)\n", path)
+			}
+			fmt.Println("Public key (add it to your team's recipients file):")
+			fmt.Println(pub)
+			return nil
+		},
+	}
+}
+
+// generateIdentity writes a new X25519 key to path in age-keygen's format.
+func generateIdentity(path string) ([]age.Identity, error) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	out := fmt.Sprintf("# created: %s\n# public key: %s\n%s\n", time.Now().Format(time.RFC3339), id.Recipient(), id)
+	if err := os.WriteFile(path, []byte(out), 0o600); err != nil {
+		return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return []age.Identity{id}, nil
+}
+
+// readIdentities parses an age identity file; a missing one is InvalidArgs.
+func readIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("no identity at %s (run `rdv share keygen` or pass --identity)", path))
+		}
+		return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	defer f.Close()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("%s: %w", path, err))
+	}
+	return ids, nil
+}
+
+func newShareExportCmd() *cobra.Command {
+	var recipientFiles, recipients, sets []string
+	var out string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Encrypt profiles to your teammates' public keys",
+		Long: `Encrypt the given profiles to every recipient and write the bundle to
+stdout (or --out). Profiles are exported with their extends chain folded in,
+so teammates don't need the bases; ${VAR} references stay unexpanded.`,
+		Example: `  rdv share export --recipients team.pub --set db.postgres:staging > shared.rdv
+  rdv share export --recipient age1... --set aws:localstack --set github:bot --out shared.rdv`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if len(sets) == 0 {
+				return exitcodes.New(exitcodes.InvalidArgs, "at least one --set is required (e.g., --set db.postgres:staging)")
+			}
+			if out == "" && iprint.JSON {
+				return exitcodes.New(exitcodes.InvalidArgs, "--json needs --out (stdout carries the bundle)")
+			}
+			if out == "" && term.IsTerminal(int(os.Stdout.Fd())) {
+				return exitcodes.New(exitcodes.InvalidArgs, "refusing to write an encrypted bundle to the terminal; redirect stdout or use --out")
+			}
+			return runShareExport(sets, recipientFiles, recipients, out)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&recipientFiles, "recipients", nil, "file of age public keys, one per line (repeatable)")
+	cmd.Flags().StringArrayVar(&recipients, "recipient", nil, "age public key (age1...) to encrypt to (repeatable)")
+	cmd.Flags().StringArrayVar(&sets, "set", nil, "profile spec: aws:<name> | gcp:<name> | db.<engine>:<name> | github:<name> (repeatable)")
+	cmd.Flags().StringVar(&out, "out", "", "write the bundle to this file instead of stdout")
+	return cmd
+}
+
+func runShareExport(sets, recipientFiles, keys []string, out string) error {
+	recipients, err := parseRecipients(recipientFiles, keys)
+	if err != nil {
+		return err
+	}
+	marks, err := shared.Load()
+	if err != nil {
+		return err
+	}
+
+	var entries []bundle.Entry
+	seen := map[string]bool{}
+	for _, s := range sets {
+		target, profile, ok := strings.Cut(s, ":")
+		target, profile = strings.TrimSpace(target), strings.TrimSpace(profile)
+		if !ok || target == "" || profile == "" {
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid --set %q, expected <plugin>[:subplugin]:<profile>", s))
+		}
+		e, ok := plugin.LookupExporter(target)
+		if !ok {
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("unknown target %q (expected %s)", target, strings.Join(plugin.ExporterNames(), "|")))
+		}
+		if e.Store == nil {
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("%s profiles can't be shared", e.Name))
+		}
+		if err := checkNotShared(marks, e.Name, e.Store, profile); err != nil {
+			return err
+		}
+		if seen[e.Name+":"+profile] {
+			continue
+		}
+		seen[e.Name+":"+profile] = true
+		es, err := bundle.CollectResolved(e.Name, profile)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, es...)
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, entries, recipients...); err != nil {
+		return err
+	}
+	if out == "" {
+		if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+			return exitcodes.Wrap(exitcodes.EnvWriteFailed, err)
+		}
+		fmt.Fprintf(os.Stderr, "✅ Shared %d profile(s) with %d recipient(s)\n", len(entries), len(recipients))
+		return nil
+	}
+	if err := os.WriteFile(out, buf.Bytes(), 0o600); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	logger.L.Infow("share bundle written", "path", out, "profiles", len(entries), "recipients", len(recipients))
+	if iprint.JSON {
+		if err := iprint.Out(map[string]any{"path": out, "profiles": len(entries), "recipients": len(recipients)}); err != nil {
+			return exitcodes.Wrap(exitcodes.JSONError, err)
+		}
+		return nil
+	}
+	fmt.Printf("✅ Shared %d profile(s) with %d recipient(s) in %s\n", len(entries), len(recipients), out)
+	return nil
+}
+
+// checkNotShared refuses to export profile when it, or a base it extends
+// and would be folded into it, came from a shared bundle.
+func checkNotShared(marks shared.Marks, target string, s profilestore.Store, profile string) error {
+	chain, err := resolve.Chain(profile, func(n string) (string, error) {
+		f, err := s.Get(n)
+		if err != nil {
+			return "", err
+		}
+		return f[profilestore.ExtendsField], nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, n := range chain {
+		mk, ok := marks.Get(target, n)
+		switch {
+		case !ok:
+			continue
+		case n == profile:
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("%s:%s came from a shared bundle (%s); only its owner should share it", target, profile, orDash(mk.Bundle)))
+		default:
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("%s:%s extends %s, which came from a shared bundle (%s); only its owner should share it", target, profile, n, orDash(mk.Bundle)))
+		}
+	}
+	return nil
+}
+
+// parseRecipients reads age X25519 public keys from files and flags.
+func parseRecipients(files, keys []string) ([]age.Recipient, error) {
+	var out []age.Recipient
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		rs, err := age.ParseRecipients(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, exitcodes.Wrap(exitcodes.InvalidArgs, fmt.Errorf("%s: %w", path, err))
+		}
+		out = append(out, rs...)
+	}
+	for _, k := range keys {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(k))
+		if err != nil {
+			return nil, exitcodes.Wrap(exitcodes.InvalidArgs, err)
+		}
+		out = append(out, r)
+	}
+	if len(out) == 0 {
+		return nil, exitcodes.New(exitcodes.InvalidArgs, "no recipients: use --recipients <file> or --recipient age1...")
+	}
+	return out, nil
+}
+
+func newShareImportCmd() *cobra.Command {
+	var identity, onConflict string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Merge a shared bundle into the local stores",
+		Long: `Decrypt a shared bundle with your key and merge its profiles into the local
+stores, marking them as shared. Profiles from an earlier import are updated;
+any other profile with the same name is kept (--on-conflict skip, the
+default), replaced (overwrite) or imported as <name>-restored (rename).
+Use - to read the bundle from stdin.
+
+Only the settings each plugin manages are imported; anything else in a
+bundled profile (an AWS credential_process, say) is dropped and listed.
+--dry-run shows the fields each profile would get, secrets redacted.`,
+		Example: `  rdv share import shared.rdv --dry-run
+  rdv share import shared.rdv --on-conflict rename
+  rdv share import shared.rdv --identity ~/keys/age.txt`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			policy, err := bundle.ParsePolicy(onConflict)
+			if err != nil {
+				return err
+			}
+			if identity == "" {
+				identity = identityPath()
+			}
+			return runShareImport(args[0], identity, policy, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVarP(&identity, "identity", "i", "", "age identity file to decrypt with (default ~/.config/rdv/share/identity.txt)")
+	cmd.Flags().StringVar(&onConflict, "on-conflict", string(bundle.Skip), "when a local profile has the same name: skip, overwrite or rename")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list what would be imported without writing anything")
+	return cmd
+}
+
+func runShareImport(file, identity string, policy bundle.Policy, dryRun bool) error {
+	ids, err := readIdentities(identity)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		defer f.Close()
+		r = f
+	}
+	m, entries, err := bundle.Read(r, ids...)
+	if err != nil {
+		return err
+	}
+
+	marks, err := shared.Load()
+	if err != nil {
+		return err
+	}
+	steps, err := bundle.Plan(entries, policy, func(target, profile string) bool {
+		_, ok := marks.Get(target, profile)
+		return ok
+	})
+	if err != nil {
+		return err
+	}
+	steps = bundle.Restrict(steps)
+	if !dryRun {
+		if err := bundle.Apply(steps); err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, st := range steps {
+			if st.Action != bundle.ActionSkip {
+				marks.Set(st.Target, st.As, filepath.Base(file), now)
+			}
+		}
+		if err := marks.Save(); err != nil {
+			return err
+		}
+		forgetReplaced(steps)
+		logger.L.Infow("share bundle imported", "path", file, "profiles", len(steps))
+	}
+	return printSteps(steps, m, dryRun, "Imported")
+}
+
+func newShareListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List profiles imported from shared bundles",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			marks, err := shared.Load()
+			if err != nil {
+				return err
+			}
+			type row struct {
+				Plugin   string    `json:"plugin"`
+				Profile  string    `json:"profile"`
+				Imported time.Time `json:"imported"`
+				Bundle   string    `json:"bundle,omitempty"`
+			}
+			rows := []row{}
+			for _, t := range slices.Sorted(maps.Keys(marks)) {
+				for _, p := range slices.Sorted(maps.Keys(marks[t])) {
+					rows = append(rows, row{Plugin: t, Profile: p, Imported: marks[t][p].Imported, Bundle: marks[t][p].Bundle})
+				}
+			}
+
+			if iprint.JSON {
+				if err := iprint.Out(map[string]any{"profiles": rows}); err != nil {
+					return exitcodes.Wrap(exitcodes.JSONError, err)
+				}
+				return nil
+			}
+			if len(rows) == 0 {
+				fmt.Println("(no shared profiles)")
+				return nil
+			}
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(tw, "PLUGIN\tPROFILE\tIMPORTED\tBUNDLE")
+			for _, r := range rows {
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Plugin, r.Profile, shortTime(r.Imported), orDash(r.Bundle))
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func newShareUnmarkCmd() *cobra.Command {
+	var sets []string
+
+	cmd := &cobra.Command{
+		Use:     "unmark",
+		Short:   "Make imported profiles your own so they can be edited and shared",
+		Example: `  rdv share unmark --set db.postgres:staging`,
+		Args:    cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if len(sets) == 0 {
+				return exitcodes.New(exitcodes.InvalidArgs, "at least one --set is required (e.g., --set db.postgres:staging)")
+			}
+			marks, err := shared.Load()
+			if err != nil {
+				return err
+			}
+			for _, s := range sets {
+				target, profile, _ := strings.Cut(s, ":")
+				if e, ok := plugin.LookupExporter(strings.TrimSpace(target)); ok {
+					target = e.Name
+				}
+				if !marks.Remove(target, strings.TrimSpace(profile)) {
+					return exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("%s is not a shared profile", s))
+				}
+			}
+			if err := marks.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("✅ Unmarked %d profile(s)\n", len(sets))
+			return nil
+		},
+	}
+	cmd.Flags().StringArrayVar(&sets, "set", nil, "profile spec, e.g. db.postgres:staging (repeatable)")
+	return cmd
+}
+
+// sharedEdits maps the plugin commands that change a saved profile to the
+// flags naming it.
+var sharedEdits = map[string][]string{
+	"set-config": {"profile"},
+	"modify":     {"profile"},
+	"set":        {"profile"}, // custom
+	"unset":      {"profile"},
+	"copy":       {"to"},
+	"rename":     {"from", "to"},
+}
+
+// guardShared stops commands from editing a profile imported from a shared
+// bundle; its owner's next bundle would silently replace the edit.
+func guardShared(c *cobra.Command) error {
+	flags, ok := sharedEdits[c.Name()]
+	if !ok {
+		return nil
+	}
+	e, ok := commandTarget(c)
+	if !ok {
+		return nil
+	}
+	marks, err := shared.Load()
+	if err != nil {
+		return err
+	}
+	for _, name := range flags {
+		f := c.Flags().Lookup(name)
+		if f == nil {
+			continue
+		}
+		if mk, ok := marks.Get(e.Name, f.Value.String()); ok {
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf(
+				"%s profile %q came from a shared bundle (%s); import a newer one, or run `rdv share unmark --set %s:%s` to edit it locally",
+				e.Name, f.Value.String(), orDash(mk.Bundle), e.Name, f.Value.String()))
+		}
+	}
+	return nil
+}
+
+// forgetShared drops the shared mark of a profile that was just deleted,
+// and marks the copy of a shared profile as shared too, so copying it can't
+// strip the mark. delete also succeeds when its confirm is declined, so the
+// mark is only dropped once the profile is really gone.
+func forgetShared(c *cobra.Command) {
+	var from, to string
+	switch c.Name() {
+	case "delete":
+		from = flagValue(c, "profile")
+	case "copy":
+		from, to = flagValue(c, "from"), flagValue(c, "to")
+	default:
+		return
+	}
+	e, ok := commandTarget(c)
+	if from == "" || !ok {
+		return
+	}
+	marks, err := shared.Load()
+	if err != nil {
+		return
+	}
+	mk, ok := marks.Get(e.Name, from)
+	if !ok {
+		return
+	}
+	if to == "" {
+		if saved, err := e.Saved(from); err != nil || saved {
+			return
+		}
+		marks.Remove(e.Name, from)
+	} else {
+		marks.Set(e.Name, to, mk.Bundle, mk.Imported)
+	}
+	if err := marks.Save(); err != nil {
+		logger.L.Warnw("failed to update shared marks", "target", e.Name, "profile", from, "err", err)
+	}
+}
+
+// flagValue returns the value of c's flag name, or "" if it has none.
+func flagValue(c *cobra.Command, name string) string {
+	if f := c.Flags().Lookup(name); f != nil {
+		return f.Value.String()
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/bundle"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	"github.com/yonasyiheyis/rdv/internal/shared"
+)
+
+// fakeStore keeps profiles in memory for the test.fake target.
+type fakeStore map[string]map[string]string
+
+func (s fakeStore) Names() ([]string, error) { return slices.Sorted(maps.Keys(s)), nil }
+func (s fakeStore) Get(name string) (map[string]string, error) {
+	p, ok := s[name]
+	if !ok {
+		return nil, exitcodes.New(exitcodes.ProfileNotFound, "not found")
+	}
+	return p, nil
+}
+func (s fakeStore) Put(name string, fields map[string]string) error { s[name] = fields; return nil }
+func (s fakeStore) Delete(name string) error                        { delete(s, name); return nil }
+func (s fakeStore) Secret(string) bool                              { return false }
+func (s fakeStore) Fields() []string                                { return []string{"host"} }
+
+var fakeProfiles = fakeStore{}
+
+func init() {
+	plugin.RegisterExporter(plugin.Exporter{Name: "test.fake", Store: fakeProfiles})
+}
+
+// fakeDelete returns `rdv test fake delete -p profile`, ready for the
+// post-run hooks.
+func fakeDelete(t *testing.T, profile string) *cobra.Command {
+	t.Helper()
+	del := &cobra.Command{Use: "delete"}
+	del.Flags().StringP("profile", "p", "default", "")
+	require.NoError(t, del.Flags().Set("profile", profile))
+	fake := &cobra.Command{Use: "fake"}
+	fake.AddCommand(del)
+	group := &cobra.Command{Use: "test"}
+	group.AddCommand(fake)
+	(&cobra.Command{Use: "rdv"}).AddCommand(group)
+	return del
+}
+
+func TestForgetSharedOnlyAfterDelete(t *testing.T) {
+	t.Setenv("RDV_STATE_DIR", t.TempDir())
+	fakeProfiles["team"] = map[string]string{"host": "db"}
+	marks := shared.Marks{}
+	marks.Set("test.fake", "team", "team.rdv", time.Now())
+	require.NoError(t, marks.Save())
+	marked := func() bool {
+		m, err := shared.Load()
+		require.NoError(t, err)
+		_, ok := m.Get("test.fake", "team")
+		return ok
+	}
+
+	// A declined confirm leaves the profile, and so its mark, in place.
+	forgetShared(fakeDelete(t, "team"))
+	require.True(t, marked())
+
+	require.NoError(t, fakeProfiles.Delete("team"))
+	forgetShared(fakeDelete(t, "team"))
+	require.False(t, marked())
+}
+
+func TestBackupKeepsSharedMarks(t *testing.T) {
+	t.Setenv("RDV_STATE_DIR", t.TempDir())
+	clear(fakeProfiles)
+	fakeProfiles["team"] = map[string]string{"host": "db"}
+	fakeProfiles["mine"] = map[string]string{"host": "local"}
+	marks := shared.Marks{}
+	marks.Set("test.fake", "team", "team.rdv", time.Now())
+	require.NoError(t, marks.Save())
+
+	entries, err := bundle.Collect("test.fake")
+	require.NoError(t, err)
+	require.NoError(t, addSharedMarks(entries))
+
+	// On another machine "mine" was imported from a share.
+	marks = shared.Marks{}
+	marks.Set("test.fake", "mine", "other.rdv", time.Now())
+	require.NoError(t, marks.Save())
+
+	steps, err := bundle.Plan(entries, bundle.Overwrite, nil)
+	require.NoError(t, err)
+	require.NoError(t, bundle.Apply(steps))
+	require.NoError(t, restoreSharedMarks(steps))
+
+	marks, err = shared.Load()
+	require.NoError(t, err)
+	mk, ok := marks.Get("test.fake", "team")
+	require.True(t, ok, "a restored shared profile stays marked")
+	require.Equal(t, "team.rdv", mk.Bundle)
+	_, ok = marks.Get("test.fake", "mine")
+	require.False(t, ok, "the user's own profile replaced the shared one")
+}
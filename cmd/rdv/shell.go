@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	execenv "github.com/yonasyiheyis/rdv/internal/exec"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	iprint "github.com/yonasyiheyis/rdv/internal/print"
+)
+
+func newShellCmd() *cobra.Command {
+	var opts execenv.Options
+	var shell string
+	var nested bool
+	var profiles func() map[string]string
+
+	cmd := &cobra.Command{
+		Use:   "shell",
+		Short: "Start a subshell with env from selected profiles",
+		Long: `Start $SHELL with env vars injected from saved profiles, like exec but
+interactive. Leave with exit (or Ctrl-D); the parent shell is untouched.
+
+The subshell gets RDV_ACTIVE=aws:dev,pg:dev listing what is loaded (for your
+prompt, or see rdv status) and RDV_SHELL_LEVEL counting nested rdv shells.
+
+Examples:
+  rdv shell --aws dev --pg dev
+  rdv shell --k8s staging --shell zsh
+  rdv shell --nested --github bot   # from inside another rdv shell`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			opts.Profiles = profiles()
+			sel := opts.Selections()
+			if len(sel) == 0 {
+				return exitcodes.New(exitcodes.InvalidArgs, "nothing to load: pass one of "+strings.Join(asFlags(execenv.ProfileFlagNames(), ""), ", "))
+			}
+
+			outer := os.Getenv(execenv.ActiveVar)
+			level := execenv.ShellLevel(os.Getenv(execenv.LevelVar))
+			if (outer != "" || level > 0) && !nested {
+				return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("already inside an rdv shell (%s); exit it first or pass --nested", outer))
+			}
+
+			opts.SetEnv = map[string]string{
+				execenv.ActiveVar: execenv.FormatActive(execenv.MergeActive(execenv.ParseActive(outer), sel)),
+				execenv.LevelVar:  strconv.Itoa(level + 1),
+			}
+			env, ro, cleanup, err := prepareChild(opts, secretHandling{}, execenv.RunOptions{})
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			if shell == "" {
+				shell = defaultShell()
+			}
+			code, err := execenv.Run([]string{shell}, env, ro)
+			if err != nil {
+				return exitcodes.Wrap(exitcodes.ChildSpawnFailed, err)
+			}
+			if code != 0 {
+				return exitcodes.WithCode(code)
+			}
+			return nil
+		},
+	}
+
+	profiles = addProfileFlags(cmd, "load")
+	cmd.Flags().StringVar(&shell, "shell", "", "shell to start (default: $SHELL, or %COMSPEC% on Windows)")
+	cmd.Flags().BoolVar(&nested, "nested", false, "allow starting inside another rdv shell; its profiles stay active unless overridden")
+	return cmd
+}
+
+// addProfileFlags adds a --<name> PROFILE selector for each of
+// execenv.ProfileFlags; verb says what is done with the profile, e.g.
+// "inject". The returned func collects the ones given, flag -> profile.
+func addProfileFlags(cmd *cobra.Command, verb string) func() map[string]string {
+	vals := map[string]*string{}
+	for _, f := range execenv.ProfileFlags {
+		usage := f.Label + " to " + verb
+		if f.Note != "" {
+			usage += " (" + f.Note + ")"
+		}
+		vals[f.Name] = cmd.Flags().String(f.Name, "", usage)
+	}
+	return func() map[string]string {
+		out := map[string]string{}
+		for k, v := range vals {
+			if *v != "" {
+				out[k] = *v
+			}
+		}
+		return out
+	}
+}
+
+// asFlags renders names as command-line flags, "--<name><arg>".
+func asFlags(names []string, arg string) []string {
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = "--" + n + arg
+	}
+	return out
+}
+
+func defaultShell() string {
+	if s := os.Getenv("SHELL"); s != "" {
+		return s
+	}
+	if runtime.GOOS == "windows" {
+		if s := os.Getenv("COMSPEC"); s != "" {
+			return s
+		}
+		return "cmd.exe"
+	}
+	return "/bin/sh"
+}
+
+func newStatusCmd() *cobra.Command {
+	var short bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the profiles loaded in the current rdv shell",
+		Example: `  rdv status
+  rdv status --short     # aws:dev,pg:dev, or nothing; for prompts
+  rdv status --json`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			active := os.Getenv(execenv.ActiveVar)
+			sel := execenv.ParseActive(active)
+			level := execenv.ShellLevel(os.Getenv(execenv.LevelVar))
+
+			if short {
+				if active != "" {
+					fmt.Println(active)
+				}
+				return nil
+			}
+
+			if iprint.JSON {
+				profiles := make([]map[string]string, 0, len(sel))
+				for _, s := range sel {
+					profiles = append(profiles, map[string]string{"plugin": s.Flag, "profile": s.Profile})
+				}
+				if err := iprint.Out(map[string]any{"active": len(sel) > 0, "level": level, "profiles": profiles}); err != nil {
+					return exitcodes.Wrap(exitcodes.JSONError, err)
+				}
+				return nil
+			}
+
+			if len(sel) == 0 {
+				fmt.Println("not in an rdv shell")
+				return nil
+			}
+			fmt.Printf("rdv shell (level %d)\n", level)
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, s := range sel {
+				_, _ = fmt.Fprintf(tw, "  %s\t%s\n", s.Flag, s.Profile)
+			}
+			return tw.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&short, "short", false, "print the raw RDV_ACTIVE value only")
+	return cmd
+}
@@ -0,0 +1,256 @@
+// Package bundle packs saved profiles from several plugins into one
+// age-encrypted tar, so `rdv backup` and `rdv share` can carry them to
+// another machine and merge them back into the local stores.
+package bundle
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
+	"github.com/yonasyiheyis/rdv/internal/shared"
+)
+
+// Version is the bundle layout this rdv writes and the newest it reads.
+const Version = 1
+
+const manifestName = "manifest.json"
+
+// Manifest describes a bundle as a whole.
+type Manifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// Entry is one saved profile in a bundle.
+type Entry struct {
+	Target  string            `json:"target"`
+	Profile string            `json:"profile"`
+	Fields  map[string]string `json:"fields"`
+	// Files holds the content of files the profile owns, keyed by the
+	// field that points at them (see profilestore.FileStore).
+	Files map[string][]byte `json:"files,omitempty"`
+	// Shared is the profile's mark if it was imported from a shared bundle.
+	// Only backups set it, so a restored profile stays marked.
+	Shared *shared.Mark `json:"shared,omitempty"`
+}
+
+// Collect reads the named profiles of target, or every saved one when
+// names is empty.
+func Collect(target string, names ...string) ([]Entry, error) {
+	return collect(target, names, false)
+}
+
+// CollectResolved is Collect for profiles leaving the machine: each one's
+// extends chain is folded into its own fields, so it stands alone without
+// the bases it was built on.
+func CollectResolved(target string, names ...string) ([]Entry, error) {
+	return collect(target, names, true)
+}
+
+func collect(target string, names []string, flatten bool) ([]Entry, error) {
+	e, ok := plugin.LookupExporter(target)
+	if !ok || e.Store == nil {
+		return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("%s profiles can't be bundled", target))
+	}
+	if len(names) == 0 {
+		var err error
+		if names, err = e.Store.Names(); err != nil {
+			return nil, err
+		}
+	}
+
+	var fileFields []string
+	if fs, ok := e.Store.(profilestore.FileStore); ok {
+		fileFields = fs.FileFields()
+	}
+	entries := make([]Entry, 0, len(names))
+	for _, n := range names {
+		var fields map[string]string
+		var err error
+		if flatten {
+			fields, err = resolved(e.Store, n)
+		} else {
+			fields, err = e.Store.Get(n)
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := Entry{Target: e.Name, Profile: n, Fields: fields}
+		for _, f := range fileFields {
+			if fields[f] == "" {
+				continue
+			}
+			b, err := os.ReadFile(fields[f])
+			if err != nil {
+				return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("%s profile %q: %w", e.Name, n, err))
+			}
+			if entry.Files == nil {
+				entry.Files = map[string][]byte{}
+			}
+			entry.Files[f] = b
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// resolved merges the stored fields of name's extends chain, nearest
+// profile winning, and drops the extends field itself. When some of the
+// chain opts in to interpolation the result does too, with the values of
+// the rest escaped so they stay literal.
+func resolved(s profilestore.Store, name string) (map[string]string, error) {
+	chain, err := resolve.Chain(name, func(n string) (string, error) {
+		f, err := s.Get(n)
+		if err != nil {
+			return "", err
+		}
+		return f[profilestore.ExtendsField], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	stored := make([]map[string]string, len(chain))
+	interp := false
+	for i, n := range chain {
+		if stored[i], err = s.Get(n); err != nil {
+			return nil, err
+		}
+		interp = interp || stored[i][resolve.InterpolateField] == "true"
+	}
+	fields := map[string]string{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		own := stored[i][resolve.InterpolateField] == "true"
+		for k, v := range stored[i] {
+			if v == "" {
+				continue
+			}
+			if interp && !own {
+				v = resolve.Literal(v)
+			}
+			fields[k] = v
+		}
+	}
+	delete(fields, profilestore.ExtendsField)
+	if interp {
+		fields[resolve.InterpolateField] = "true"
+	}
+	return fields, nil
+}
+
+// Write tars entries and encrypts the result to recipients.
+func Write(w io.Writer, entries []Entry, recipients ...age.Recipient) error {
+	aw, err := age.Encrypt(w, recipients...)
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	tw := tar.NewWriter(aw)
+	add := func(name string, v any) error {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return exitcodes.Wrap(exitcodes.JSONError, err)
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(b)), ModTime: now}); err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		if _, err := tw.Write(b); err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		return nil
+	}
+
+	if err := add(manifestName, Manifest{Version: Version, Created: now}); err != nil {
+		return err
+	}
+	for i, e := range entries {
+		// The name only helps people listing the tar; Read goes by content.
+		name := fmt.Sprintf("profiles/%03d-%s-%s.json", i, e.Target, strings.ReplaceAll(e.Profile, "/", "_"))
+		if err := add(name, e); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if err := aw.Close(); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return nil
+}
+
+// Read decrypts a bundle with identities and returns its manifest and
+// entries.
+func Read(r io.Reader, identities ...age.Identity) (Manifest, []Entry, error) {
+	var m Manifest
+	ar, err := age.Decrypt(r, identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return m, nil, exitcodes.New(exitcodes.InvalidArgs, "can't decrypt bundle: wrong passphrase or key")
+		}
+		return m, nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("can't decrypt bundle: %w", err))
+	}
+
+	var entries []Entry
+	tr := tar.NewReader(ar)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("corrupt bundle: %w", err))
+		}
+		switch {
+		case hdr.Name == manifestName:
+			if err := json.NewDecoder(tr).Decode(&m); err != nil {
+				return m, nil, exitcodes.Wrap(exitcodes.JSONError, fmt.Errorf("corrupt bundle manifest: %w", err))
+			}
+		case path.Dir(hdr.Name) == "profiles":
+			var e Entry
+			if err := json.NewDecoder(tr).Decode(&e); err != nil {
+				return m, nil, exitcodes.Wrap(exitcodes.JSONError, fmt.Errorf("corrupt bundle entry %s: %w", hdr.Name, err))
+			}
+			if e.Target == "" || e.Profile == "" {
+				return m, nil, exitcodes.New(exitcodes.JSONError, fmt.Sprintf("corrupt bundle entry %s: missing target or profile", hdr.Name))
+			}
+			if err := validNames(e); err != nil {
+				return m, nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("bundle entry %s: %v", hdr.Name, err))
+			}
+			entries = append(entries, e)
+		}
+	}
+
+	switch {
+	case m.Version == 0:
+		return m, nil, exitcodes.New(exitcodes.InvalidArgs, "not an rdv bundle (no manifest)")
+	case m.Version > Version:
+		return m, nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("bundle version %d is newer than this rdv supports (%d); upgrade rdv", m.Version, Version))
+	}
+	return m, entries, nil
+}
+
+// validNames checks the profile names an entry would write or point at, so
+// a bundle can't reach outside the stores with names like "../../x".
+func validNames(e Entry) error {
+	if err := profilestore.ValidName(e.Profile); err != nil {
+		return err
+	}
+	if base := e.Fields[profilestore.ExtendsField]; base != "" {
+		return profilestore.ValidName(base)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package bundle
+
+import (
+	"bytes"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+// mem is an in-memory store whose "key" field points at a file it owns,
+// like a copied GCP key.
+type mem struct {
+	dir      string
+	profiles map[string]map[string]string
+}
+
+func (m *mem) Names() ([]string, error) { return slices.Sorted(maps.Keys(m.profiles)), nil }
+
+func (m *mem) Get(name string) (map[string]string, error) {
+	p, ok := m.profiles[name]
+	if !ok {
+		return nil, exitcodes.New(exitcodes.ProfileNotFound, "not found")
+	}
+	return maps.Clone(p), nil
+}
+
+func (m *mem) Put(name string, fields map[string]string) error {
+	if p := fields["key"]; p != "" && p != m.keyPath(name) {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(m.keyPath(name), b, 0o600); err != nil {
+			return err
+		}
+		fields["key"] = m.keyPath(name)
+	}
+	m.profiles[name] = fields
+	return nil
+}
+
+func (m *mem) Delete(name string) error { delete(m.profiles, name); return nil }
+func (m *mem) Secret(field string) bool { return field == "key" }
+func (m *mem) FileFields() []string     { return []string{"key"} }
+func (m *mem) Fields() []string {
+	return []string{"extends", "interpolate", "host", "user", "password", "key"}
+}
+func (m *mem) keyPath(name string) string { return filepath.Join(m.dir, name+".key") }
+
+var testStore = &mem{}
+
+func init() {
+	plugin.RegisterExporter(plugin.Exporter{Name: "test.mem", Store: testStore})
+}
+
+// reset empties the test store and gives it a fresh key dir.
+func reset(t *testing.T) *mem {
+	t.Helper()
+	testStore.dir, testStore.profiles = t.TempDir(), map[string]map[string]string{}
+	return testStore
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	s := reset(t)
+	keySrc := filepath.Join(t.TempDir(), "sa.json")
+	require.NoError(t, os.WriteFile(keySrc, []byte(`{"type":"service_account"}`), 0o600))
+	require.NoError(t, s.Put("dev", map[string]string{"host": "localhost", "key": keySrc}))
+	require.NoError(t, s.Put("ci", map[string]string{"extends": "dev"}))
+
+	entries, err := Collect("test.mem")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, []byte(`{"type":"service_account"}`), entries[1].Files["key"])
+
+	r, err := age.NewScryptRecipient("correct horse")
+	require.NoError(t, err)
+	r.SetWorkFactor(10)
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, entries, r))
+
+	wrong, err := age.NewScryptIdentity("battery staple")
+	require.NoError(t, err)
+	_, _, err = Read(bytes.NewReader(buf.Bytes()), wrong)
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(err))
+
+	id, err := age.NewScryptIdentity("correct horse")
+	require.NoError(t, err)
+	m, got, err := Read(bytes.NewReader(buf.Bytes()), id)
+	require.NoError(t, err)
+	require.Equal(t, Version, m.Version)
+	require.Equal(t, entries, got)
+}
+
+func TestCollectUnknownTarget(t *testing.T) {
+	_, err := Collect("nope")
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(err))
+}
+
+func TestPlanPolicies(t *testing.T) {
+	s := reset(t)
+	s.profiles["base"] = map[string]string{"host": "local"}
+	s.profiles["base-restored"] = map[string]string{"host": "older"}
+	entries := []Entry{
+		{Target: "test.mem", Profile: "base", Fields: map[string]string{"host": "backup"}},
+		{Target: "test.mem", Profile: "child", Fields: map[string]string{"extends": "base"}},
+		{Target: "gone", Profile: "x", Fields: map[string]string{}},
+	}
+
+	steps, err := Plan(entries, Skip, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{ActionSkip, ActionAdd, ActionSkip}, actions(steps))
+	require.Equal(t, "unknown plugin", steps[2].Detail)
+
+	steps, err = Plan(entries, Overwrite, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{ActionOverwrite, ActionAdd, ActionSkip}, actions(steps))
+
+	steps, err = Plan(entries, Rename, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{ActionRename, ActionAdd, ActionSkip}, actions(steps))
+	require.Equal(t, "base-restored-2", steps[0].As)
+	require.Equal(t, "base-restored-2", steps[1].Entry.Fields["extends"], "child follows its renamed base")
+	require.Equal(t, "base", entries[1].Fields["extends"], "plan leaves the entries alone")
+
+	require.NoError(t, Apply(steps))
+	require.Equal(t, "local", s.profiles["base"]["host"])
+	require.Equal(t, "backup", s.profiles["base-restored-2"]["host"])
+	require.Equal(t, "base-restored-2", s.profiles["child"]["extends"])
+
+	_, err = ParsePolicy("merge")
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(err))
+}
+
+func TestApplyRestoresOwnedFiles(t *testing.T) {
+	s := reset(t)
+	entries := []Entry{{
+		Target: "test.mem", Profile: "dev",
+		Fields: map[string]string{"key": "/elsewhere/dev.key"},
+		Files:  map[string][]byte{"key": []byte("secret key")},
+	}}
+
+	steps, err := Plan(entries, Skip, nil)
+	require.NoError(t, err)
+	require.NoError(t, Apply(steps))
+
+	require.Equal(t, s.keyPath("dev"), s.profiles["dev"]["key"])
+	b, err := os.ReadFile(s.keyPath("dev"))
+	require.NoError(t, err)
+	require.Equal(t, "secret key", string(b))
+}
+
+func actions(steps []Step) []string {
+	out := make([]string, len(steps))
+	for i, st := range steps {
+		out[i] = st.Action
+	}
+	return out
+}
+
+func TestCollectResolvedFoldsExtends(t *testing.T) {
+	s := reset(t)
+	s.profiles["base"] = map[string]string{"host": "db", "user": "app", "password": "${PGPASS}"}
+	s.profiles["staging"] = map[string]string{"extends": "base", "host": "stg"}
+
+	entries, err := CollectResolved("test.mem", "staging")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"host": "stg", "user": "app", "password": "${PGPASS}"}, entries[0].Fields)
+
+	// A base that opts in to interpolation keeps its templates; the literal
+	// child's values are escaped to stay literal in the folded profile.
+	s.profiles["tmpl"] = map[string]string{"interpolate": "true", "user": "app_{{ .profile }}"}
+	s.profiles["lit"] = map[string]string{"extends": "tmpl", "password": "p{{w"}
+	entries, err = CollectResolved("test.mem", "lit")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"interpolate": "true", "user": "app_{{ .profile }}", "password": `p{{"{{"}}w`}, entries[0].Fields)
+
+	s.profiles["orphan"] = map[string]string{"extends": "gone"}
+	_, err = CollectResolved("test.mem", "orphan")
+	require.Equal(t, exitcodes.ProfileNotFound, exitcodes.FromError(err))
+}
+
+func TestPlanReplaceable(t *testing.T) {
+	s := reset(t)
+	s.profiles["staging"] = map[string]string{"host": "old"}
+	s.profiles["mine"] = map[string]string{"host": "local"}
+	entries := []Entry{
+		{Target: "test.mem", Profile: "staging", Fields: map[string]string{"host": "new"}},
+		{Target: "test.mem", Profile: "mine", Fields: map[string]string{"host": "theirs"}},
+	}
+
+	steps, err := Plan(entries, Skip, func(target, profile string) bool { return profile == "staging" })
+	require.NoError(t, err)
+	require.Equal(t, []string{ActionOverwrite, ActionSkip}, actions(steps))
+}
+
+func TestRestrictDropsUnknownFields(t *testing.T) {
+	s := reset(t)
+	entries := []Entry{
+		{Target: "test.mem", Profile: "a", Fields: map[string]string{"host": "db", "credential_process": "sh -c evil"}},
+		{Target: "test.mem", Profile: "b", Fields: map[string]string{"host": "db", "key": "/home/me/.ssh/id_ed25519"},
+			Files: map[string][]byte{"junk": []byte("x")}},
+	}
+
+	steps, err := Plan(entries, Skip, nil)
+	require.NoError(t, err)
+	steps = Restrict(steps)
+	require.Equal(t, []string{"credential_process"}, steps[0].Dropped)
+	require.Equal(t, []string{"key"}, steps[1].Dropped, "a key field without the key's content is dropped")
+	require.Equal(t, "sh -c evil", entries[0].Fields["credential_process"], "restrict leaves the entries alone")
+
+	require.NoError(t, Apply(steps))
+	require.Equal(t, map[string]string{"host": "db"}, s.profiles["a"])
+	require.Equal(t, map[string]string{"host": "db"}, s.profiles["b"])
+}
+
+func TestRestrictDropsExtends(t *testing.T) {
+	s := reset(t)
+	s.profiles["prod"] = map[string]string{"host": "prod.db", "password": "local-secret"}
+	steps, err := Plan([]Entry{{Target: "test.mem", Profile: "a", Fields: map[string]string{"extends": "prod", "host": "db"}}}, Skip, nil)
+	require.NoError(t, err)
+	steps = Restrict(steps)
+	require.Equal(t, []string{"extends"}, steps[0].Dropped)
+
+	require.NoError(t, Apply(steps))
+	require.Equal(t, map[string]string{"host": "db"}, s.profiles["a"], "an import can't inherit a local profile's values")
+}
+
+func TestRestrictKeepsValuesLiteral(t *testing.T) {
+	s := reset(t)
+	fields := map[string]string{"interpolate": "true", "user": "app_{{ .profile }}", "password": "${AWS_SECRET_ACCESS_KEY}"}
+	steps, err := Plan([]Entry{{Target: "test.mem", Profile: "a", Fields: fields}}, Skip, nil)
+	require.NoError(t, err)
+	steps = Restrict(steps)
+	require.Equal(t, []string{"interpolate"}, steps[0].Dropped)
+
+	require.NoError(t, Apply(steps))
+	require.Equal(t, map[string]string{"user": "app_{{ .profile }}", "password": "${AWS_SECRET_ACCESS_KEY}"}, s.profiles["a"],
+		"without the opt-in the values are used as stored, never expanded from the importer's environment")
+}
+
+func TestPreviewRedactsSecrets(t *testing.T) {
+	reset(t)
+	steps, err := Plan([]Entry{{Target: "test.mem", Profile: "a", Fields: map[string]string{"host": "db", "key": "/k"}}}, Skip, nil)
+	require.NoError(t, err)
+	Preview(steps)
+	require.Equal(t, "db", steps[0].Preview["host"])
+	require.NotEqual(t, "/k", steps[0].Preview["key"])
+}
+
+func TestReadRejectsUnsafeNames(t *testing.T) {
+	r, err := age.NewScryptRecipient("pw")
+	require.NoError(t, err)
+	r.SetWorkFactor(10)
+	id, err := age.NewScryptIdentity("pw")
+	require.NoError(t, err)
+
+	for _, e := range []Entry{
+		{Target: "test.mem", Profile: "../../x", Fields: map[string]string{}},
+		{Target: "test.mem", Profile: `a\b`, Fields: map[string]string{}},
+		{Target: "test.mem", Profile: "x", Fields: map[string]string{"extends": "../y"}},
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, []Entry{e}, r))
+		_, _, err = Read(&buf, id)
+		require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(err), e.Profile)
+	}
+}
@@ -0,0 +1,214 @@
+package bundle
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
+)
+
+// Policy decides what happens to a bundled profile whose name is already
+// saved locally.
+type Policy string
+
+const (
+	Skip      Policy = "skip"      // keep the local profile
+	Overwrite Policy = "overwrite" // replace it with the bundled one
+	Rename    Policy = "rename"    // save the bundled one as <name>-restored
+)
+
+// ParsePolicy validates a --on-conflict value.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case Skip, Overwrite, Rename:
+		return p, nil
+	}
+	return "", exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid conflict policy %q (use skip, overwrite or rename)", s))
+}
+
+// What a restore does with one bundled profile.
+const (
+	ActionAdd       = "add"
+	ActionOverwrite = "overwrite"
+	ActionRename    = "rename"
+	ActionSkip      = "skip"
+)
+
+// Step is the planned fate of one bundled profile.
+type Step struct {
+	Entry   Entry  `json:"-"`
+	Target  string `json:"plugin"`
+	Profile string `json:"profile"`
+	Action  string `json:"action"`
+	// As is the name the profile is saved under; it differs from Profile
+	// only when renamed.
+	As     string `json:"as,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	// Dropped lists the bundled fields Restrict left out.
+	Dropped []string `json:"dropped,omitempty"`
+	// Preview holds the fields to be saved, secrets redacted; it is only
+	// filled in (by Preview) for dry runs.
+	Preview map[string]string `json:"fields,omitempty"`
+}
+
+// Plan works out what restoring entries does under policy, without
+// writing anything. Local profiles for which replaceable reports true (such
+// as ones imported from an earlier share) are overwritten whatever the
+// policy; replaceable may be nil.
+func Plan(entries []Entry, policy Policy, replaceable func(target, profile string) bool) ([]Step, error) {
+	taken := map[string]map[string]bool{} // target -> local and planned names
+	renamed := map[string]map[string]string{}
+	steps := make([]Step, 0, len(entries))
+	for _, e := range entries {
+		st := Step{Entry: e, Target: e.Target, Profile: e.Profile, As: e.Profile}
+		ex, ok := plugin.LookupExporter(e.Target)
+		if !ok || ex.Store == nil {
+			st.Action, st.As, st.Detail = ActionSkip, "", "unknown plugin"
+			steps = append(steps, st)
+			continue
+		}
+		names, ok := taken[e.Target]
+		if !ok {
+			local, err := ex.Store.Names()
+			if err != nil {
+				return nil, err
+			}
+			names = map[string]bool{}
+			for _, n := range local {
+				names[n] = true
+			}
+			taken[e.Target] = names
+		}
+
+		switch {
+		case !names[e.Profile]:
+			st.Action = ActionAdd
+		case policy == Overwrite, replaceable != nil && replaceable(e.Target, e.Profile):
+			st.Action = ActionOverwrite
+		case policy == Rename:
+			st.Action, st.As = ActionRename, freeName(names, e.Profile)
+			if renamed[e.Target] == nil {
+				renamed[e.Target] = map[string]string{}
+			}
+			renamed[e.Target][e.Profile] = st.As
+		default:
+			st.Action, st.As, st.Detail = ActionSkip, "", "already exists"
+		}
+		if st.As != "" {
+			names[st.As] = true
+		}
+		steps = append(steps, st)
+	}
+
+	// Bundled children follow a bundled base that was restored under a new
+	// name, the same way `rename` repoints them.
+	for i, st := range steps {
+		base := st.Entry.Fields[profilestore.ExtendsField]
+		if to, ok := renamed[st.Target][base]; ok && st.Action != ActionSkip {
+			steps[i].Entry.Fields = maps.Clone(st.Entry.Fields)
+			steps[i].Entry.Fields[profilestore.ExtendsField] = to
+		}
+	}
+	return steps, nil
+}
+
+// Restrict cuts steps down to the fields their stores know, for bundles
+// from someone else: unknown fields (an AWS credential_process, say),
+// owned-file fields whose file the bundle doesn't carry, and `extends` and
+// the interpolation opt-in are left out and listed in Step.Dropped. So an
+// imported profile can't pull in a local profile's values or read the
+// environment: its values are saved, and used, exactly as bundled.
+func Restrict(steps []Step) []Step {
+	untrusted := []string{profilestore.ExtendsField, resolve.InterpolateField}
+	for i, st := range steps {
+		if st.Action == ActionSkip {
+			continue
+		}
+		ex, _ := plugin.LookupExporter(st.Target)
+		var fileFields []string
+		if fs, ok := ex.Store.(profilestore.FileStore); ok {
+			fileFields = fs.FileFields()
+		}
+		known := ex.Store.Fields()
+		open, _ := ex.Store.(profilestore.OpenStore)
+		fields := map[string]string{}
+		for _, k := range slices.Sorted(maps.Keys(st.Entry.Fields)) {
+			_, carried := st.Entry.Files[k]
+			if !slices.Contains(known, k) && (open == nil || !open.Accepts(k)) || slices.Contains(untrusted, k) || slices.Contains(fileFields, k) && !carried {
+				steps[i].Dropped = append(steps[i].Dropped, k)
+				continue
+			}
+			fields[k] = st.Entry.Fields[k]
+		}
+		files := map[string][]byte{}
+		for k, b := range st.Entry.Files {
+			if _, ok := fields[k]; ok && slices.Contains(fileFields, k) {
+				files[k] = b
+			}
+		}
+		steps[i].Entry.Fields, steps[i].Entry.Files = fields, files
+	}
+	return steps
+}
+
+// Preview fills in each written step's Preview with the fields it would
+// save, secrets redacted.
+func Preview(steps []Step) {
+	for i, st := range steps {
+		if st.Action == ActionSkip {
+			continue
+		}
+		ex, _ := plugin.LookupExporter(st.Target)
+		steps[i].Preview = map[string]string{}
+		for k, v := range st.Entry.Fields {
+			steps[i].Preview[k] = profilestore.Redact(ex.Store, k, v)
+		}
+	}
+}
+
+// freeName returns the first of <name>-restored, <name>-restored-2, ...
+// not in taken.
+func freeName(taken map[string]bool, name string) string {
+	n := name + "-restored"
+	for i := 2; taken[n]; i++ {
+		n = fmt.Sprintf("%s-restored-%d", name, i)
+	}
+	return n
+}
+
+// Apply saves the profiles of the steps that aren't skipped. Owned files
+// are written to a private temp dir first, and the store copies them into
+// place as it would a key given on the command line.
+func Apply(steps []Step) error {
+	tmp, err := os.MkdirTemp("", "rdv-restore-")
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	defer os.RemoveAll(tmp)
+
+	for i, st := range steps {
+		if st.Action == ActionSkip {
+			continue
+		}
+		ex, _ := plugin.LookupExporter(st.Target)
+		fields := maps.Clone(st.Entry.Fields)
+		for f, b := range st.Entry.Files {
+			p := filepath.Join(tmp, fmt.Sprintf("%d-%s", i, strings.ReplaceAll(f, "/", "_")))
+			if err := os.WriteFile(p, b, 0o600); err != nil {
+				return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+			}
+			fields[f] = p
+		}
+		if err := ex.Store.Put(st.As, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,603 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yonasyiheyis/rdv/internal/cli"
+	"github.com/yonasyiheyis/rdv/internal/envfile"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	fflags "github.com/yonasyiheyis/rdv/internal/flags"
+	"github.com/yonasyiheyis/rdv/internal/logger"
+	iprint "github.com/yonasyiheyis/rdv/internal/print"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
+	"github.com/yonasyiheyis/rdv/internal/ui"
+)
+
+// Command builds `rdv <name>` (or `rdv db <name>`) and its sub-commands
+// from a driver.
+func Command(d *Driver) *cobra.Command {
+	var profile string
+	var testConn bool
+
+	driverCmd := &cobra.Command{
+		Use:     d.Name,
+		Aliases: d.Aliases,
+		Short:   d.help("", fmt.Sprintf("Manage %s profiles", d.Label)),
+	}
+
+	// -------- set-config --------
+	var noPrompt bool
+
+	setCmd := &cobra.Command{
+		Use:   "set-config",
+		Short: d.help("set-config", fmt.Sprintf("Interactively set %s connection info", d.Label)),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			in, err := d.inputs(cmd.Flags(), profile)
+			if err != nil {
+				return err
+			}
+			return SetConfig(d, profile, testConn, noPrompt, in)
+		},
+	}
+	fflags.AddNoPromptFlag(setCmd.Flags(), &noPrompt)
+	setCmd.Flags().StringVarP(&profile, "profile", "p", "default", "profile name")
+	d.addTestConnFlag(setCmd.Flags(), &testConn)
+	d.addFieldFlags(setCmd.Flags())
+
+	// -------- modify ------------
+	var modNoPrompt bool
+
+	modCmd := &cobra.Command{
+		Use:   "modify",
+		Short: d.help("modify", fmt.Sprintf("Modify an existing %s profile", d.Label)),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			in, err := d.inputs(cmd.Flags(), profile)
+			if err != nil {
+				return err
+			}
+			return Modify(d, profile, testConn, modNoPrompt, in)
+		},
+	}
+	fflags.AddNoPromptFlag(modCmd.Flags(), &modNoPrompt)
+	modCmd.Flags().StringVarP(&profile, "profile", "p", "default", "profile name")
+	d.addTestConnFlag(modCmd.Flags(), &testConn)
+	d.addFieldFlags(modCmd.Flags())
+
+	// -------- delete ------------
+	delCmd := &cobra.Command{
+		Use:   "delete",
+		Short: d.help("delete", fmt.Sprintf("Delete a %s profile", d.Label)),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runDelete(d, profile)
+		},
+	}
+	delCmd.Flags().StringVarP(&profile, "profile", "p", "default", "profile name")
+
+	// -------- export ------------
+	var envPath string
+
+	expCmd := &cobra.Command{
+		Use:   "export",
+		Short: d.help("export", fmt.Sprintf("Print connection env vars for a %s profile", d.Label)),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runExport(d, profile, envPath)
+		},
+	}
+	expCmd.Flags().StringVarP(&profile, "profile", "p", "default", "profile name")
+	fflags.AddEnvFileFlag(expCmd.Flags(), &envPath) // --env-file/-o flag
+
+	// -------- list ------------
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: d.help("list", fmt.Sprintf("List %s profiles", d.Label)),
+		RunE:  func(cmd *cobra.Command, _ []string) error { return runList(d) },
+	}
+
+	// -------- show ------------
+	var showName string
+	var showResolved bool
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: d.help("show", fmt.Sprintf("Show %s profile (redacted)", d.Label)),
+		RunE:  func(cmd *cobra.Command, _ []string) error { return runShow(d, showName, showResolved) },
+	}
+	showCmd.Flags().StringVarP(&showName, "profile", "p", "default", "profile name")
+	showCmd.Flags().BoolVar(&showResolved, "resolved", false, "show the effective values after extends and ${VAR} / {{ .profile }} interpolation")
+
+	// -------- test-conn ------------
+	var testName string
+	testCmd := &cobra.Command{
+		Use:   "test-conn",
+		Short: d.help("test-conn", fmt.Sprintf("Test a saved %s profile", d.Label)),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runTestConn(foreground(), d, testName, os.Stdout)
+		},
+	}
+	testCmd.Flags().StringVarP(&testName, "profile", "p", "default", "profile name")
+
+	driverCmd.AddCommand(setCmd, modCmd, delCmd, expCmd, listCmd, showCmd)
+	if d.Test != nil {
+		driverCmd.AddCommand(testCmd)
+	}
+	driverCmd.AddCommand(profilestore.Commands(store{d}, d.Label)...)
+	return driverCmd
+}
+
+func (d *Driver) help(cmd, def string) string {
+	if h, ok := d.Help[cmd]; ok {
+		return h
+	}
+	return def
+}
+
+// ------------ flag helpers ------------
+
+func (d *Driver) addTestConnFlag(fs *pflag.FlagSet, testConn *bool) {
+	if d.Test != nil {
+		fs.BoolVar(testConn, "test-conn", false, "try to connect after saving")
+	}
+}
+
+func (d *Driver) addFieldFlags(fs *pflag.FlagSet) {
+	for _, f := range d.Fields {
+		switch {
+		case f.File:
+		case f.Bool:
+			fs.Bool(f.flag(), false, f.Usage)
+		case f.List:
+			fs.StringSlice(f.flag(), nil, f.Usage)
+		case f.Lines:
+			fs.StringArray(f.flag(), nil, f.Usage)
+		default:
+			fs.String(f.flag(), "", f.Usage)
+		}
+	}
+	if d.Flags != nil {
+		d.Flags(fs)
+	}
+}
+
+// flagValues collects the field flags given on the command line. A flag
+// given an empty value is kept as "", which clears the field on modify.
+func (d *Driver) flagValues(fs *pflag.FlagSet) Settings {
+	vals := Settings{}
+	for _, f := range d.Fields {
+		if f.File || !fs.Changed(f.flag()) {
+			continue
+		}
+		switch {
+		case f.Bool:
+			b, _ := fs.GetBool(f.flag())
+			vals[f.Key] = strconv.FormatBool(b)
+		case f.List:
+			v, _ := fs.GetStringSlice(f.flag())
+			vals[f.Key] = strings.Join(v, ",")
+		case f.Lines:
+			v, _ := fs.GetStringArray(f.flag())
+			vals[f.Key] = strings.Join(v, "\n")
+		default:
+			vals[f.Key], _ = fs.GetString(f.flag())
+		}
+	}
+	return vals
+}
+
+// inputs is what the command line sets for profile name: the field flags,
+// then whatever the driver's own flags add.
+func (d *Driver) inputs(fs *pflag.FlagSet, name string) (Settings, error) {
+	in := d.flagValues(fs)
+	if d.Apply != nil {
+		if err := d.Apply(name, fs, in); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// promptProfile runs the interactive form for d, editing p in place.
+func promptProfile(d *Driver, p Settings) error {
+	vals := make([]string, len(d.Fields))
+	bools := make([]bool, len(d.Fields))
+	inputs := make([]huh.Field, 0, len(d.Fields))
+	for i, f := range d.Fields {
+		switch {
+		case f.File:
+			continue
+		case f.Bool:
+			bools[i] = p.Bool(f.Key)
+			inputs = append(inputs, huh.NewConfirm().Title(f.Title).Value(&bools[i]))
+			continue
+		}
+		vals[i] = p[f.Key]
+		title := f.Title
+		if f.Optional {
+			title += " (optional)"
+		}
+		if f.Lines {
+			inputs = append(inputs, huh.NewText().Title(title+" (one per line)").Value(&vals[i]))
+			continue
+		}
+		in := huh.NewInput().Title(title).Value(&vals[i])
+		switch {
+		case f.Default != "":
+			in = in.Placeholder(f.Default)
+		case f.Example != "":
+			in = in.Placeholder(f.Example)
+		}
+		if f.Secret {
+			in = in.EchoMode(huh.EchoModePassword)
+		}
+		required := f.required() && p[profilestore.ExtendsField] == "" // inherited fields may stay empty
+		in = in.Validate(func(s string) error {
+			s = strings.TrimSpace(s)
+			switch {
+			case required && s == "":
+				return fmt.Errorf("%s is required", f.Title)
+			case f.Int && s != "":
+				if _, err := strconv.Atoi(s); err != nil {
+					return fmt.Errorf("%s must be a whole number", f.Title)
+				}
+			}
+			return nil
+		})
+		inputs = append(inputs, in)
+	}
+
+	if err := ui.NewForm(huh.NewGroup(inputs...)).Run(); err != nil {
+		return err
+	}
+	for i, f := range d.Fields {
+		switch {
+		case f.File:
+		case f.Bool:
+			p[f.Key] = strconv.FormatBool(bools[i])
+		case f.Lines:
+			p[f.Key] = strings.Join(Settings{f.Key: vals[i]}.Lines(f.Key), "\n")
+		case f.List:
+			p[f.Key] = strings.Join(Settings{f.Key: vals[i]}.Values(f.Key), ",")
+		default:
+			p[f.Key] = strings.TrimSpace(vals[i])
+		}
+	}
+	return nil
+}
+
+// ------------ command logic ------------
+
+// SetConfig saves name from in, the values given on the command line,
+// prompting for the rest when interactive.
+func SetConfig(d *Driver, name string, testConn, noPrompt bool, in Settings) error {
+	if err := profilestore.ValidName(name); err != nil {
+		return err
+	}
+	p := Settings{}
+	for k, v := range in {
+		p[k] = v
+	}
+
+	if !noPrompt && cli.IsInteractive() {
+		if err := promptProfile(d, p); err != nil {
+			return err
+		}
+	}
+	p = d.withDefaults(p)
+
+	cfg, err := d.load()
+	if err != nil {
+		return err
+	}
+	full, err := d.withBase(cfg, name, p)
+	if err != nil {
+		return err
+	}
+	if err := d.check(full); err != nil {
+		return err
+	}
+	if err := d.normalize(p); err != nil {
+		return err
+	}
+
+	cfg.Profiles[name] = p
+	if err := d.save(cfg); err != nil {
+		return err
+	}
+
+	logger.L.Infow("profile saved", "target", d.target(), "profile", name, "file", d.Path())
+	fmt.Printf("✅ %s profile %q saved to %s\n", d.Label, name, d.Path())
+
+	if testConn {
+		return runTestConn(foreground(), d, name, os.Stdout) // tests the resolved profile
+	}
+	return nil
+}
+
+// Modify updates name with the values of in, or interactively. An empty
+// value clears its field.
+func Modify(d *Driver, name string, testConn, noPrompt bool, in Settings) error {
+	cfg, err := d.load()
+	if err != nil {
+		return err
+	}
+	p := Settings{}
+	for k, v := range cfg.Profiles[name] { // empty if not found
+		p[k] = v
+	}
+
+	if noPrompt || !cli.IsInteractive() {
+		d.merge(p, in)
+		full, err := d.withBase(cfg, name, p)
+		if err != nil {
+			return err
+		}
+		if len(d.missing(full)) > 0 {
+			return exitcodes.New(exitcodes.InvalidArgs, "missing values; provide all with flags or run interactively")
+		}
+	} else {
+		d.merge(p, in) // driver flags such as an embedded key still apply
+		if err := promptProfile(d, p); err != nil {
+			return err
+		}
+	}
+	if p[profilestore.ExtendsField] == "" { // a child keeps inheriting whatever it leaves empty
+		p = d.withDefaults(p)
+	}
+	full, err := d.withBase(cfg, name, p)
+	if err != nil {
+		return err
+	}
+	if err := d.check(full); err != nil {
+		return err
+	}
+	if err := d.normalize(p); err != nil {
+		return err
+	}
+
+	old := cfg.Profiles[name]
+	cfg.Profiles[name] = p
+	if err := d.save(cfg); err != nil {
+		return err
+	}
+	if err := d.removeReplacedFiles(name, old, p); err != nil {
+		return err
+	}
+
+	logger.L.Infow("profile modified", "target", d.target(), "profile", name)
+	fmt.Printf("✅ Updated %s profile %q\n", d.Label, name)
+
+	if testConn {
+		return runTestConn(foreground(), d, name, os.Stdout) // tests the resolved profile
+	}
+	return nil
+}
+
+// merge sets the values of in on p, first dropping the fields the
+// non-empty ones replace. Empty values clear; save drops them.
+func (d *Driver) merge(p, in Settings) {
+	for _, f := range d.Fields {
+		if in[f.Key] != "" {
+			for _, k := range f.Replaces {
+				delete(p, k)
+			}
+		}
+	}
+	for k, v := range in {
+		p[k] = v
+	}
+}
+
+// removeReplacedFiles deletes the owned files old had that p no longer
+// points at.
+func (d *Driver) removeReplacedFiles(name string, old, p Settings) error {
+	for _, f := range d.Fields {
+		path := d.FilePath(name, f.Key)
+		if !f.File || old[f.Key] != path || p[f.Key] == path {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+	}
+	_ = os.Remove(d.Dir(name)) // only once nothing else is left in it
+	return nil
+}
+
+func runDelete(d *Driver, name string) error {
+	ok, err := ui.Confirm(fmt.Sprintf("Delete %s profile %q?", d.Label, name))
+	if err != nil || !ok {
+		return err
+	}
+
+	if err := (store{d}).Delete(name); err != nil {
+		return err
+	}
+
+	logger.L.Infow("profile deleted", "target", d.target(), "profile", name)
+	fmt.Printf("🗑️  Deleted %s profile %q\n", d.Label, name)
+	return nil
+}
+
+func runExport(d *Driver, name string, envPath string) error {
+	vars, err := d.ExportVars(name)
+	if err != nil {
+		return err
+	}
+
+	if envPath != "" { // write/merge to .env
+		if err := envfile.WriteEnv(envPath, vars); err != nil {
+			return exitcodes.Wrap(exitcodes.EnvWriteFailed, err)
+		}
+		if iprint.JSON {
+			if err := iprint.Out(map[string]any{
+				"written": len(vars),
+				"path":    envPath,
+				"vars":    vars,
+			}); err != nil {
+				return exitcodes.Wrap(exitcodes.JSONError, err)
+			}
+			return nil
+		}
+		fmt.Printf("✅ wrote %d vars to %s\n", len(vars), envPath)
+		return nil
+	}
+
+	if iprint.JSON {
+		if err := iprint.Out(vars); err != nil {
+			return exitcodes.Wrap(exitcodes.JSONError, err)
+		}
+		return nil
+	}
+
+	for k, v := range vars { // fallback: print exports
+		fmt.Printf("export %s=%s\n", k, v)
+	}
+	return nil
+}
+
+func runList(d *Driver) error {
+	names, err := (store{d}).Names()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		if iprint.JSON {
+			return iprint.Out(map[string]any{"profiles": []string{}})
+		}
+		fmt.Println("(no profiles)")
+		return nil
+	}
+	if iprint.JSON {
+		return iprint.Out(map[string]any{"profiles": names})
+	}
+	for _, n := range names {
+		fmt.Println(n)
+	}
+	return nil
+}
+
+func runShow(d *Driver, name string, resolved bool) error {
+	cfg, err := d.load()
+	if err != nil {
+		return err
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found", name))
+	}
+	// Without --resolved a child profile shows only what it overrides.
+	extends, interpolate := p[profilestore.ExtendsField], p[resolve.InterpolateField]
+	switch {
+	case resolved:
+		if p, err = d.resolve(cfg, name); err != nil {
+			return err
+		}
+		extends, interpolate = "", ""
+	case extends == "":
+		p = d.withDefaults(p)
+	}
+
+	payload := map[string]any{"profile": name}
+	text := map[string]string{}
+	width := 0
+	if extends != "" {
+		payload[profilestore.ExtendsField] = extends
+		width = len(profilestore.ExtendsField)
+	}
+	if interpolate != "" {
+		payload[resolve.InterpolateField] = interpolate
+		width = max(width, len(resolve.InterpolateField))
+	}
+	for _, f := range d.Fields {
+		v := p[f.Key]
+		if f.Bool && v == "" && extends == "" {
+			v = "false"
+		}
+		if f.Secret && !resolve.IsEnvRef(v) {
+			v = iprint.Redact(v)
+		}
+		payload[f.Key] = v
+		switch {
+		case f.List:
+			payload[f.Key] = Settings{f.Key: v}.Values(f.Key)
+		case f.Lines:
+			payload[f.Key] = Settings{f.Key: v}.Lines(f.Key)
+		}
+		text[f.Key] = v
+		width = max(width, len(f.Key))
+	}
+	varKeys := d.varKeys(p)
+	if d.Vars != nil { // apart from the fields, so a variable can't shadow one
+		vars, secret := map[string]string{}, []string{}
+		for _, k := range varKeys {
+			v := p[k]
+			if d.secretVar(p, k) {
+				secret = append(secret, k)
+				if !resolve.IsEnvRef(v) {
+					v = iprint.Redact(v)
+				}
+			}
+			vars[k], text[k] = v, v
+			width = max(width, len(k))
+		}
+		payload["vars"], payload["secret"] = vars, secret
+	}
+	if iprint.JSON {
+		return iprint.Out(payload)
+	}
+
+	fmt.Printf("profile: %s\n", name)
+	if extends != "" {
+		fmt.Printf("  %-*s: %s\n", width, profilestore.ExtendsField, extends)
+	}
+	if interpolate != "" {
+		fmt.Printf("  %-*s: %s\n", width, resolve.InterpolateField, interpolate)
+	}
+	for _, f := range d.Fields {
+		v := text[f.Key]
+		if v == "" && (f.Optional || f.File || extends != "") {
+			continue
+		}
+		// continuation lines of a Lines field line up under the first
+		v = strings.ReplaceAll(v, "\n", "\n"+strings.Repeat(" ", width+4))
+		fmt.Printf("  %-*s: %s\n", width, f.Key, v)
+	}
+	for _, k := range varKeys {
+		fmt.Printf("  %-*s: %s\n", width, k, text[k])
+	}
+	return nil
+}
+
+type promptKey struct{}
+
+// foreground is the context of a test the user runs in the terminal
+// (test-conn or --test-conn), where a driver's Test may prompt.
+func foreground() context.Context {
+	return context.WithValue(context.Background(), promptKey{}, true)
+}
+
+// MayPrompt reports whether a Test runs in the foreground and may ask the
+// user for input, rather than for rdv doctor.
+func MayPrompt(ctx context.Context) bool {
+	v, _ := ctx.Value(promptKey{}).(bool)
+	return v
+}
+
+func runTestConn(ctx context.Context, d *Driver, name string, out io.Writer) error {
+	p, err := d.Resolve(name)
+	if err != nil {
+		return err
+	}
+	if err := d.Test(ctx, p, out); err != nil {
+		return exitcodes.Wrap(exitcodes.ConnectionFailed, err)
+	}
+	return nil
+}
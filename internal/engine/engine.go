@@ -0,0 +1,516 @@
+// Package engine turns a table of fields into a plugin's saved profiles:
+// the YAML store, the set-config/modify/delete/export/list/show/test-conn
+// commands, extends and ${VAR} / {{ .profile }} interpolation, and the
+// profilestore.Store that copy, rename, diff, backup and share work on.
+// A Driver supplies the fields and what to do with a resolved profile;
+// hooks cover what a table can't say.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
+)
+
+// Field describes one stored setting of a driver's profiles. Key is the YAML
+// key; the CLI flag is the same name with dashes (read_only -> --read-only)
+// unless Flag says otherwise.
+type Field struct {
+	Key      string
+	Flag     string // CLI flag name, when it isn't Key with dashes
+	Title    string // prompt title
+	Usage    string // flag help
+	Default  string // applied when left empty; shown as prompt placeholder
+	Example  string // prompt placeholder when there is no default
+	Secret   bool   // masked in prompts and redacted by show
+	Optional bool
+	Bool     bool // stored as "true"/"false"; a bool flag and a yes/no prompt
+	Int      bool // a whole number
+	List     bool // comma-separated values; the flag may also be repeated
+	Lines    bool // one value per line, for values that hold commas; a repeated flag and a text prompt
+	Path     bool // a local file path, normalized to an absolute path on save
+	// Replaces lists fields that a new value of this one clears on modify,
+	// such as a client secret swapped for a certificate.
+	Replaces []string
+	// File marks a file the profile owns, kept in its own dir (see
+	// Driver.FilePath): copy, rename and restore move it along, delete
+	// removes it, and backups carry its content. Set by hooks, not flags.
+	File bool
+}
+
+// required reports whether the user must supply a value.
+func (f Field) required() bool { return !f.Optional && !f.Bool && !f.File && f.Default == "" }
+
+func (f Field) flag() string {
+	if f.Flag != "" {
+		return f.Flag
+	}
+	return strings.ReplaceAll(f.Key, "_", "-")
+}
+
+// Settings holds one saved profile keyed by field name.
+type Settings map[string]string
+
+// Values splits a List field's value into its items.
+func (p Settings) Values(key string) []string {
+	sep := ","
+	if strings.Contains(p[key], "\n") {
+		sep = "\n"
+	}
+	return split(p[key], sep)
+}
+
+// Lines splits a Lines field's value into its items, which may hold commas.
+func (p Settings) Lines(key string) []string { return split(p[key], "\n") }
+
+func split(s, sep string) []string {
+	var out []string
+	for _, v := range strings.Split(s, sep) {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Bool reports whether a Bool field is set.
+func (p Settings) Bool(key string) bool { return p[key] == "true" }
+
+// Int returns an Int field's value, 0 when unset.
+func (p Settings) Int(key string) int {
+	n, _ := strconv.Atoi(p[key])
+	return n
+}
+
+// Driver declares one kind of profile: its fields, env mapping and tester.
+// Each driver gets the full set-config/modify/delete/export/list/show/test-conn
+// command surface plus copy/rename/diff, and an env export target.
+type Driver struct {
+	Name    string   // sub-command, e.g. "postgres" or "redis"
+	Target  string   // env export target; Name when empty, e.g. "db.postgres"
+	Aliases []string // extra command names and export targets, e.g. "pg"
+	Related []string // ambient variable globs exec strips, e.g. "MYSQL_*"
+	Label   string   // human name, e.g. "PostgreSQL"
+	// Path returns the YAML file the profiles are saved in.
+	Path   func() string
+	Fields []Field
+	// Summary lists the settings `rdv list --all` shows.
+	Summary []string
+	// Help overrides the short help of a sub-command, by name.
+	Help map[string]string
+
+	Env func(p Settings) map[string]string
+	// Test checks a resolved profile can connect; drivers without one get
+	// no test-conn.
+	Test func(ctx context.Context, p Settings, out io.Writer) error
+
+	// Secrets lists the exported vars that carry credentials. When empty
+	// they are worked out from the values of Secret fields.
+	Secrets []string
+	// Validate checks a profile about to be saved, with what it inherits and
+	// defaults filled in, beyond its required fields.
+	Validate func(p Settings) error
+	// Export replaces Env for drivers whose export also writes files,
+	// such as a generated kubeconfig.
+	Export func(name string, p Settings) (map[string]string, error)
+	// Exec builds what `rdv exec` injects, when that differs from Export;
+	// see plugin.Exporter.Exec.
+	Exec func(name string, p Settings) (map[string]string, func(), error)
+	// Flags adds set-config/modify flags that aren't fields, and Apply
+	// folds them into the profile once the fields are in.
+	Flags func(fs *pflag.FlagSet)
+	Apply func(name string, fs *pflag.FlagSet, p Settings) error
+	// Remove deletes files a profile left outside its own dir, such as
+	// those written by export.
+	Remove func(name string) error
+	// Check runs offline health checks for `rdv doctor`.
+	Check plugin.CheckFunc
+
+	// Vars lets profiles hold env variables of any name it accepts besides
+	// the fields, such as a custom bundle's: they are exported as they are
+	// and treated as secrets unless Plain lists them.
+	Vars  func(key string) bool
+	Plain func(p Settings) []string
+}
+
+// Register makes d an env export target, with its profiles as a store.
+func Register(d *Driver) {
+	plugin.RegisterExporter(plugin.Exporter{
+		Name:    d.target(),
+		Aliases: d.aliases(),
+		Export:  d.ExportVars,
+		Exec:    d.exec(),
+		Secrets: d.secretKeys,
+		List:    d.summaries,
+		Test:    d.tester(),
+		Files:   func() []string { return []string{d.Path()} },
+		Check:   d.Check,
+		Store:   d.Store(),
+		Related: d.Related,
+	})
+}
+
+// Store returns d's profiles as a profilestore.Store.
+func (d *Driver) Store() profilestore.Store { return store{d} }
+
+func (d *Driver) target() string {
+	if d.Target != "" {
+		return d.Target
+	}
+	return d.Name
+}
+
+func (d *Driver) aliases() []string {
+	if d.Target == "" || d.Target == d.Name {
+		return d.Aliases
+	}
+	return append([]string{d.Name}, d.Aliases...)
+}
+
+func (d *Driver) tester() plugin.TestFunc {
+	if d.Test == nil {
+		return nil
+	}
+	return func(ctx context.Context, name string, out io.Writer) error { return runTestConn(ctx, d, name, out) }
+}
+
+func (d *Driver) exec() func(string) (map[string]string, func(), error) {
+	if d.Exec == nil {
+		return nil
+	}
+	return func(name string) (map[string]string, func(), error) {
+		p, err := d.Resolve(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return d.Exec(name, p)
+	}
+}
+
+// FilePath is where name keeps its File field key: <dir>/<Name>/<name>/<key>
+// next to the YAML file.
+func (d *Driver) FilePath(name, key string) string {
+	return filepath.Join(d.Dir(name), key)
+}
+
+// Dir is name's own directory, removed with the profile once empty.
+func (d *Driver) Dir(name string) string {
+	return filepath.Join(filepath.Dir(d.Path()), d.Name, name)
+}
+
+type config struct {
+	Profiles map[string]Settings `yaml:"profiles"`
+}
+
+func (d *Driver) load() (config, error) {
+	cfg := config{Profiles: map[string]Settings{}}
+	b, err := os.ReadFile(d.Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return cfg, exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("failed to parse %s: %w", d.Path(), err))
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Settings{}
+	}
+	return cfg, nil
+}
+
+func (d *Driver) save(cfg config) error {
+	if err := os.MkdirAll(filepath.Dir(d.Path()), 0o700); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	for _, p := range cfg.Profiles {
+		for k, v := range p {
+			if v == "" {
+				delete(p, k)
+			}
+		}
+	}
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(d.Path(), out, 0o600); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return nil
+}
+
+func (d *Driver) notFound(name string) error {
+	return exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found in %s", name, d.Path()))
+}
+
+// withDefaults fills empty fields with their driver defaults.
+func (d *Driver) withDefaults(p Settings) Settings {
+	out := maps.Clone(p)
+	if out == nil {
+		out = Settings{}
+	}
+	for _, f := range d.Fields {
+		if out[f.Key] == "" && f.Default != "" {
+			out[f.Key] = f.Default
+		}
+	}
+	return out
+}
+
+// normalize expands ~ and makes Path fields absolute, leaving those that
+// start with a ${VAR} to be resolved when used.
+func (d *Driver) normalize(p Settings) error {
+	for _, f := range d.Fields {
+		v := p[f.Key]
+		if !f.Path || v == "" || resolve.RootedInRef(v) {
+			continue
+		}
+		if v == "~" || strings.HasPrefix(v, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+			v = filepath.Join(home, v[1:])
+		}
+		abs, err := filepath.Abs(v)
+		if err != nil {
+			return fmt.Errorf("failed to normalize %s: %w", f.Key, err)
+		}
+		p[f.Key] = abs
+	}
+	return nil
+}
+
+// missing returns the flag names of required fields that are empty.
+func (d *Driver) missing(p Settings) []string {
+	var out []string
+	for _, f := range d.Fields {
+		if f.required() && p[f.Key] == "" {
+			out = append(out, "--"+f.flag())
+		}
+	}
+	return out
+}
+
+// check validates a full profile: required fields, whole numbers, and the
+// driver's own rules. Values still holding ${VAR} or {{ }} are checked once
+// they are resolved, by the tool that gets them.
+func (d *Driver) check(p Settings) error {
+	if miss := d.missing(p); len(miss) > 0 {
+		return exitcodes.New(exitcodes.InvalidArgs, "missing required flags: "+strings.Join(miss, " "))
+	}
+	for _, f := range d.Fields {
+		v := p[f.Key]
+		if !f.Int || v == "" || strings.Contains(v, "${") || strings.Contains(v, "{{") {
+			continue
+		}
+		if _, err := strconv.Atoi(v); err != nil {
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("--%s must be a whole number, got %q", f.flag(), v))
+		}
+	}
+	if d.Validate != nil {
+		return d.Validate(p)
+	}
+	return nil
+}
+
+// ExportVars returns the env vars for a saved profile.
+func (d *Driver) ExportVars(name string) (map[string]string, error) {
+	p, err := d.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if d.Export != nil {
+		return d.Export(name, p)
+	}
+	vars := map[string]string{}
+	if d.Env != nil {
+		vars = d.Env(p)
+	}
+	for _, k := range d.varKeys(p) {
+		vars[k] = p[k]
+	}
+	return vars, nil
+}
+
+// varKeys lists the Vars of p, sorted.
+func (d *Driver) varKeys(p Settings) []string {
+	var keys []string
+	for k := range p {
+		if d.isVar(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isVar reports whether key is a variable rather than a field.
+func (d *Driver) isVar(key string) bool {
+	if d.Vars == nil || key == profilestore.ExtendsField || key == resolve.InterpolateField {
+		return false
+	}
+	for _, f := range d.Fields {
+		if f.Key == key {
+			return false
+		}
+	}
+	return d.Vars(key)
+}
+
+// secretVar reports whether the variable key of p is a secret.
+func (d *Driver) secretVar(p Settings, key string) bool {
+	return d.Plain == nil || !slices.Contains(d.Plain(p), key)
+}
+
+// inherit merges name's settings over those of the profiles it extends,
+// field by field, nearest profile winning. Values are not interpolated, but
+// with literal set those of profiles that don't opt in to interpolation are
+// escaped so a later Expand leaves them as stored.
+func (d *Driver) inherit(cfg config, name string, literal bool) (Settings, error) {
+	chain, err := resolve.Chain(name, func(n string) (string, error) {
+		p, ok := cfg.Profiles[n]
+		if !ok {
+			return "", d.notFound(n)
+		}
+		return p[profilestore.ExtendsField], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := Settings{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		p := cfg.Profiles[chain[i]]
+		for k, v := range p {
+			if v == "" {
+				continue
+			}
+			if literal && p[resolve.InterpolateField] != "true" {
+				v = resolve.Literal(v)
+			}
+			out[k] = v
+		}
+	}
+	delete(out, profilestore.ExtendsField)
+	delete(out, resolve.InterpolateField)
+	return out, nil
+}
+
+// withBase returns p, about to be saved as name, merged over the profiles
+// it extends and with defaults filled in, to validate partial children.
+func (d *Driver) withBase(cfg config, name string, p Settings) (Settings, error) {
+	if p[profilestore.ExtendsField] == "" {
+		return d.withDefaults(p), nil
+	}
+	c := config{Profiles: maps.Clone(cfg.Profiles)}
+	c.Profiles[name] = p
+	full, err := d.inherit(c, name, false)
+	if err != nil {
+		return nil, err
+	}
+	return d.withDefaults(full), nil
+}
+
+// Resolve returns the settings name is used with: inherited, with ${VAR}
+// and {{ .profile }} interpolated in the values of profiles that opt in,
+// and defaults filled in.
+func (d *Driver) Resolve(name string) (Settings, error) {
+	cfg, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+	return d.resolve(cfg, name)
+}
+
+func (d *Driver) resolve(cfg config, name string) (Settings, error) {
+	p, err := d.inherit(cfg, name, true)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range p {
+		out, err := resolve.Expand(v, name)
+		if err != nil {
+			return nil, exitcodes.New(exitcodes.ConfigReadWrite, fmt.Sprintf("profile %q field %s: %v", name, k, err))
+		}
+		p[k] = out
+	}
+	return d.withDefaults(p), nil
+}
+
+// secretKeys lists the exported vars that expose a secret field's value,
+// either verbatim (PGPASSWORD) or embedded in a URL or DSN.
+func (d *Driver) secretKeys(name string) ([]string, error) {
+	if len(d.Secrets) > 0 {
+		return d.Secrets, nil
+	}
+	p, err := d.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]string{}
+	if d.Env != nil {
+		vars = d.Env(p)
+	}
+	var secrets []string
+	for _, k := range d.varKeys(p) {
+		if d.secretVar(p, k) {
+			secrets = append(secrets, k)
+		}
+	}
+	for _, f := range d.Fields {
+		if !f.Secret || p[f.Key] == "" {
+			continue
+		}
+		for k, v := range vars {
+			if strings.Contains(v, p[f.Key]) || strings.Contains(v, url.QueryEscape(p[f.Key])) || strings.Contains(v, url.PathEscape(p[f.Key])) {
+				secrets = append(secrets, k)
+			}
+		}
+	}
+	sort.Strings(secrets)
+	return slices.Compact(secrets), nil
+}
+
+// summaries describes each profile for `rdv list --all`, with inherited
+// values filled in but not interpolated.
+func (d *Driver) summaries() ([]plugin.Summary, error) {
+	cfg, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+	var out []plugin.Summary
+	for _, n := range slices.Sorted(maps.Keys(cfg.Profiles)) {
+		p, err := d.inherit(cfg, n, false)
+		if err != nil {
+			p = cfg.Profiles[n]
+		}
+		p = d.withDefaults(p)
+		meta := map[string]string{profilestore.ExtendsField: cfg.Profiles[n][profilestore.ExtendsField]}
+		for _, k := range d.Summary {
+			meta[k] = p[k]
+		}
+		if d.Vars != nil {
+			meta["vars"] = strconv.Itoa(len(d.varKeys(p)))
+		}
+		out = append(out, plugin.Summary{Profile: n, Meta: meta, Modified: plugin.ModTime(d.Path())})
+	}
+	return out, nil
+}
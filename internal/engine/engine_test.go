@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+)
+
+// testDriver is a postgres-like driver saving to a temp dir.
+func testDriver(t *testing.T) *Driver {
+	path := filepath.Join(t.TempDir(), "pg.yaml")
+	return &Driver{
+		Name:  "pg",
+		Label: "Test",
+		Path:  func() string { return path },
+		Fields: []Field{
+			{Key: "host", Title: "Host"},
+			{Key: "port", Title: "Port", Default: "5432", Int: true},
+			{Key: "dbname", Title: "Database"},
+			{Key: "user", Title: "Username"},
+			{Key: "password", Title: "Password", Secret: true},
+			{Key: "hosts", Title: "Hosts", List: true, Optional: true},
+			{Key: "known_hosts", Title: "Known hosts", Lines: true, Optional: true},
+			{Key: "key_file", File: true},
+		},
+		Env: func(p Settings) map[string]string {
+			return map[string]string{
+				"PGHOST": p["host"], "PGPORT": p["port"], "PGUSER": p["user"],
+				"PGPASSWORD": p["password"], "PGDATABASE": p["dbname"],
+				"PG_DATABASE_URL": "postgres://" + p["user"] + ":" + p["password"] + "@" + p["host"],
+			}
+		},
+	}
+}
+
+func saveProfiles(t *testing.T, d *Driver, profiles map[string]Settings) {
+	t.Helper()
+	require.NoError(t, d.save(config{Profiles: profiles}))
+}
+
+func TestExtendsAndInterpolation(t *testing.T) {
+	d := testDriver(t)
+	t.Setenv("STAGING_PG_PASSWORD", "s3cret")
+
+	saveProfiles(t, d, map[string]Settings{
+		"base":    {"interpolate": "true", "host": "db.internal", "port": "6543", "user": "app", "password": "base-pw", "dbname": "app_{{ .profile }}"},
+		"staging": {"extends": "base", "interpolate": "true", "host": "staging.db", "password": "${STAGING_PG_PASSWORD}"},
+	})
+
+	vars, err := d.ExportVars("staging")
+	require.NoError(t, err)
+	require.Equal(t, "staging.db", vars["PGHOST"])
+	require.Equal(t, "6543", vars["PGPORT"], "inherited over the driver default")
+	require.Equal(t, "s3cret", vars["PGPASSWORD"])
+	require.Equal(t, "app_staging", vars["PGDATABASE"], "{{ .profile }} is the requested profile")
+
+	keys, err := d.secretKeys("staging")
+	require.NoError(t, err)
+	require.Equal(t, []string{"PGPASSWORD", "PG_DATABASE_URL"}, keys)
+
+	// Modifying a child doesn't bake defaults or base values into it.
+	require.NoError(t, Modify(d, "staging", false, true, Settings{"user": "svc"}))
+	cfg, _ := d.load()
+	require.Equal(t, Settings{"extends": "base", "interpolate": "true", "host": "staging.db", "password": "${STAGING_PG_PASSWORD}", "user": "svc"}, cfg.Profiles["staging"])
+}
+
+// TestValuesLiteralWithoutOptIn keeps profiles saved before interpolation
+// existed working: their values are used exactly as stored.
+func TestValuesLiteralWithoutOptIn(t *testing.T) {
+	d := testDriver(t)
+	t.Setenv("x", "expanded")
+
+	saveProfiles(t, d, map[string]Settings{
+		"old":   {"host": "h", "user": "u", "dbname": "d", "password": "p{{w${x}"},
+		"child": {"extends": "old", "interpolate": "true", "dbname": "app_{{ .profile }}"},
+	})
+
+	vars, err := d.ExportVars("old")
+	require.NoError(t, err)
+	require.Equal(t, "p{{w${x}", vars["PGPASSWORD"])
+
+	vars, err = d.ExportVars("child")
+	require.NoError(t, err)
+	require.Equal(t, "app_child", vars["PGDATABASE"], "the child's own values are interpolated")
+	require.Equal(t, "p{{w${x}", vars["PGPASSWORD"], "the base's stay literal")
+}
+
+func TestExtendsErrors(t *testing.T) {
+	d := testDriver(t)
+
+	saveProfiles(t, d, map[string]Settings{
+		"a":       {"extends": "b", "host": "h"},
+		"b":       {"extends": "a"},
+		"orphan":  {"extends": "gone"},
+		"unset":   {"interpolate": "true", "host": "h", "user": "u", "dbname": "d", "password": "${RDV_TEST_NOT_SET}"},
+		"badtmpl": {"interpolate": "true", "host": "h", "user": "u", "dbname": "{{ .nope }}", "password": "p"},
+	})
+
+	_, err := d.ExportVars("a")
+	require.Equal(t, exitcodes.ConfigReadWrite, exitcodes.FromError(err))
+	require.ErrorContains(t, err, "a -> b -> a")
+
+	_, err = d.ExportVars("orphan")
+	require.Equal(t, exitcodes.ProfileNotFound, exitcodes.FromError(err))
+	require.ErrorContains(t, err, `"orphan" extends unknown profile "gone"`)
+
+	_, err = d.ExportVars("unset")
+	require.ErrorContains(t, err, "RDV_TEST_NOT_SET is not set")
+
+	_, err = d.ExportVars("badtmpl")
+	require.Equal(t, exitcodes.ConfigReadWrite, exitcodes.FromError(err))
+}
+
+func TestSetConfigChecksFields(t *testing.T) {
+	d := testDriver(t)
+
+	err := SetConfig(d, "ci", false, true, Settings{"host": "h"})
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(err))
+	require.ErrorContains(t, err, "--dbname --user --password")
+
+	err = SetConfig(d, "ci", false, true, Settings{"host": "h", "dbname": "d", "user": "u", "password": "p", "port": "x"})
+	require.ErrorContains(t, err, "--port must be a whole number")
+
+	err = SetConfig(d, "../ci", false, true, Settings{"host": "h", "dbname": "d", "user": "u", "password": "p"})
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(err))
+
+	d.Validate = func(p Settings) error {
+		if p["user"] == "root" {
+			return exitcodes.New(exitcodes.InvalidArgs, "no root")
+		}
+		return nil
+	}
+	require.ErrorContains(t, SetConfig(d, "ci", false, true, Settings{"host": "h", "dbname": "d", "user": "root", "password": "p"}), "no root")
+}
+
+func TestModifyReplacesFields(t *testing.T) {
+	d := testDriver(t)
+	d.Fields = append(d.Fields, Field{Key: "socket", Optional: true, Replaces: []string{"hosts"}})
+	require.NoError(t, SetConfig(d, "dev", false, true, Settings{"host": "h", "dbname": "d", "user": "u", "password": "p", "hosts": "a:1"}))
+
+	require.NoError(t, Modify(d, "dev", false, true, Settings{"socket": "/run/pg.sock"}))
+	cfg, _ := d.load()
+	require.NotContains(t, cfg.Profiles["dev"], "hosts")
+	require.Equal(t, "/run/pg.sock", cfg.Profiles["dev"]["socket"])
+}
+
+func TestModifyClearsEmptyFlag(t *testing.T) {
+	d := testDriver(t)
+	require.NoError(t, SetConfig(d, "dev", false, true, Settings{"host": "h", "dbname": "d", "user": "u", "password": "p", "hosts": "a:1"}))
+
+	fs := pflag.NewFlagSet("modify", pflag.ContinueOnError)
+	d.addFieldFlags(fs)
+	require.NoError(t, fs.Parse([]string{"--hosts=", "--user", "v"}))
+	require.NoError(t, Modify(d, "dev", false, true, d.flagValues(fs)))
+
+	cfg, _ := d.load()
+	require.NotContains(t, cfg.Profiles["dev"], "hosts")
+	require.Equal(t, "v", cfg.Profiles["dev"]["user"])
+	require.Equal(t, "h", cfg.Profiles["dev"]["host"])
+}
+
+func TestListAndLinesValues(t *testing.T) {
+	p := Settings{"hosts": "a:1, b:2", "known_hosts": "h1,h2 ssh-ed25519 AAAA\nh3 ssh-rsa BBBB"}
+	require.Equal(t, []string{"a:1", "b:2"}, p.Values("hosts"))
+	require.Equal(t, []string{"h1,h2 ssh-ed25519 AAAA", "h3 ssh-rsa BBBB"}, p.Lines("known_hosts"))
+	require.Equal(t, []string{"h1,h2 ssh-ed25519 AAAA"}, Settings{"known_hosts": "h1,h2 ssh-ed25519 AAAA"}.Lines("known_hosts"))
+	require.Nil(t, p.Values("missing"))
+}
+
+func TestStoreDiffUsesSecretFields(t *testing.T) {
+	d := testDriver(t)
+	require.NoError(t, SetConfig(d, "dev", false, true, Settings{"host": "localhost", "user": "app", "password": "devsecret", "dbname": "app"}))
+
+	s := store{d}
+	require.NoError(t, profilestore.Copy(s, "dev", "staging", false))
+	require.NoError(t, Modify(d, "staging", false, true, Settings{"host": "stg.internal", "password": "stgsecret"}))
+
+	diffs, err := profilestore.Diff(s, "dev", "staging")
+	require.NoError(t, err)
+	require.Equal(t, []profilestore.FieldDiff{
+		{Field: "host", A: "localhost", B: "stg.internal"},
+		{Field: "password", A: "de****et", B: "st****et", Secret: true},
+	}, diffs)
+}
+
+func TestStoreMovesOwnedFiles(t *testing.T) {
+	d := testDriver(t)
+	s := store{d}
+	require.Equal(t, []string{"key_file"}, s.FileFields())
+
+	src := filepath.Join(t.TempDir(), "restored-key")
+	require.NoError(t, os.WriteFile(src, []byte("KEY"), 0o600))
+	require.NoError(t, s.Put("dev", map[string]string{"host": "h", "key_file": src}))
+
+	fields, err := s.Get("dev")
+	require.NoError(t, err)
+	require.Equal(t, d.FilePath("dev", "key_file"), fields["key_file"], "copied into the profile's own dir")
+
+	require.NoError(t, profilestore.Rename(s, "dev", "prod", false))
+	b, err := os.ReadFile(d.FilePath("prod", "key_file"))
+	require.NoError(t, err)
+	require.Equal(t, "KEY", string(b))
+	require.NoDirExists(t, d.Dir("dev"), "removed with its profile")
+
+	require.NoError(t, s.Delete("prod"))
+	require.NoDirExists(t, d.Dir("prod"))
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
+)
+
+// store exposes one driver's profiles to copy/rename/diff and backups. Files
+// a profile owns (File fields) are copied, removed and backed up with it.
+type store struct{ d *Driver }
+
+func (s store) Names() ([]string, error) {
+	cfg, err := s.d.load()
+	if err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(cfg.Profiles)), nil
+}
+
+func (s store) Get(name string) (map[string]string, error) {
+	cfg, err := s.d.load()
+	if err != nil {
+		return nil, err
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return nil, s.d.notFound(name)
+	}
+	return maps.Clone(p), nil
+}
+
+func (s store) Put(name string, fields map[string]string) error {
+	cfg, err := s.d.load()
+	if err != nil {
+		return err
+	}
+	p := maps.Clone(fields)
+	for _, key := range s.FileFields() {
+		src, dst := p[key], s.d.FilePath(name, key)
+		if src == "" || src == dst {
+			continue
+		}
+		if err := copyFile(src, dst); err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("failed to copy %s: %w", key, err))
+		}
+		p[key] = dst
+	}
+	cfg.Profiles[name] = p
+	return s.d.save(cfg)
+}
+
+func (s store) Delete(name string) error {
+	cfg, err := s.d.load()
+	if err != nil {
+		return err
+	}
+	p := cfg.Profiles[name]
+	delete(cfg.Profiles, name)
+	if err := s.d.save(cfg); err != nil {
+		return err
+	}
+	for _, key := range s.FileFields() {
+		if path := s.d.FilePath(name, key); p[key] == path {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+			}
+		}
+	}
+	_ = os.Remove(s.d.Dir(name)) // only once nothing else is left in it
+	if s.d.Remove != nil {
+		return s.d.Remove(name)
+	}
+	return nil
+}
+
+// Secret also covers every variable: plain ones are marked per profile,
+// which a field name alone can't tell.
+func (s store) Secret(key string) bool {
+	for _, f := range s.d.Fields {
+		if f.Key == key {
+			return f.Secret
+		}
+	}
+	return s.d.isVar(key)
+}
+
+func (s store) Fields() []string {
+	keys := []string{profilestore.ExtendsField, resolve.InterpolateField}
+	for _, f := range s.d.Fields {
+		keys = append(keys, f.Key)
+	}
+	return keys
+}
+
+func (s store) Accepts(key string) bool { return s.d.isVar(key) }
+
+func (s store) FileFields() []string {
+	var keys []string
+	for _, f := range s.d.Fields {
+		if f.File {
+			keys = append(keys, f.Key)
+		}
+	}
+	return keys
+}
+
+// copyFile copies src to dst (0600), creating dst's dir (0700).
+func copyFile(src, dst string) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, b, 0o600)
+}
@@ -0,0 +1,59 @@
+package envfile
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReadEnv parses a dotenv file into key→value pairs. It understands blank
+// lines, # comments, an optional "export " prefix, and single- or
+// double-quoted values (double quotes honour \n, \" and \\ escapes).
+func ReadEnv(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	out := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		k = strings.TrimSpace(k)
+		if k == "" {
+			return nil, fmt.Errorf("%s:%d: empty key", path, n)
+		}
+		out[k] = unquote(strings.TrimSpace(v))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// unquote strips matching quotes; unquoted values lose a trailing " #comment".
+func unquote(v string) string {
+	if len(v) >= 2 {
+		switch {
+		case v[0] == '\'' && v[len(v)-1] == '\'':
+			return v[1 : len(v)-1]
+		case v[0] == '"' && v[len(v)-1] == '"':
+			r := strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`)
+			return r.Replace(v[1 : len(v)-1])
+		}
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	return v
+}
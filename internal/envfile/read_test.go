@@ -0,0 +1,37 @@
+package envfile
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadEnv(t *testing.T) {
+	tmp := t.TempDir() + "/.env"
+	src := `# stripe
+export STRIPE_KEY=sk_test_123
+SENTRY_DSN="https://abc@o1.ingest.sentry.io/2"
+GREETING='hello # not a comment'
+FLAGS=beta,dark-mode # trailing comment
+MULTI="a\nb"
+
+EMPTY=
+`
+	require.NoError(t, os.WriteFile(tmp, []byte(src), 0o600))
+
+	got, err := ReadEnv(tmp)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"STRIPE_KEY": "sk_test_123",
+		"SENTRY_DSN": "https://abc@o1.ingest.sentry.io/2",
+		"GREETING":   "hello # not a comment",
+		"FLAGS":      "beta,dark-mode",
+		"MULTI":      "a\nb",
+		"EMPTY":      "",
+	}, got)
+
+	require.NoError(t, os.WriteFile(tmp, []byte("NOEQUALS\n"), 0o600))
+	_, err = ReadEnv(tmp)
+	require.ErrorContains(t, err, ":1: expected KEY=VALUE")
+}
@@ -0,0 +1,76 @@
+package execenv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ActiveVar lists the profiles loaded into an `rdv shell`, as
+// flag:profile pairs (aws:dev,pg:dev), for prompts and `rdv status`.
+const ActiveVar = "RDV_ACTIVE"
+
+// LevelVar counts nested `rdv shell`s, like SHLVL.
+const LevelVar = "RDV_SHELL_LEVEL"
+
+// Selection is one flag:profile pair of RDV_ACTIVE.
+type Selection struct {
+	Flag    string
+	Profile string
+}
+
+// Selections lists the profiles o selects, in flag order.
+func (o Options) Selections() []Selection {
+	var out []Selection
+	for _, t := range o.targets() {
+		out = append(out, Selection{Flag: t.name, Profile: t.profile})
+	}
+	return out
+}
+
+// ParseActive parses an RDV_ACTIVE value; malformed pairs are skipped.
+func ParseActive(s string) []Selection {
+	var out []Selection
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && k != "" && v != "" {
+			out = append(out, Selection{Flag: k, Profile: v})
+		}
+	}
+	return out
+}
+
+// FormatActive renders selections as an RDV_ACTIVE value.
+func FormatActive(sel []Selection) string {
+	parts := make([]string, len(sel))
+	for i, s := range sel {
+		parts[i] = s.Flag + ":" + s.Profile
+	}
+	return strings.Join(parts, ",")
+}
+
+// MergeActive layers inner over outer for a nested shell: a flag selected
+// again takes the inner profile in place, new flags are appended.
+func MergeActive(outer, inner []Selection) []Selection {
+	out := append([]Selection{}, outer...)
+	for _, s := range inner {
+		replaced := false
+		for i := range out {
+			if out[i].Flag == s.Flag {
+				out[i].Profile, replaced = s.Profile, true
+			}
+		}
+		if !replaced {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// ShellLevel parses a RDV_SHELL_LEVEL value, treating junk as 0.
+func ShellLevel(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,32 @@
+package execenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActiveRoundTrip(t *testing.T) {
+	sel := Options{Profiles: map[string]string{"pg": "dev", "aws": "prod", "custom": "x"}}.Selections()
+	require.Equal(t, []Selection{{"aws", "prod"}, {"pg", "dev"}, {"custom", "x"}}, sel)
+
+	s := FormatActive(sel)
+	require.Equal(t, "aws:prod,pg:dev,custom:x", s)
+	require.Equal(t, sel, ParseActive(s))
+	require.Empty(t, ParseActive(""))
+	require.Equal(t, []Selection{{"aws", "dev"}}, ParseActive("aws:dev,junk,:x,pg:"))
+}
+
+func TestMergeActive(t *testing.T) {
+	outer := ParseActive("aws:dev,pg:dev")
+	merged := MergeActive(outer, ParseActive("pg:prod,github:bot"))
+	require.Equal(t, "aws:dev,pg:prod,github:bot", FormatActive(merged))
+	require.Equal(t, "aws:dev,pg:dev", FormatActive(outer), "outer is not modified")
+}
+
+func TestShellLevel(t *testing.T) {
+	require.Equal(t, 0, ShellLevel(""))
+	require.Equal(t, 0, ShellLevel("x"))
+	require.Equal(t, 0, ShellLevel("-2"))
+	require.Equal(t, 3, ShellLevel("3"))
+}
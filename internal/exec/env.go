@@ -1,66 +1,146 @@
 package execenv
 
 import (
+	"fmt"
 	"maps"
 	"os"
-	"strings"
+	"slices"
 
-	aws "github.com/yonasyiheyis/rdv/internal/plugins/aws"
-	db "github.com/yonasyiheyis/rdv/internal/plugins/db"
-	gh "github.com/yonasyiheyis/rdv/internal/plugins/github"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/logger"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
 )
 
-type Options struct {
-	AWS       string
-	Postgres  string
-	MySQL     string
-	GitHub    string
-	NoInherit bool
+// ProfileFlag is an exec/shell flag selecting a profile of the export
+// target it is named after (a target name or alias, e.g. "pg").
+type ProfileFlag struct {
+	Name string
+	// Label names what it selects in help, e.g. "Postgres profile".
+	Label string
+	// Note says what else is set up for the child, if anything.
+	Note string
 }
 
-// BuildEnv composes environment variables for the selected profiles.
-// Reuses per-plugin ExportVars helpers.
-func BuildEnv(o Options) (map[string]string, error) {
-	env := map[string]string{}
+// ProfileFlags are the exec/shell profile flags, in the order BuildEnv
+// merges them (later ones win) and RDV_ACTIVE lists them.
+var ProfileFlags = []ProfileFlag{
+	{Name: "aws", Label: "AWS profile"},
+	{Name: "azure", Label: "Azure profile"},
+	{Name: "pg", Label: "Postgres profile"},
+	{Name: "mysql", Label: "MySQL profile"},
+	{Name: "github", Label: "GitHub profile"},
+	{Name: "redis", Label: "Redis profile"},
+	{Name: "mongo", Label: "MongoDB profile"},
+	{Name: "kafka", Label: "Kafka profile"},
+	{Name: "registry", Label: "container registry profile", Note: "temporary DOCKER_CONFIG"},
+	{Name: "k8s", Label: "Kubernetes profile", Note: "temporary KUBECONFIG"},
+	{Name: "ssh", Label: "SSH key profile", Note: "loaded into a private ssh-agent"},
+	{Name: "pkg", Label: "package registry token profile", Note: "temporary .npmrc / settings.xml / .netrc"},
+	{Name: "custom", Label: "custom key/value profile"},
+}
 
-	// inherit current process env unless told not to
-	if !o.NoInherit {
-		for _, kv := range os.Environ() {
-			if i := strings.IndexByte(kv, '='); i >= 0 {
-				env[kv[:i]] = kv[i+1:]
-			}
-		}
+// ProfileFlagNames returns the names of ProfileFlags, in order.
+func ProfileFlagNames() []string {
+	names := make([]string, len(ProfileFlags))
+	for i, f := range ProfileFlags {
+		names[i] = f.Name
 	}
+	return names
+}
 
-	// Merge in each selected profile (later ones win on key collisions).
-	if o.AWS != "" {
-		m, err := aws.ExportVars(o.AWS)
-		if err != nil {
-			return nil, err
+// isProfileFlag reports whether name is one of ProfileFlags.
+func isProfileFlag(name string) bool {
+	return slices.Contains(ProfileFlagNames(), name)
+}
+
+type Options struct {
+	// Profiles maps a ProfileFlags name to the profile selected with it.
+	Profiles  map[string]string
+	NoInherit bool
+
+	// Inherit and InheritPrefix name ambient variables to keep; either
+	// one implies NoInherit, extending the default allowlist.
+	Inherit       []string
+	InheritPrefix []string
+	// Drop holds glob patterns (AWS_*) removed from the ambient env
+	// before profiles are merged, so stale credentials can't leak in.
+	Drop []string
+	// SetEnv is applied last and wins over every profile.
+	SetEnv map[string]string
+	// KeepAmbient disables stripping inherited variables related to the
+	// selected plugins (AWS_PROFILE when --aws is used, and so on).
+	KeepAmbient bool
+}
+
+// BuildEnv composes environment variables for the selected profiles
+// through their exporters. Some plugins also materialize temporary files
+// for the child (a DOCKER_CONFIG dir, a KUBECONFIG, an ssh-agent socket,
+// package manager configs); the returned cleanup removes them and must be
+// called once the child exits.
+func BuildEnv(o Options) (env map[string]string, cleanup func(), err error) {
+	env = map[string]string{}
+	var cleanups []func()
+	runCleanups := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
 		}
-		maps.Copy(env, m)
 	}
-	if o.Postgres != "" {
-		m, err := db.PGExportVars(o.Postgres)
+	defer func() {
 		if err != nil {
-			return nil, err
+			runCleanups()
 		}
-		maps.Copy(env, m)
+	}()
+
+	// inherit current process env (all of it, or an allowlist)
+	ambient, err := o.ambient(os.Environ())
+	if err != nil {
+		return nil, nil, err
 	}
-	if o.MySQL != "" {
-		m, err := db.MySQLExportVars(o.MySQL)
-		if err != nil {
-			return nil, err
+	if !o.KeepAmbient {
+		for _, k := range o.stripRelated(ambient) {
+			logger.L.Debugw("removed ambient variable shadowed by injected profile", "var", k)
 		}
-		maps.Copy(env, m)
 	}
-	if o.GitHub != "" {
-		m, err := gh.ExportVars(o.GitHub)
-		if err != nil {
-			return nil, err
+	maps.Copy(env, ambient)
+
+	// Merge in each selected profile (later ones win on key collisions).
+	for _, t := range o.targets() {
+		e, ok := plugin.LookupExporter(t.name)
+		if !ok {
+			return nil, nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("unknown target %q", t.name))
+		}
+		var m map[string]string
+		if e.Exec != nil {
+			var done func()
+			m, done, err = e.Exec(t.profile)
+			if err != nil {
+				return nil, nil, err
+			}
+			cleanups = append(cleanups, done)
+		} else if m, err = e.Export(t.profile); err != nil {
+			return nil, nil, err
+		}
+		// pkg adds its settings to the inherited MAVEN_ARGS (-B, -T 4, ...)
+		// rather than replacing them.
+		if prev := env["MAVEN_ARGS"]; prev != "" && m["MAVEN_ARGS"] != "" {
+			m["MAVEN_ARGS"] = prev + " " + m["MAVEN_ARGS"]
 		}
 		maps.Copy(env, m)
 	}
+	maps.Copy(env, o.SetEnv)
 
-	return env, nil
+	return env, runCleanups, nil
+}
+
+// CleanupFlags returns the ProfileFlags whose exporters set up more than
+// variables (see plugin.Exporter.Exec), so rdv has to outlive the child
+// to undo it.
+func CleanupFlags() []string {
+	var out []string
+	for _, f := range ProfileFlags {
+		if e, ok := plugin.LookupExporter(f.Name); ok && e.Exec != nil {
+			out = append(out, f.Name)
+		}
+	}
+	return out
 }
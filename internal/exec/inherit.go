@@ -0,0 +1,124 @@
+package execenv
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+// DefaultInherit is kept even under NoInherit: without PATH, HOME, the
+// locale and the terminal most commands misbehave. The Windows entries are
+// what the OS itself needs to start processes.
+var DefaultInherit = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "COLORTERM", "LANG", "LANGUAGE", "TZ", "TMPDIR",
+	"SYSTEMROOT", "SYSTEMDRIVE", "COMSPEC", "PATHEXT", "TEMP", "TMP", "USERPROFILE", "APPDATA", "LOCALAPPDATA",
+}
+
+// DefaultInheritPrefix complements DefaultInherit with locale families.
+var DefaultInheritPrefix = []string{"LC_"}
+
+// isolated reports whether only allowlisted ambient vars are inherited.
+func (o Options) isolated() bool {
+	return o.NoInherit || len(o.Inherit) > 0 || len(o.InheritPrefix) > 0
+}
+
+// ambient filters environ (KEY=VALUE entries) down to what the child
+// inherits: everything, or the allowlist when isolated, minus Drop.
+func (o Options) ambient(environ []string) (map[string]string, error) {
+	for _, p := range o.Drop {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid --drop pattern %q: %v", p, err))
+		}
+	}
+
+	names := append(append([]string{}, DefaultInherit...), o.Inherit...)
+	prefixes := append(append([]string{}, DefaultInheritPrefix...), o.InheritPrefix...)
+
+	env := map[string]string{}
+	for _, kv := range environ {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			continue
+		}
+		if o.isolated() && !allowed(k, names, prefixes) {
+			continue
+		}
+		if dropped(k, o.Drop) {
+			continue
+		}
+		env[k] = v
+	}
+	return env, nil
+}
+
+// stripRelated deletes from env every variable in the Related family of a
+// selected target and returns the removed names, sorted. It runs on the
+// ambient env only: what the profiles export is merged afterwards.
+func (o Options) stripRelated(env map[string]string) []string {
+	var removed []string
+	for _, t := range o.targets() {
+		e, ok := plugin.LookupExporter(t.name)
+		if !ok {
+			continue
+		}
+		for k := range env {
+			if dropped(k, e.Related) {
+				delete(env, k)
+				removed = append(removed, k)
+			}
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// fold normalises a variable name for comparison; Windows env names are
+// case-insensitive (Path vs PATH).
+func fold(k string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToUpper(k)
+	}
+	return k
+}
+
+func allowed(k string, names, prefixes []string) bool {
+	k = fold(k)
+	for _, n := range names {
+		if k == fold(n) {
+			return true
+		}
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(k, fold(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+func dropped(k string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(fold(p), fold(k)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSetEnv turns KEY=VALUE flags into a map (later entries win).
+func ParseSetEnv(pairs []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, kv := range pairs {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid --set-env %q, expected KEY=VALUE", kv))
+		}
+		out[k] = v
+	}
+	return out, nil
+}
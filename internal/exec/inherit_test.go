@@ -0,0 +1,141 @@
+package execenv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+
+	// BuildEnv reaches plugins through their registered exporters.
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/aws"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/custom"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/db"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/github"
+	_ "github.com/yonasyiheyis/rdv/internal/plugins/pkg"
+)
+
+var environ = []string{
+	"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C", "GOPATH=/go", "GOFLAGS=-mod=mod",
+	"AWS_ACCESS_KEY_ID=AKIAOLD", "AWS_SESSION_TOKEN=old", "EDITOR=vim",
+}
+
+func TestAmbientInheritsEverythingByDefault(t *testing.T) {
+	env, err := Options{}.ambient(environ)
+	require.NoError(t, err)
+	require.Len(t, env, len(environ))
+}
+
+func TestAmbientNoInheritKeepsDefaults(t *testing.T) {
+	env, err := Options{NoInherit: true}.ambient(environ)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"PATH": "/usr/bin", "HOME": "/home/me", "LC_ALL": "C"}, env)
+}
+
+func TestAmbientAllowlistAndDrop(t *testing.T) {
+	env, err := Options{Inherit: []string{"EDITOR"}, InheritPrefix: []string{"GO"}}.ambient(environ)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"PATH": "/usr/bin", "HOME": "/home/me", "LC_ALL": "C",
+		"EDITOR": "vim", "GOPATH": "/go", "GOFLAGS": "-mod=mod",
+	}, env)
+
+	env, err = Options{Drop: []string{"AWS_*", "GOFLAGS"}}.ambient(environ)
+	require.NoError(t, err)
+	require.NotContains(t, env, "AWS_ACCESS_KEY_ID")
+	require.NotContains(t, env, "AWS_SESSION_TOKEN")
+	require.NotContains(t, env, "GOFLAGS")
+	require.Contains(t, env, "GOPATH")
+
+	_, err = Options{Drop: []string{"AWS_["}}.ambient(environ)
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(err))
+}
+
+func TestBuildEnvDropAndSetEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("RDV_CUSTOM_DIR", dir)
+	t.Setenv("AWS_SESSION_TOKEN", "stale")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "custom.yaml"), []byte(`profiles:
+  app:
+    API_URL: https://api.test
+    AWS_REGION: us-east-1
+`), 0o600))
+
+	env, cleanup, err := BuildEnv(Options{
+		Profiles: map[string]string{"custom": "app"},
+		Drop:     []string{"AWS_*"},
+		SetEnv:   map[string]string{"API_URL": "http://localhost:8080"},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	require.NotContains(t, env, "AWS_SESSION_TOKEN")          // ambient, dropped
+	require.Equal(t, "us-east-1", env["AWS_REGION"])          // from the profile, kept
+	require.Equal(t, "http://localhost:8080", env["API_URL"]) // --set-env wins
+}
+
+func TestParseSetEnv(t *testing.T) {
+	m, err := ParseSetEnv([]string{"A=1", "B=x=y", "A=2"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"A": "2", "B": "x=y"}, m)
+
+	_, err = ParseSetEnv([]string{"NOPE"})
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(err))
+}
+
+func TestStripRelated(t *testing.T) {
+	env := map[string]string{
+		"AWS_PROFILE": "corp", "AWS_SESSION_TOKEN": "old", "AWS_REGION": "us-west-2",
+		"PGPASSWORD": "x", "PGSSLMODE": "require", "REDIS_URL": "redis://old",
+	}
+	removed := Options{Profiles: map[string]string{"aws": "dev", "pg": "dev"}}.stripRelated(env)
+	require.Equal(t, []string{"AWS_PROFILE", "AWS_SESSION_TOKEN", "PGPASSWORD", "PGSSLMODE"}, removed)
+	require.Equal(t, map[string]string{"AWS_REGION": "us-west-2", "REDIS_URL": "redis://old"}, env)
+}
+
+func TestBuildEnvKeepAmbient(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("RDV_GH_DIR", dir)
+	t.Setenv("GH_TOKEN", "ambient")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "github.yaml"), []byte("profiles:\n  bot:\n    token: injected\n"), 0o600))
+
+	env, cleanup, err := BuildEnv(Options{Profiles: map[string]string{"github": "bot"}})
+	require.NoError(t, err)
+	cleanup()
+	require.Equal(t, "injected", env["GITHUB_TOKEN"])
+	require.NotContains(t, env, "GH_TOKEN")
+
+	env, cleanup, err = BuildEnv(Options{Profiles: map[string]string{"github": "bot"}, KeepAmbient: true})
+	require.NoError(t, err)
+	cleanup()
+	require.Equal(t, "ambient", env["GH_TOKEN"])
+}
+
+func TestBuildEnvKeepsInheritedMavenArgs(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("RDV_PKG_DIR", dir)
+	t.Setenv("MAVEN_ARGS", "-B")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg.yaml"), []byte(`profiles:
+  ci:
+    maven_servers: github=bot:p
+`), 0o600))
+
+	env, cleanup, err := BuildEnv(Options{Profiles: map[string]string{"pkg": "ci"}})
+	require.NoError(t, err)
+	defer cleanup()
+	require.Regexp(t, `^-B -gs \S+settings\.xml$`, env["MAVEN_ARGS"])
+
+	env, cleanup, err = BuildEnv(Options{Profiles: map[string]string{"pkg": "ci"}, NoInherit: true})
+	require.NoError(t, err)
+	defer cleanup()
+	require.Regexp(t, `^-gs \S+settings\.xml$`, env["MAVEN_ARGS"])
+}
+
+func TestBuildEnvMissingProfile(t *testing.T) {
+	t.Setenv("RDV_CUSTOM_DIR", t.TempDir())
+
+	_, _, err := BuildEnv(Options{Profiles: map[string]string{"custom": "missing"}})
+	require.Equal(t, exitcodes.ProfileNotFound, exitcodes.FromError(err))
+}
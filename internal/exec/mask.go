@@ -0,0 +1,155 @@
+package execenv
+
+import (
+	"encoding/base64"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Mask replaces secret values in masked output.
+const Mask = "***"
+
+// MinMaskLen is the shortest secret value that gets masked; shorter ones
+// ("1", "on") would shred ordinary output.
+const MinMaskLen = 4
+
+// MaskPatterns expands secret values into every form that should be
+// masked: verbatim, URL-encoded (query and path) and base64 (standard and
+// URL alphabets, padded and raw). Longest first, duplicates removed.
+func MaskPatterns(values []string) []string {
+	seen := map[string]bool{}
+	for _, v := range values {
+		if len(v) < MinMaskLen {
+			continue
+		}
+		for _, p := range []string{
+			v,
+			url.QueryEscape(v),
+			url.PathEscape(v),
+			base64.StdEncoding.EncodeToString([]byte(v)),
+			base64.URLEncoding.EncodeToString([]byte(v)),
+			base64.RawStdEncoding.EncodeToString([]byte(v)),
+			base64.RawURLEncoding.EncodeToString([]byte(v)),
+		} {
+			seen[p] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for p := range seen {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i]) != len(out[j]) {
+			return len(out[i]) > len(out[j])
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// Masker is a streaming filter that writes through to w with every pattern
+// replaced by Mask. A secret split across two writes is still caught: the
+// tail of a write that could begin a pattern is held back until the next
+// write (or Flush) shows whether it completes one.
+type Masker struct {
+	w        io.Writer
+	patterns []string
+	buf      string
+}
+
+// NewMasker masks patterns (see MaskPatterns) in everything written to w.
+func NewMasker(w io.Writer, patterns []string) *Masker {
+	return &Masker{w: w, patterns: patterns}
+}
+
+func (m *Masker) Write(b []byte) (int, error) {
+	m.buf += string(b)
+	out, rest := m.scan(false)
+	m.buf = rest
+	if out != "" {
+		if _, err := io.WriteString(m.w, out); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush writes out whatever is held back; call it once the stream ends.
+func (m *Masker) Flush() error {
+	out, _ := m.scan(true)
+	m.buf = ""
+	if out == "" {
+		return nil
+	}
+	_, err := io.WriteString(m.w, out)
+	return err
+}
+
+// scan masks complete matches in m.buf and returns what is safe to emit
+// plus the held-back tail (empty when final).
+func (m *Masker) scan(final bool) (out, rest string) {
+	var b strings.Builder
+	s := m.buf
+	for {
+		i, n := m.match(s, final)
+		if i < 0 {
+			break
+		}
+		b.WriteString(s[:i])
+		b.WriteString(Mask)
+		s = s[i+n:]
+	}
+	if final {
+		b.WriteString(s)
+		return b.String(), ""
+	}
+	keep := m.partial(s)
+	b.WriteString(s[:keep])
+	return b.String(), s[keep:]
+}
+
+// match finds the leftmost pattern occurrence in s (longest on ties). A
+// match that might still grow into a longer pattern with more input is
+// deferred unless final.
+func (m *Masker) match(s string, final bool) (at, length int) {
+	at, length = -1, 0
+	for _, p := range m.patterns {
+		i := strings.Index(s, p)
+		if i < 0 || (at >= 0 && i > at) || (i == at && len(p) <= length) {
+			continue
+		}
+		at, length = i, len(p)
+	}
+	if at < 0 || final {
+		return at, length
+	}
+	for _, p := range m.patterns {
+		if len(p) > length && len(s)-at < len(p) && strings.HasPrefix(p, s[at:]) {
+			return -1, 0
+		}
+	}
+	return at, length
+}
+
+// partial returns the offset of the earliest suffix of s that is a proper
+// prefix of some pattern, or len(s) when nothing needs holding back.
+func (m *Masker) partial(s string) int {
+	for i := max(0, len(s)-longest(m.patterns)+1); i < len(s); i++ {
+		for _, p := range m.patterns {
+			if len(s)-i < len(p) && strings.HasPrefix(p, s[i:]) {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+func longest(patterns []string) int {
+	n := 0
+	for _, p := range patterns {
+		n = max(n, len(p))
+	}
+	return n
+}
@@ -0,0 +1,73 @@
+package execenv
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskPatterns(t *testing.T) {
+	p := MaskPatterns([]string{"p@ss word", "on", "p@ss word"})
+	require.Contains(t, p, "p@ss word")
+	require.Contains(t, p, url.QueryEscape("p@ss word"))
+	require.Contains(t, p, url.PathEscape("p@ss word"))
+	require.Contains(t, p, base64.StdEncoding.EncodeToString([]byte("p@ss word")))
+	require.Contains(t, p, base64.RawURLEncoding.EncodeToString([]byte("p@ss word")))
+	require.NotContains(t, p, "on", "values shorter than MinMaskLen are left alone")
+	for i := 1; i < len(p); i++ {
+		require.GreaterOrEqual(t, len(p[i-1]), len(p[i]))
+	}
+}
+
+func TestMaskerMasksAllForms(t *testing.T) {
+	var out bytes.Buffer
+	m := NewMasker(&out, MaskPatterns([]string{"s3cr3t/key"}))
+	_, _ = m.Write([]byte("raw=s3cr3t/key url=" + url.QueryEscape("s3cr3t/key") +
+		" b64=" + base64.StdEncoding.EncodeToString([]byte("s3cr3t/key")) + "\n"))
+	require.NoError(t, m.Flush())
+	require.Equal(t, "raw=*** url=*** b64=***\n", out.String())
+}
+
+func TestMaskerSplitWrites(t *testing.T) {
+	secret := "hunter22"
+	input := "token hunter22 and hunter2 again hunter22\n"
+
+	// Every possible split, including one byte per write.
+	for step := 1; step <= len(input); step++ {
+		var out bytes.Buffer
+		m := NewMasker(&out, MaskPatterns([]string{secret}))
+		for i := 0; i < len(input); i += step {
+			n, err := m.Write([]byte(input[i:min(i+step, len(input))]))
+			require.NoError(t, err)
+			require.Equal(t, min(step, len(input)-i), n)
+		}
+		require.NoError(t, m.Flush())
+		require.Equal(t, "token *** and hunter2 again ***\n", out.String(), "step %d", step)
+	}
+}
+
+func TestMaskerHoldsBackOnlyPossiblePrefixes(t *testing.T) {
+	var out bytes.Buffer
+	m := NewMasker(&out, []string{"abcdef"})
+
+	_, _ = m.Write([]byte("xyz abc"))
+	require.Equal(t, "xyz ", out.String(), "a possible secret prefix waits for more input")
+	_, _ = m.Write([]byte("xyz\n"))
+	require.Equal(t, "xyz abcxyz\n", out.String())
+
+	_, _ = m.Write([]byte("tail abcd"))
+	require.NoError(t, m.Flush())
+	require.Equal(t, "xyz abcxyz\ntail abcd", out.String(), "Flush releases an incomplete prefix")
+}
+
+func TestMaskerPrefersLongerPattern(t *testing.T) {
+	var out bytes.Buffer
+	m := NewMasker(&out, []string{"abcdefgh", "abcd"})
+	_, _ = m.Write([]byte("1 abcd"))
+	_, _ = m.Write([]byte("efgh 2 abcd 3\n"))
+	require.NoError(t, m.Flush())
+	require.Equal(t, "1 *** 2 *** 3\n", out.String())
+}
@@ -0,0 +1,91 @@
+package execenv
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// Axis is one --matrix dimension: an exec profile flag and the profiles to
+// cycle through, e.g. pg=dev,staging,prod.
+type Axis struct {
+	Key      string
+	Profiles []string
+}
+
+// Combo is one run of a matrix: the options to build its env from and a
+// label such as "pg=dev aws=prod" used to prefix its output.
+type Combo struct {
+	Label    string
+	Profiles map[string]string
+	Options  Options
+}
+
+// ParseMatrix parses --matrix values of the form key=profile[,profile...].
+func ParseMatrix(specs []string) ([]Axis, error) {
+	var axes []Axis
+	seen := map[string]bool{}
+	for _, s := range specs {
+		k, v, ok := strings.Cut(s, "=")
+		k = strings.TrimSpace(k)
+		if !ok || !isProfileFlag(k) {
+			return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid --matrix %q, expected <flag>=<profile>[,<profile>...] with flag one of %s", s, strings.Join(ProfileFlagNames(), ", ")))
+		}
+		if seen[k] {
+			return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("--matrix %s given twice", k))
+		}
+		seen[k] = true
+
+		var profiles []string
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				profiles = append(profiles, p)
+			}
+		}
+		if len(profiles) == 0 {
+			return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("--matrix %s lists no profiles", k))
+		}
+		axes = append(axes, Axis{Key: k, Profiles: profiles})
+	}
+	return axes, nil
+}
+
+// Combinations expands axes into their cartesian product on top of base,
+// first axis outermost. An axis may not override a profile base already
+// selects with its own flag.
+func Combinations(base Options, axes []Axis) ([]Combo, error) {
+	for _, a := range axes {
+		if base.Profiles[a.Key] != "" {
+			return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("--%s and --matrix %s=... cannot be combined", a.Key, a.Key))
+		}
+	}
+
+	combos := []Combo{{Options: base, Profiles: map[string]string{}}}
+	for _, a := range axes {
+		var next []Combo
+		for _, c := range combos {
+			for _, p := range a.Profiles {
+				o := c.Options
+				o.Profiles = maps.Clone(o.Profiles)
+				if o.Profiles == nil {
+					o.Profiles = map[string]string{}
+				}
+				o.Profiles[a.Key] = p
+
+				profiles := map[string]string{a.Key: p}
+				for k, v := range c.Profiles {
+					profiles[k] = v
+				}
+				label := a.Key + "=" + p
+				if c.Label != "" {
+					label = c.Label + " " + label
+				}
+				next = append(next, Combo{Label: label, Profiles: profiles, Options: o})
+			}
+		}
+		combos = next
+	}
+	return combos, nil
+}
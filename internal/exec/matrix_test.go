@@ -0,0 +1,51 @@
+package execenv
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+func TestMatrixCombinations(t *testing.T) {
+	axes, err := ParseMatrix([]string{"pg=dev, staging,prod", "aws=a,b"})
+	require.NoError(t, err)
+	require.Equal(t, []Axis{{"pg", []string{"dev", "staging", "prod"}}, {"aws", []string{"a", "b"}}}, axes)
+
+	combos, err := Combinations(Options{Profiles: map[string]string{"redis": "shared"}}, axes)
+	require.NoError(t, err)
+	require.Len(t, combos, 6)
+	require.Equal(t, "pg=dev aws=a", combos[0].Label)
+	require.Equal(t, "pg=prod aws=b", combos[5].Label)
+	require.Equal(t, map[string]string{"pg": "staging", "aws": "a"}, combos[2].Profiles)
+	require.Equal(t, Options{Profiles: map[string]string{"redis": "shared", "pg": "staging", "aws": "a"}}, combos[2].Options)
+}
+
+func TestMatrixRejectsBadSpecs(t *testing.T) {
+	for _, spec := range [][]string{{"pg"}, {"nope=dev"}, {"pg="}, {"pg=a", "pg=b"}} {
+		_, err := ParseMatrix(spec)
+		require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(err), spec)
+	}
+
+	axes, _ := ParseMatrix([]string{"pg=dev"})
+	_, err := Combinations(Options{Profiles: map[string]string{"pg": "ci"}}, axes)
+	require.ErrorContains(t, err, "--pg and --matrix pg")
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	a := NewPrefixWriter(&out, &mu, "[a] ")
+	b := NewPrefixWriter(&out, &mu, "[b] ")
+
+	_, _ = a.Write([]byte("one\ntw"))
+	_, _ = b.Write([]byte("x\n"))
+	_, _ = a.Write([]byte("o\nthree"))
+	require.NoError(t, a.Flush())
+	require.NoError(t, b.Flush())
+
+	require.Equal(t, "[a] one\n[b] x\n[a] two\n[a] three\n", out.String())
+}
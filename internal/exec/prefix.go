@@ -0,0 +1,53 @@
+package execenv
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// PrefixWriter prepends a label to every line written through it. Only
+// whole lines reach the underlying writer, under a lock that may be shared
+// with other PrefixWriters, so parallel runs never interleave mid-line.
+type PrefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+// NewPrefixWriter returns a PrefixWriter writing to w under mu.
+func NewPrefixWriter(w io.Writer, mu *sync.Mutex, prefix string) *PrefixWriter {
+	return &PrefixWriter{mu: mu, w: w, prefix: []byte(prefix)}
+}
+
+func (p *PrefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		if err := p.emit(p.buf[:i+1]); err != nil {
+			return len(b), err
+		}
+		p.buf = p.buf[i+1:]
+	}
+}
+
+// Flush writes a trailing partial line, terminating it with a newline.
+func (p *PrefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	line := append(p.buf, '\n')
+	p.buf = nil
+	return p.emit(line)
+}
+
+func (p *PrefixWriter) emit(line []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.w.Write(append(append([]byte{}, p.prefix...), line...))
+	return err
+}
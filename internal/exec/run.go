@@ -0,0 +1,36 @@
+package execenv
+
+import (
+	"io"
+	"os"
+)
+
+// RunOptions controls how Run supervises the child process.
+type RunOptions struct {
+	// ProcessGroup starts the child in a new process group and delivers
+	// forwarded signals to the whole group, so grandchildren see them too.
+	// The child is then no longer in the terminal's foreground group, which
+	// suits CI and daemons better than interactive programs.
+	ProcessGroup bool
+	// ExtraFiles are inherited by the child as descriptors 3, 4, ...
+	// (see SecretsToPipe). Not supported on Windows.
+	ExtraFiles []*os.File
+	// Stdin, Stdout and Stderr default to rdv's own when nil.
+	Stdin          io.Reader
+	Stdout, Stderr io.Writer
+}
+
+func (o RunOptions) stdio() (io.Reader, io.Writer, io.Writer) {
+	var in io.Reader = os.Stdin
+	var out, errw io.Writer = os.Stdout, os.Stderr
+	if o.Stdin != nil {
+		in = o.Stdin
+	}
+	if o.Stdout != nil {
+		out = o.Stdout
+	}
+	if o.Stderr != nil {
+		errw = o.Stderr
+	}
+	return in, out, errw
+}
@@ -0,0 +1,108 @@
+//go:build !windows
+
+package execenv
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// forwarded are the signals relayed to the child. Job-control signals
+// (TSTP, TTIN, TTOU, CONT) are left alone so Ctrl-Z keeps stopping rdv
+// and the child together; SIGKILL and SIGSTOP cannot be caught at all.
+var forwarded = []os.Signal{
+	syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM,
+	syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGWINCH, syscall.SIGALRM,
+}
+
+// fromTTY reports whether sig is one the terminal sends to its whole
+// foreground process group (Ctrl-C, Ctrl-\).
+func fromTTY(sig syscall.Signal) bool {
+	return sig == syscall.SIGINT || sig == syscall.SIGQUIT
+}
+
+// inForeground reports whether rdv's process group is the foreground group
+// of its controlling terminal. Only then did an INT or QUIT most likely come
+// from the keyboard and reach a child sharing the group on its own; one sent
+// to rdv alone (kill -INT, timeout -s INT, a CI runner) must be relayed.
+var inForeground = func() bool {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false // no controlling terminal
+	}
+	defer tty.Close()
+	pgrp, err := unix.IoctlGetInt(int(tty.Fd()), unix.TIOCGPGRP)
+	return err == nil && pgrp == syscall.Getpgrp()
+}
+
+// Run starts argv with env and stdio attached, forwards signals until it
+// exits and returns its exit status: the child's own code, or 128+N when
+// signal N killed it (the shell convention). err is set only when the
+// child could not be started.
+func Run(argv, env []string, o RunOptions) (int, error) {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = o.stdio()
+	cmd.ExtraFiles = o.ExtraFiles
+	if o.ProcessGroup {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
+
+	// Subscribe before starting so no signal slips through and kills rdv
+	// while the child keeps running.
+	sigs := make(chan os.Signal, 16)
+	signal.Notify(sigs, forwarded...)
+	defer signal.Stop(sigs)
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case s := <-sigs:
+				sig := s.(syscall.Signal)
+				switch {
+				case o.ProcessGroup:
+					_ = syscall.Kill(-cmd.Process.Pid, sig)
+				// A keyboard INT or QUIT already reached a child in rdv's
+				// foreground group; relaying it would double it.
+				case !fromTTY(sig) || !inForeground():
+					_ = cmd.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	close(done)
+	if cmd.ProcessState == nil {
+		return 0, err
+	}
+	return exitStatus(cmd.ProcessState), nil
+}
+
+func exitStatus(ps *os.ProcessState) int {
+	if ws, ok := ps.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return ps.ExitCode()
+}
+
+// Replace execs argv in place of the current process; it only returns on
+// failure. Nothing deferred by the caller runs afterwards.
+func Replace(argv, env []string) error {
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, argv, env)
+}
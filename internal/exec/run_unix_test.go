@@ -0,0 +1,204 @@
+//go:build !windows
+
+package execenv
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelperProcess is not a real test: Run re-executes the test binary
+// with RDV_HELPER set and this function plays the child.
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv("RDV_HELPER")
+	if mode == "" {
+		t.Skip("helper process only")
+	}
+	ready := func() { _ = os.WriteFile(os.Getenv("RDV_HELPER_READY"), nil, 0o600) }
+
+	switch mode {
+	case "exit3":
+		os.Exit(3)
+	case "sigkill":
+		_ = syscall.Kill(os.Getpid(), syscall.SIGKILL)
+	case "trap-term": // exit 42 once SIGTERM arrives
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGTERM)
+		ready()
+		<-c
+		os.Exit(42)
+	case "wait": // default disposition: SIGTERM kills us
+		ready()
+	case "read-fd": // exit 0 if fd 3 carries the expected secrets
+		b, _ := io.ReadAll(os.NewFile(SecretsFD, "secrets"))
+		if os.Getenv("RDV_SECRETS_FD") == "3" && string(b) == "TOKEN=abc\n" {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	case "count-int": // exit with the number of SIGINTs received
+		c := make(chan os.Signal, 4)
+		signal.Notify(c, syscall.SIGINT)
+		_ = os.WriteFile(os.Getenv("RDV_HELPER_READY"), []byte(strconv.Itoa(os.Getpid())), 0o600)
+		<-c
+		n := 1
+		for {
+			select {
+			case <-c:
+				n++
+			case <-time.After(300 * time.Millisecond):
+				os.Exit(n)
+			}
+		}
+	case "pgroup":
+		if syscall.Getpgrp() == os.Getpid() {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+	time.Sleep(10 * time.Second)
+	os.Exit(99)
+}
+
+func helper(t *testing.T, mode string) ([]string, []string, string) {
+	t.Helper()
+	ready := filepath.Join(t.TempDir(), "ready")
+	argv := []string{os.Args[0], "-test.run=^TestHelperProcess$"}
+	env := append(os.Environ(), "RDV_HELPER="+mode, "RDV_HELPER_READY="+ready)
+	return argv, env, ready
+}
+
+// signalWhenReady sends sig to the test process (where Run is listening)
+// once the helper has signalled readiness.
+func signalWhenReady(t *testing.T, ready string, sig syscall.Signal) {
+	t.Helper()
+	go func() {
+		for i := 0; i < 500; i++ {
+			if _, err := os.Stat(ready); err == nil {
+				_ = syscall.Kill(os.Getpid(), sig)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+}
+
+func TestRunExitCode(t *testing.T) {
+	argv, env, _ := helper(t, "exit3")
+	code, err := Run(argv, env, RunOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 3, code)
+}
+
+func TestRunSignalDeath(t *testing.T) {
+	argv, env, _ := helper(t, "sigkill")
+	code, err := Run(argv, env, RunOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 128+int(syscall.SIGKILL), code)
+}
+
+func TestRunForwardsSignals(t *testing.T) {
+	argv, env, ready := helper(t, "trap-term")
+	signalWhenReady(t, ready, syscall.SIGTERM)
+	code, err := Run(argv, env, RunOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 42, code)
+
+	argv, env, ready = helper(t, "wait")
+	signalWhenReady(t, ready, syscall.SIGTERM)
+	code, err = Run(argv, env, RunOptions{ProcessGroup: true})
+	require.NoError(t, err)
+	require.Equal(t, 128+int(syscall.SIGTERM), code)
+}
+
+// pretendForeground makes Run see rdv's group as the terminal's foreground
+// group or not, whatever terminal the test runs under.
+func pretendForeground(t *testing.T, fg bool) {
+	orig := inForeground
+	t.Cleanup(func() { inForeground = orig })
+	inForeground = func() bool { return fg }
+}
+
+// TestRunInterruptDeliveredOnce plays the terminal: Ctrl-C sends SIGINT to
+// every process in the foreground group, here rdv and the child.
+func TestRunInterruptDeliveredOnce(t *testing.T) {
+	pretendForeground(t, true)
+	argv, env, ready := helper(t, "count-int")
+	go func() {
+		for i := 0; i < 500; i++ {
+			if b, _ := os.ReadFile(ready); len(b) > 0 {
+				pid, _ := strconv.Atoi(string(b))
+				_ = syscall.Kill(pid, syscall.SIGINT)
+				_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	code, err := Run(argv, env, RunOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, code, "child saw the interrupt more than once")
+
+	// In its own group the child is out of the terminal's reach, so rdv
+	// relays the interrupt.
+	argv, env, ready = helper(t, "count-int")
+	go func() {
+		for i := 0; i < 500; i++ {
+			if b, _ := os.ReadFile(ready); len(b) > 0 {
+				_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	code, err = Run(argv, env, RunOptions{ProcessGroup: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, code)
+}
+
+// TestRunRelaysInterruptWithoutTTY sends SIGINT to rdv alone, as kill -INT,
+// timeout -s INT or a CI runner does; the child must still get it.
+func TestRunRelaysInterruptWithoutTTY(t *testing.T) {
+	pretendForeground(t, false)
+	argv, env, ready := helper(t, "count-int")
+	signalWhenReady(t, ready, syscall.SIGINT)
+	code, err := Run(argv, env, RunOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, code)
+}
+
+func TestRunProcessGroup(t *testing.T) {
+	argv, env, _ := helper(t, "pgroup")
+	code, err := Run(argv, env, RunOptions{ProcessGroup: true})
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+
+	code, err = Run(argv, env, RunOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, code)
+}
+
+func TestRunSpawnFailure(t *testing.T) {
+	_, err := Run([]string{"/nonexistent/rdv-no-such-binary"}, nil, RunOptions{})
+	require.Error(t, err)
+}
+
+func TestRunPassesSecretsFD(t *testing.T) {
+	argv, env, _ := helper(t, "read-fd")
+	vars := map[string]string{"TOKEN": "abc"}
+	r, cleanup, err := SecretsToPipe(vars, []string{"TOKEN"})
+	require.NoError(t, err)
+	defer cleanup()
+
+	env = append(env, "RDV_SECRETS_FD="+vars["RDV_SECRETS_FD"])
+	code, err := Run(argv, env, RunOptions{ExtraFiles: []*os.File{r}})
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+}
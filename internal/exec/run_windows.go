@@ -0,0 +1,40 @@
+//go:build windows
+
+package execenv
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// Run starts argv with env and stdio attached and returns its exit code.
+// Windows delivers Ctrl-C to every process on the console, so rdv only
+// has to survive it long enough to report the child's status.
+func Run(argv, env []string, o RunOptions) (int, error) {
+	if len(o.ExtraFiles) > 0 {
+		return 0, errors.New("passing file descriptors to the child is not supported on Windows")
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = o.stdio()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	err := cmd.Wait()
+	if cmd.ProcessState == nil {
+		return 0, err
+	}
+	return cmd.ProcessState.ExitCode(), nil
+}
+
+// Replace is not available on Windows, which has no exec(2).
+func Replace(_, _ []string) error {
+	return errors.New("--replace is not supported on Windows")
+}
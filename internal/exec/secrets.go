@@ -0,0 +1,133 @@
+package execenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+// SecretsFD is the descriptor number the child sees for the secrets pipe
+// (the first of exec.Cmd.ExtraFiles).
+const SecretsFD = 3
+
+// target pairs an exec profile flag, which names an export target, with
+// the profile selected for it.
+type target struct{ name, profile string }
+
+// targets lists the profiles o selects, in ProfileFlags order.
+func (o Options) targets() []target {
+	var out []target
+	for _, f := range ProfileFlags {
+		if p := o.Profiles[f.Name]; p != "" {
+			out = append(out, target{f.Name, p})
+		}
+	}
+	return out
+}
+
+// SecretKeys returns the sorted variable names the selected profiles mark
+// as credentials, using each exporter's Secrets metadata.
+func SecretKeys(o Options) ([]string, error) {
+	seen := map[string]bool{}
+	for _, t := range o.targets() {
+		e, ok := plugin.LookupExporter(t.name)
+		if !ok || e.Secrets == nil {
+			continue
+		}
+		keys, err := e.Secrets(t.profile)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			seen[k] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for k := range seen {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// TmpfsDir is the directory used for `--secrets-as-files tmpfs`: /dev/shm
+// when the system has it (memory-backed), the OS temp dir otherwise.
+func TmpfsDir() string {
+	if fi, err := os.Stat("/dev/shm"); err == nil && fi.IsDir() {
+		return "/dev/shm"
+	}
+	return os.TempDir()
+}
+
+// SecretsToFiles moves every key of keys present in env into its own 0400
+// file under a fresh private directory inside dir, replacing KEY with
+// KEY_FILE=<path>. cleanup removes the directory.
+func SecretsToFiles(env map[string]string, keys []string, dir string) (cleanup func(), err error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	priv, err := os.MkdirTemp(dir, "rdv-secrets-")
+	if err != nil {
+		return nil, err
+	}
+	cleanup = func() { _ = os.RemoveAll(priv) }
+
+	for _, k := range keys {
+		v, ok := env[k]
+		if !ok {
+			continue
+		}
+		path := filepath.Join(priv, k)
+		if err := os.WriteFile(path, []byte(v), 0o400); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("write secret %s: %w", k, err)
+		}
+		delete(env, k)
+		env[k+"_FILE"] = path
+	}
+	return cleanup, nil
+}
+
+// SecretsToPipe removes every key of keys present in env and streams them
+// as dotenv lines (KEY=VALUE, double-quoted when needed) through a pipe.
+// The returned read end is meant for the child's ExtraFiles, and env gets
+// RDV_SECRETS_FD pointing at it. cleanup closes both ends.
+func SecretsToPipe(env map[string]string, keys []string) (r *os.File, cleanup func(), err error) {
+	var b strings.Builder
+	for _, k := range keys {
+		v, ok := env[k]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "%s=%s\n", k, dotenvQuote(v))
+		delete(env, k)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	// Write in the background: payloads larger than the pipe buffer only
+	// drain once the child reads. Closing w in cleanup unblocks a child
+	// that never does.
+	go func() {
+		_, _ = w.WriteString(b.String())
+		_ = w.Close()
+	}()
+	env["RDV_SECRETS_FD"] = fmt.Sprint(SecretsFD)
+	return r, func() { _ = r.Close(); _ = w.Close() }, nil
+}
+
+// dotenvQuote leaves plain values alone and double-quotes the rest with
+// the escapes envfile.ReadEnv understands.
+func dotenvQuote(v string) string {
+	if !strings.ContainsAny(v, "\n\"'# \\") {
+		return v
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(v) + `"`
+}
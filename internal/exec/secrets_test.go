@@ -0,0 +1,72 @@
+package execenv
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/envfile"
+)
+
+func TestSecretKeysFromExporterMetadata(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("RDV_CUSTOM_DIR", dir)
+	t.Setenv("RDV_GH_DIR", dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "custom.yaml"), []byte(`profiles:
+  app:
+    STRIPE_KEY: sk_test
+    FEATURE_BETA: "true"
+    plain: FEATURE_BETA
+`), 0o600))
+
+	keys, err := SecretKeys(Options{Profiles: map[string]string{"custom": "app", "github": "bot"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"GITHUB_TOKEN", "STRIPE_KEY"}, keys)
+}
+
+func TestSecretsToFiles(t *testing.T) {
+	dir := t.TempDir()
+	env := map[string]string{"PGPASSWORD": "s3cret", "PGHOST": "db"}
+
+	cleanup, err := SecretsToFiles(env, []string{"PGPASSWORD", "ABSENT"}, dir)
+	require.NoError(t, err)
+
+	path := env["PGPASSWORD_FILE"]
+	require.NotContains(t, env, "PGPASSWORD")
+	require.NotContains(t, env, "ABSENT_FILE")
+	require.Equal(t, "db", env["PGHOST"])
+	require.Equal(t, dir, filepath.Dir(filepath.Dir(path)))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", string(b))
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o400), fi.Mode().Perm())
+
+	cleanup()
+	_, err = os.Stat(filepath.Dir(path))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSecretsToPipe(t *testing.T) {
+	env := map[string]string{"TOKEN": "abc", "DSN": `p@ss "word" # x`, "HOST": "h"}
+
+	r, cleanup, err := SecretsToPipe(env, []string{"DSN", "TOKEN"})
+	require.NoError(t, err)
+	defer cleanup()
+
+	require.Equal(t, map[string]string{"HOST": "h", "RDV_SECRETS_FD": "3"}, env)
+
+	// The payload round-trips through the dotenv reader.
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	f := filepath.Join(t.TempDir(), "secrets.env")
+	require.NoError(t, os.WriteFile(f, b, 0o600))
+	got, err := envfile.ReadEnv(f)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"TOKEN": "abc", "DSN": `p@ss "word" # x`}, got)
+}
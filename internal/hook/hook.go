@@ -0,0 +1,153 @@
+// Package hook implements direnv-style loading of a project's .rdv.envrc
+// from a shell prompt hook: finding the file, remembering which files the
+// user trusts, and turning profile exports into shell statements.
+package hook
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// EnvrcName is the per-project file listing profiles to load.
+const EnvrcName = ".rdv.envrc"
+
+// StateVar carries what the hook loaded between prompts, so it can restore
+// the previous values when leaving the directory.
+const StateVar = "RDV_HOOK_STATE"
+
+// Spec is one `<target>:<profile>` line of an .rdv.envrc, e.g. aws:dev or
+// db.postgres:dev.
+type Spec struct {
+	Target  string
+	Profile string
+}
+
+func (s Spec) String() string { return s.Target + ":" + s.Profile }
+
+// Find returns the nearest .rdv.envrc in dir or one of its parents, or ""
+// if there is none.
+func Find(dir string) string {
+	for {
+		p := filepath.Join(dir, EnvrcName)
+		if fi, err := os.Stat(p); err == nil && fi.Mode().IsRegular() {
+			return p
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Hash fingerprints an .rdv.envrc's content; trust is tied to it.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Parse reads .rdv.envrc content: one target:profile per line, blank lines
+// and # comments ignored.
+func Parse(path string, data []byte) ([]Spec, error) {
+	var specs []Spec
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		target, profile, ok := strings.Cut(line, ":")
+		target, profile = strings.TrimSpace(target), strings.TrimSpace(profile)
+		if !ok || target == "" || profile == "" {
+			return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("%s:%d: expected <target>:<profile>, e.g. aws:dev", path, n))
+		}
+		specs = append(specs, Spec{Target: target, Profile: profile})
+	}
+	return specs, sc.Err()
+}
+
+// State is what the hook loaded for the current shell.
+type State struct {
+	File string `json:"file"`
+	Hash string `json:"hash"`
+	// Trusted records whether File was allowed at Hash, so a later
+	// `rdv hook trust` is picked up on the next prompt.
+	Trusted bool `json:"trusted"`
+	// Saved holds the value each loaded variable had before; nil means
+	// it was unset.
+	Saved map[string]*string `json:"saved,omitempty"`
+}
+
+// DecodeState parses a StateVar value; anything unreadable is treated as
+// no state.
+func DecodeState(s string) State {
+	var st State
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &st) != nil {
+		return State{}
+	}
+	return st
+}
+
+// Encode renders s as a StateVar value.
+func (s State) Encode() string {
+	b, _ := json.Marshal(s)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Change is a set of env edits to apply in the shell.
+type Change struct {
+	Set   map[string]string
+	Unset []string
+}
+
+// Unload restores what s overwrote and clears StateVar.
+func (s State) Unload() Change {
+	c := Change{Set: map[string]string{}, Unset: []string{StateVar}}
+	for k, v := range s.Saved {
+		if v == nil {
+			c.Unset = append(c.Unset, k)
+		} else {
+			c.Set[k] = *v
+		}
+	}
+	return c
+}
+
+// Render turns c into statements for shell (bash, zsh or fish), in a
+// stable order.
+func Render(shell string, c Change) (string, error) {
+	q, err := quoter(shell)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, k := range sortedUnique(c.Unset) {
+		if _, set := c.Set[k]; set {
+			continue
+		}
+		if shell == "fish" {
+			fmt.Fprintf(&b, "set -e %s;\n", k)
+		} else {
+			fmt.Fprintf(&b, "unset %s;\n", k)
+		}
+	}
+	for _, k := range sortedKeys(c.Set) {
+		if shell == "fish" {
+			fmt.Fprintf(&b, "set -gx %s %s;\n", k, q(c.Set[k]))
+		} else {
+			fmt.Fprintf(&b, "export %s=%s;\n", k, q(c.Set[k]))
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,126 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAndParse(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.Equal(t, "", Find(sub))
+
+	path := filepath.Join(root, EnvrcName)
+	require.NoError(t, os.WriteFile(path, []byte("# dev\naws:dev\n\n db.postgres : ci \n"), 0o644))
+	require.Equal(t, path, Find(sub))
+
+	data, _ := os.ReadFile(path)
+	specs, err := Parse(path, data)
+	require.NoError(t, err)
+	require.Equal(t, []Spec{{"aws", "dev"}, {"db.postgres", "ci"}}, specs)
+
+	_, err = Parse(path, []byte("aws:dev\noops\n"))
+	require.ErrorContains(t, err, EnvrcName+":2:")
+}
+
+func TestRender(t *testing.T) {
+	c := Change{Set: map[string]string{"B": "it's", "A": "1"}, Unset: []string{"Z", "A", "Z"}}
+	out, err := Render("bash", c)
+	require.NoError(t, err)
+	require.Equal(t, "unset Z;\nexport A=1;\nexport B='it'\\''s';\n", out)
+
+	out, err = Render("fish", c)
+	require.NoError(t, err)
+	require.Equal(t, "set -e Z;\nset -gx A 1;\nset -gx B 'it\\'s';\n", out)
+
+	_, err = Render("tcsh", c)
+	require.Error(t, err)
+}
+
+func TestTrust(t *testing.T) {
+	t.Setenv("RDV_TRUST_DIR", t.TempDir())
+	tr, err := LoadTrust()
+	require.NoError(t, err)
+	require.Equal(t, Unknown, tr.Check("/p/.rdv.envrc", "h1"))
+
+	tr.Allow("/p/.rdv.envrc", "h1")
+	require.NoError(t, tr.Save())
+	tr, err = LoadTrust()
+	require.NoError(t, err)
+	require.Equal(t, Allowed, tr.Check("/p/.rdv.envrc", "h1"))
+	require.Equal(t, Unknown, tr.Check("/p/.rdv.envrc", "h2"), "edited file needs approval again")
+
+	tr.Deny("/p/.rdv.envrc", "h2")
+	require.Equal(t, Denied, tr.Check("/p/.rdv.envrc", "h2"))
+	require.True(t, tr.Forget("/p/.rdv.envrc"))
+	require.Equal(t, Unknown, tr.Check("/p/.rdv.envrc", "h2"))
+}
+
+// shell is a fake environment that applies each Change like eval would.
+type shell map[string]string
+
+func (s shell) getenv(k string) (string, bool) { v, ok := s[k]; return v, ok }
+
+func (s shell) apply(c Change) {
+	for _, k := range c.Unset {
+		delete(s, k)
+	}
+	for k, v := range c.Set {
+		s[k] = v
+	}
+}
+
+func TestUpdateLoadsAndRestores(t *testing.T) {
+	t.Setenv("RDV_TRUST_DIR", t.TempDir())
+	proj := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(proj, EnvrcName), []byte("custom:a\ncustom:b\n"), 0o644))
+	elsewhere := t.TempDir()
+
+	sh := shell{"A": "orig"}
+	asked := 0
+	var warnings []string
+	l := Loader{
+		Getenv: sh.getenv,
+		Export: func(s Spec) (map[string]string, error) {
+			return map[string]string{"A": "from-" + s.Profile, "ONLY_" + s.Profile: "x"}, nil
+		},
+		Ask:  func(string, []Spec) (bool, error) { asked++; return true, nil },
+		Warn: func(m string) { warnings = append(warnings, m) },
+	}
+	tr, _ := LoadTrust()
+
+	c, err := l.Update(proj, tr)
+	require.NoError(t, err)
+	sh.apply(c)
+	require.Equal(t, 1, asked)
+	require.Equal(t, "from-b", sh["A"], "later lines win")
+	require.Equal(t, "x", sh["ONLY_a"])
+
+	// Next prompt in the same place: nothing to do, no second question.
+	c, err = l.Update(proj, tr)
+	require.NoError(t, err)
+	require.Empty(t, c.Set)
+	require.Empty(t, c.Unset)
+	require.Equal(t, 1, asked)
+
+	c, err = l.Update(elsewhere, tr)
+	require.NoError(t, err)
+	sh.apply(c)
+	require.Equal(t, shell{"A": "orig"}, sh)
+	require.Empty(t, warnings)
+
+	// Without a terminal an unknown file is reported, not loaded.
+	tr, _ = LoadTrust()
+	tr.Forget(filepath.Join(proj, EnvrcName))
+	l.Ask = nil
+	c, err = l.Update(proj, tr)
+	require.NoError(t, err)
+	sh.apply(c)
+	require.Equal(t, "orig", sh["A"])
+	require.Len(t, warnings, 1)
+	require.Contains(t, warnings[0], "not trusted")
+}
@@ -0,0 +1,69 @@
+package hook
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/shellquote"
+)
+
+// Shells lists the shells `rdv hook` supports.
+var Shells = []string{"bash", "zsh", "fish"}
+
+func quoter(shell string) (func(string) string, error) {
+	switch shell {
+	case "bash", "zsh":
+		return shellquote.Sh, nil
+	case "fish":
+		return shellquote.Fish, nil
+	}
+	return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("unsupported shell %q (expected bash, zsh or fish)", shell))
+}
+
+// Script returns the snippet that installs the prompt hook in shell. exe is
+// the rdv binary to call.
+func Script(shell, exe string) (string, error) {
+	q, err := quoter(shell)
+	if err != nil {
+		return "", err
+	}
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(`_rdv_hook() {
+  local previous_exit_status=$?
+  eval "$(%s hook env bash)"
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_rdv_hook;"* ]]; then
+  PROMPT_COMMAND="_rdv_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`, q(exe)), nil
+	case "zsh":
+		return fmt.Sprintf(`_rdv_hook() {
+  eval "$(%s hook env zsh)"
+}
+typeset -ag precmd_functions chpwd_functions
+if (( ! ${precmd_functions[(I)_rdv_hook]} )); then
+  precmd_functions=(_rdv_hook $precmd_functions)
+fi
+if (( ! ${chpwd_functions[(I)_rdv_hook]} )); then
+  chpwd_functions=(_rdv_hook $chpwd_functions)
+fi
+`, q(exe)), nil
+	default: // fish
+		return fmt.Sprintf(`function __rdv_hook --on-event fish_prompt --on-variable PWD
+    %s hook env fish | source
+end
+`, q(exe)), nil
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+func sortedUnique(s []string) []string {
+	return slices.Compact(slices.Sorted(slices.Values(s)))
+}
@@ -0,0 +1,101 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// trustPath returns ~/.config/rdv/trust.yaml (or override via RDV_TRUST_DIR for tests)
+func trustPath() string {
+	if v := os.Getenv("RDV_TRUST_DIR"); v != "" {
+		return filepath.Join(v, "trust.yaml")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv", "trust.yaml")
+}
+
+// Decision is what the user said about an .rdv.envrc.
+type Decision int
+
+const (
+	Unknown Decision = iota
+	Allowed
+	Denied
+)
+
+// Trust maps .rdv.envrc paths to the content hash the user allowed or
+// denied. Editing a file changes its hash, so it has to be approved again.
+type Trust struct {
+	Allowed map[string]string `yaml:"allowed"`
+	Denied  map[string]string `yaml:"denied"`
+}
+
+// LoadTrust reads the trust store; a missing file is an empty store.
+func LoadTrust() (*Trust, error) {
+	t := &Trust{}
+	b, err := os.ReadFile(trustPath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(b, t); err != nil {
+			return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("failed to parse %s: %w", trustPath(), err))
+		}
+	}
+	if t.Allowed == nil {
+		t.Allowed = map[string]string{}
+	}
+	if t.Denied == nil {
+		t.Denied = map[string]string{}
+	}
+	return t, nil
+}
+
+// Save writes the trust store with owner-only permissions.
+func (t *Trust) Save() error {
+	if err := os.MkdirAll(filepath.Dir(trustPath()), 0o700); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	out, _ := yaml.Marshal(t)
+	if err := os.WriteFile(trustPath(), out, 0o600); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return nil
+}
+
+// Check reports the decision recorded for path at this content hash.
+func (t *Trust) Check(path, hash string) Decision {
+	if h, ok := t.Allowed[path]; ok && h == hash {
+		return Allowed
+	}
+	if h, ok := t.Denied[path]; ok && h == hash {
+		return Denied
+	}
+	return Unknown
+}
+
+// Allow trusts path at hash.
+func (t *Trust) Allow(path, hash string) {
+	delete(t.Denied, path)
+	t.Allowed[path] = hash
+}
+
+// Deny remembers that path at hash should not be loaded or asked about.
+func (t *Trust) Deny(path, hash string) {
+	delete(t.Allowed, path)
+	t.Denied[path] = hash
+}
+
+// Forget drops any decision about path, so the hook asks again.
+func (t *Trust) Forget(path string) bool {
+	_, a := t.Allowed[path]
+	_, d := t.Denied[path]
+	delete(t.Allowed, path)
+	delete(t.Denied, path)
+	return a || d
+}
@@ -0,0 +1,113 @@
+package hook
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// Loader wires Update to the rest of rdv and to the user.
+type Loader struct {
+	// Getenv reads the shell's current environment.
+	Getenv func(string) (string, bool)
+	// Export resolves one spec through the exporter registry.
+	Export func(Spec) (map[string]string, error)
+	// Ask asks whether to trust an .rdv.envrc; nil when there is no
+	// terminal to ask on.
+	Ask func(path string, specs []Spec) (bool, error)
+	// Warn reports problems without failing the prompt.
+	Warn func(msg string)
+}
+
+// Update works out the env change for a shell in cwd: unloading what the
+// previous .rdv.envrc set when it no longer applies and loading the one
+// that does, once it is trusted. It returns an empty Change when nothing
+// moved since the last prompt.
+func (l Loader) Update(cwd string, trust *Trust) (Change, error) {
+	prev, _ := l.Getenv(StateVar)
+	st := DecodeState(prev)
+
+	file := Find(cwd)
+	var data []byte
+	var hash string
+	decision := Unknown
+	if file != "" {
+		var err error
+		if data, err = os.ReadFile(file); err != nil {
+			return Change{}, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		hash = Hash(data)
+		decision = trust.Check(file, hash)
+	}
+	if file == st.File && hash == st.Hash && st.Trusted == (decision == Allowed) {
+		return Change{}, nil
+	}
+
+	c := Change{Set: map[string]string{}}
+	if prev != "" {
+		c = st.Unload()
+	}
+	if file == "" {
+		return c, nil
+	}
+
+	next := State{File: file, Hash: hash}
+	defer func() { c.Set[StateVar] = next.Encode() }()
+
+	specs, err := Parse(file, data)
+	if err != nil {
+		l.Warn(exitcodes.Message(err))
+		return c, nil
+	}
+
+	if decision == Unknown {
+		if l.Ask == nil {
+			l.Warn(fmt.Sprintf("%s is not trusted yet; run `rdv hook trust` to load it", file))
+			return c, nil
+		}
+		ok, err := l.Ask(file, specs)
+		if err != nil {
+			return Change{}, err
+		}
+		if ok {
+			trust.Allow(file, hash)
+			decision = Allowed
+		} else {
+			trust.Deny(file, hash)
+		}
+		if err := trust.Save(); err != nil {
+			return Change{}, err
+		}
+	}
+	if decision != Allowed {
+		return c, nil
+	}
+	next.Trusted = true
+
+	vars := map[string]string{}
+	for _, s := range specs {
+		v, err := l.Export(s)
+		if err != nil {
+			l.Warn(fmt.Sprintf("%s: %s: %s", file, s, exitcodes.Message(err)))
+			return c, nil
+		}
+		maps.Copy(vars, v) // later lines win
+	}
+
+	// Remember the values in effect once the previous file is unloaded.
+	next.Saved = map[string]*string{}
+	for k := range vars {
+		if v, ok := c.Set[k]; ok {
+			next.Saved[k] = &v
+		} else if v, ok := l.Getenv(k); ok && !slices.Contains(c.Unset, k) {
+			next.Saved[k] = &v
+		} else {
+			next.Saved[k] = nil
+		}
+	}
+	maps.Copy(c.Set, vars)
+	return c, nil
+}
@@ -0,0 +1,108 @@
+// Package lasttest remembers when each profile last passed a connection
+// test, so inventories can show how fresh a profile is known to be.
+package lasttest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// path returns ~/.config/rdv/last-test.yaml (or override via RDV_STATE_DIR for tests)
+func path() string {
+	if v := os.Getenv("RDV_STATE_DIR"); v != "" {
+		return filepath.Join(v, "last-test.yaml")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv", "last-test.yaml")
+}
+
+// Times maps target -> profile -> time of the last successful test.
+type Times map[string]map[string]time.Time
+
+// Load reads the recorded times; a missing file records nothing.
+func Load() (Times, error) {
+	t := Times{}
+	b, err := os.ReadFile(path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return t, nil
+		}
+		return t, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if err := yaml.Unmarshal(b, &t); err != nil {
+		return Times{}, exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("failed to parse %s: %w", path(), err))
+	}
+	if t == nil {
+		t = Times{}
+	}
+	return t, nil
+}
+
+// Get returns when target:profile last passed, or zero if never.
+func (t Times) Get(target, profile string) time.Time {
+	return t[target][profile]
+}
+
+// Set records that target:profile passed at at.
+func (t Times) Set(target, profile string, at time.Time) {
+	if t[target] == nil {
+		t[target] = map[string]time.Time{}
+	}
+	t[target][profile] = at.UTC().Truncate(time.Second)
+}
+
+// Remove forgets target:profile, reporting whether it had a time.
+func (t Times) Remove(target, profile string) bool {
+	if _, ok := t[target][profile]; !ok {
+		return false
+	}
+	delete(t[target], profile)
+	if len(t[target]) == 0 {
+		delete(t, target)
+	}
+	return true
+}
+
+// Move hands target:from's time to target:to, as a rename does; to loses
+// its own time either way. It reports whether anything changed.
+func (t Times) Move(target, from, to string) bool {
+	at, ok := t[target][from]
+	changed := t.Remove(target, to)
+	if !ok {
+		return changed
+	}
+	t.Remove(target, from)
+	t.Set(target, to, at)
+	return true
+}
+
+// Save writes the recorded times.
+func (t Times) Save() error {
+	if err := os.MkdirAll(filepath.Dir(path()), 0o700); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	out, err := yaml.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path(), out, 0o600); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return nil
+}
+
+// Record notes that target:profile passed a connection test just now.
+func Record(target, profile string) error {
+	t, err := Load()
+	if err != nil {
+		return err
+	}
+	t.Set(target, profile, time.Now())
+	return t.Save()
+}
@@ -0,0 +1,45 @@
+package lasttest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	t.Setenv("RDV_STATE_DIR", t.TempDir())
+	times, err := Load()
+	require.NoError(t, err)
+	require.True(t, times.Get("aws", "dev").IsZero())
+
+	before := time.Now().Add(-time.Second)
+	require.NoError(t, Record("db.postgres", "dev"))
+	require.NoError(t, Record("aws", "dev"))
+
+	times, err = Load()
+	require.NoError(t, err)
+	require.True(t, times.Get("db.postgres", "dev").After(before))
+	require.False(t, times.Get("aws", "dev").IsZero())
+	require.True(t, times.Get("db.postgres", "staging").IsZero())
+}
+
+func TestRemoveAndMove(t *testing.T) {
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	times := Times{}
+	times.Set("aws", "dev", at)
+	times.Set("aws", "old", at)
+
+	require.True(t, times.Move("aws", "dev", "staging"))
+	require.True(t, times.Get("aws", "dev").IsZero())
+	require.Equal(t, at, times.Get("aws", "staging"))
+
+	// Moving an untested profile over a tested one clears the stale time.
+	require.True(t, times.Move("aws", "new", "old"))
+	require.True(t, times.Get("aws", "old").IsZero())
+	require.False(t, times.Move("aws", "new", "old"))
+
+	require.True(t, times.Remove("aws", "staging"))
+	require.False(t, times.Remove("aws", "staging"))
+	require.Empty(t, times)
+}
@@ -0,0 +1,160 @@
+package plugin
+
+import (
+	"context"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+)
+
+// ExportFunc returns the environment variables for one saved profile.
+type ExportFunc func(profile string) (map[string]string, error)
+
+// SecretsFunc reports which of a profile's exported variables carry
+// credentials (passwords, tokens, URLs with embedded passwords).
+type SecretsFunc func(profile string) ([]string, error)
+
+// StaticSecrets returns a SecretsFunc reporting the same keys for every
+// profile, for targets whose secret variables never change.
+func StaticSecrets(keys ...string) SecretsFunc {
+	return func(string) ([]string, error) { return keys, nil }
+}
+
+// Summary describes one saved profile for inventories such as
+// `rdv list --all`.
+type Summary struct {
+	Profile string
+	// Meta holds a few identifying, non-secret settings such as region,
+	// host or user. Empty values are dropped when shown.
+	Meta map[string]string
+	// Modified is when the profile was last saved, or its store file's
+	// mtime when the plugin doesn't track it per profile; zero if unknown.
+	Modified time.Time
+}
+
+// ListFunc summarizes every saved profile of a target.
+type ListFunc func() ([]Summary, error)
+
+// TestFunc checks that a saved profile can connect, like its plugin's
+// test-conn command, giving up when ctx is done. The success message goes
+// to out. It never prompts: a profile that needs input it doesn't store,
+// such as a key passphrase, fails instead.
+type TestFunc func(ctx context.Context, profile string, out io.Writer) error
+
+// Issue is a problem found by a target's static health checks.
+type Issue struct {
+	// Profile is the affected profile, or "" for the target as a whole.
+	Profile string
+	// Check names what was checked, e.g. "key-file".
+	Check  string
+	Detail string
+}
+
+// CheckFunc runs a target's offline health checks, e.g. that files its
+// profiles reference still exist.
+type CheckFunc func() ([]Issue, error)
+
+// ModTime returns path's modification time, or zero if it can't be read.
+func ModTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+// Exporter describes an env export target such as "aws" or "db.postgres".
+// Plugins register exporters so `env export` and `exec` can resolve
+// <target>:<profile> specs without knowing every plugin up front.
+type Exporter struct {
+	// Name is the canonical target, e.g. "db.postgres".
+	Name string
+	// Aliases are extra accepted spellings, e.g. "pg" or "postgres".
+	Aliases []string
+	// Export builds the env var map for a profile.
+	Export ExportFunc
+	// Exec builds what `rdv exec` and `rdv shell` inject when that is more
+	// than Export, such as a temporary config file or an ssh-agent; cleanup
+	// undoes it once the child exits. nil means Export.
+	Exec func(profile string) (env map[string]string, cleanup func(), err error)
+	// Secrets lists the exported keys that hold credentials; nil means none.
+	Secrets SecretsFunc
+	// List summarizes the saved profiles; nil leaves the target out of
+	// `rdv list --all`.
+	List ListFunc
+	// Test runs a profile's connection test; nil means the target has none.
+	Test TestFunc
+	// Files returns the store files holding the profiles, for `rdv doctor`
+	// permission checks.
+	Files func() []string
+	// Check runs extra offline checks for `rdv doctor`; nil means none.
+	Check CheckFunc
+	// Store reads and writes the saved profiles field by field for
+	// `rdv backup`; nil leaves the target out of backups.
+	Store profilestore.Store
+	// Related are glob patterns for the variable family the target owns
+	// (e.g. AWS_PROFILE, AWS_SESSION_TOKEN for aws). exec strips inherited
+	// matches so stale shell credentials don't mix with injected ones.
+	Related []string
+}
+
+var exporters = make(map[string]*Exporter) // name and aliases -> exporter
+
+// RegisterExporter makes e resolvable by its name and aliases.
+// It panics if any of them is already taken.
+func RegisterExporter(e Exporter) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, n := range append([]string{e.Name}, e.Aliases...) {
+		if _, exists := exporters[n]; exists {
+			panic("exporter '" + n + "' already registered")
+		}
+	}
+	for _, n := range append([]string{e.Name}, e.Aliases...) {
+		exporters[n] = &e
+	}
+}
+
+// LookupExporter resolves a target name or alias.
+func LookupExporter(target string) (*Exporter, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	e, ok := exporters[target]
+	return e, ok
+}
+
+// ExporterNames returns the canonical exporter names, sorted.
+func ExporterNames() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(exporters))
+	for n, e := range exporters {
+		if n == e.Name {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Saved reports whether profile is saved, asking Store or else List. A
+// target with neither can't tell and reports true, so callers cleaning up
+// after a delete leave its state alone.
+func (e *Exporter) Saved(profile string) (bool, error) {
+	switch {
+	case e.Store != nil:
+		names, err := e.Store.Names()
+		return slices.Contains(names, profile), err
+	case e.List != nil:
+		sums, err := e.List()
+		return slices.ContainsFunc(sums, func(s Summary) bool { return s.Profile == profile }), err
+	}
+	return true, nil
+}
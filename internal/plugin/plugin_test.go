@@ -30,3 +30,41 @@ func TestRegisterAndLoad(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, cmd)
 }
+
+func TestRegisterAndLookupExporter(t *testing.T) {
+	RegisterExporter(Exporter{
+		Name:    "test.exp",
+		Aliases: []string{"texp"},
+		Export: func(profile string) (map[string]string, error) {
+			return map[string]string{"PROFILE": profile}, nil
+		},
+	})
+
+	e, ok := LookupExporter("texp")
+	require.True(t, ok)
+	require.Equal(t, "test.exp", e.Name)
+
+	vars, err := e.Export("dev")
+	require.NoError(t, err)
+	require.Equal(t, "dev", vars["PROFILE"])
+
+	require.Contains(t, ExporterNames(), "test.exp")
+	require.NotContains(t, ExporterNames(), "texp")
+	require.Panics(t, func() { RegisterExporter(Exporter{Name: "texp"}) })
+}
+
+func TestExporterSaved(t *testing.T) {
+	listed := &Exporter{List: func() ([]Summary, error) {
+		return []Summary{{Profile: "dev"}}, nil
+	}}
+	ok, err := listed.Saved("dev")
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = listed.Saved("prod")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = (&Exporter{}).Saved("prod")
+	require.NoError(t, err)
+	require.True(t, ok)
+}
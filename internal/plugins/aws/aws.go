@@ -1,7 +1,9 @@
 package aws
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -18,6 +20,7 @@ import (
 	"github.com/yonasyiheyis/rdv/internal/logger"
 	"github.com/yonasyiheyis/rdv/internal/plugin"
 	iprint "github.com/yonasyiheyis/rdv/internal/print"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
 	"github.com/yonasyiheyis/rdv/internal/ui"
 )
 
@@ -117,11 +120,20 @@ func (a *awsPlugin) Register(root *cobra.Command) {
 	showCmd.Flags().StringVarP(&showProfile, "profile", "p", "default", "AWS profile")
 
 	awsCmd.AddCommand(setCmd, modifyCmd, deleteCmd, exportCmd, listCmd, showCmd)
+	awsCmd.AddCommand(profilestore.Commands(store{}, "AWS")...)
 	root.AddCommand(awsCmd)
 }
 
 func init() {
 	plugin.Register(&awsPlugin{})
+	plugin.RegisterExporter(plugin.Exporter{Name: "aws", Export: ExportVars,
+		Secrets: plugin.StaticSecrets("AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"),
+		List:    listSummaries,
+		Test:    awsTestConn,
+		Files:   func() []string { return []string{credentialsPath(), configPath()} },
+		Store:   store{},
+		Related: []string{"AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_SECURITY_TOKEN",
+			"AWS_CREDENTIAL_EXPIRATION", "AWS_ROLE_ARN", "AWS_ROLE_SESSION_NAME", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_VAULT"}})
 }
 
 // ---------- data types ----------
@@ -241,7 +253,7 @@ func runSet(profile string, testConn, noPrompt bool, access, secret, region stri
 	fmt.Printf("✅ Credentials saved to %s (profile %q)\n", credentialsPath(), profile)
 
 	if testConn {
-		if err := testAWSCreds(in); err != nil {
+		if err := testAWSCreds(context.Background(), in, os.Stdout); err != nil {
 			return exitcodes.Wrap(exitcodes.ConnectionFailed, err)
 		}
 	}
@@ -289,7 +301,7 @@ func runModifyAWS(profile string, testConn, noPrompt bool, access, secret, regio
 	fmt.Printf("✅ Updated profile %q\n", profile)
 
 	if testConn {
-		if err := testAWSCreds(in); err != nil {
+		if err := testAWSCreds(context.Background(), in, os.Stdout); err != nil {
 			return exitcodes.Wrap(exitcodes.ConnectionFailed, err)
 		}
 	}
@@ -306,20 +318,32 @@ func runDeleteAWS(profile string) error {
 		return nil
 	}
 
+	if err := deleteAWSProfile(profile); err != nil {
+		return err
+	}
+
+	logger.L.Infow("aws profile deleted", "profile", profile)
+	fmt.Printf("🗑️  Deleted profile %q\n", profile)
+	return nil
+}
+
+// deleteAWSProfile drops profile's sections from both ~/.aws files.
+func deleteAWSProfile(profile string) error {
 	credINI, _ := ini.Load(credentialsPath())
 	cfgINI, _ := ini.Load(configPath())
 
 	if credINI != nil {
 		credINI.DeleteSection(profile)
-		_ = credINI.SaveTo(credentialsPath())
+		if err := credINI.SaveTo(credentialsPath()); err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
 	}
 	if cfgINI != nil {
 		cfgINI.DeleteSection("profile " + profile)
-		_ = cfgINI.SaveTo(configPath())
+		if err := cfgINI.SaveTo(configPath()); err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
 	}
-
-	logger.L.Infow("aws profile deleted", "profile", profile)
-	fmt.Printf("🗑️  Deleted profile %q\n", profile)
 	return nil
 }
 
@@ -360,7 +384,8 @@ func runExport(profile string, envPath string) error {
 	return nil
 }
 
-func runListAWS() error {
+// listAWSProfiles returns the profile names found in either ~/.aws file.
+func listAWSProfiles() []string {
 	namesSet := map[string]struct{}{}
 
 	if credINI, err := ini.Load(credentialsPath()); err == nil {
@@ -387,7 +412,43 @@ func runListAWS() error {
 		names = append(names, n)
 	}
 	sort.Strings(names)
+	return names
+}
+
+// awsTestConn validates a saved profile's credentials with STS.
+func awsTestConn(ctx context.Context, profile string, out io.Writer) error {
+	in, err := loadAWSProfile(profile)
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if in.AccessKey == "" || in.SecretKey == "" {
+		return exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found in %s", profile, credentialsPath()))
+	}
+	if err := testAWSCreds(ctx, in, out); err != nil {
+		return exitcodes.Wrap(exitcodes.ConnectionFailed, err)
+	}
+	return nil
+}
 
+// listSummaries describes each profile for `rdv list --all`.
+func listSummaries() ([]plugin.Summary, error) {
+	var out []plugin.Summary
+	for _, n := range listAWSProfiles() {
+		p, err := loadAWSProfile(n)
+		if err != nil {
+			return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		out = append(out, plugin.Summary{
+			Profile:  n,
+			Meta:     map[string]string{"region": p.Region},
+			Modified: plugin.ModTime(credentialsPath()),
+		})
+	}
+	return out, nil
+}
+
+func runListAWS() error {
+	names := listAWSProfiles()
 	if iprint.JSON {
 		return iprint.Out(map[string]any{"profiles": names})
 	}
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
 )
 
 func TestPathsOverride(t *testing.T) {
@@ -16,3 +18,26 @@ func TestPathsOverride(t *testing.T) {
 	require.Contains(t, credentialsPath(), tmp)
 	require.Contains(t, configPath(), tmp)
 }
+
+func TestStoreCopyKeepsUnmanagedKeys(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(tmp, "creds"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(tmp, "config"))
+	require.NoError(t, os.WriteFile(configPath(), []byte("[profile dev]\nregion = us-east-1\noutput = json\n"), 0o600))
+	require.NoError(t, saveAWSProfile("dev", credsInput{AccessKey: "AKIADEV", SecretKey: "devsecret", Region: "us-east-1"}))
+
+	s := store{}
+	require.NoError(t, profilestore.Rename(s, "dev", "staging", false))
+	require.Equal(t, []string{"staging"}, listAWSProfiles())
+
+	got, err := s.Get("staging")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"aws_access_key_id": "AKIADEV", "aws_secret_access_key": "devsecret",
+		"region": "us-east-1", "output": "json",
+	}, got)
+
+	vars, err := ExportVars("staging")
+	require.NoError(t, err)
+	require.Equal(t, "devsecret", vars["AWS_SECRET_ACCESS_KEY"])
+}
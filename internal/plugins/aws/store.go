@@ -0,0 +1,101 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// credentialKeys are the fields kept in ~/.aws/credentials; every other
+// field of a profile lives in its ~/.aws/config section.
+var credentialKeys = []string{"aws_access_key_id", "aws_secret_access_key", "aws_session_token"}
+
+// sharedKeys are the settings a profile imported from someone else's bundle
+// may carry. Anything else in an AWS profile, credential_process above all,
+// can run commands or lean on other local profiles, so imports drop it.
+var sharedKeys = append([]string{"region", "output", "endpoint_url"}, credentialKeys...)
+
+// store exposes the ~/.aws files to copy/rename/diff and backups. Fields are
+// the raw INI keys, so settings rdv doesn't manage (output, role_arn, ...)
+// carry over.
+type store struct{}
+
+func (store) Names() ([]string, error) { return listAWSProfiles(), nil }
+
+func (store) Get(name string) (map[string]string, error) {
+	fields := map[string]string{}
+	if credINI, err := ini.Load(credentialsPath()); err == nil {
+		if sec, err := credINI.GetSection(name); err == nil {
+			for _, k := range sec.Keys() {
+				fields[k.Name()] = k.String()
+			}
+		}
+	}
+	if cfgINI, err := ini.Load(configPath()); err == nil {
+		if sec, err := cfgINI.GetSection("profile " + name); err == nil {
+			for _, k := range sec.Keys() {
+				fields[k.Name()] = k.String()
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil, exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found in %s", name, credentialsPath()))
+	}
+	return fields, nil
+}
+
+func (store) Put(name string, fields map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(credentialsPath()), 0o700); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	credINI, err := loadINI(credentialsPath())
+	if err != nil {
+		return err
+	}
+	cfgINI, err := loadINI(configPath())
+	if err != nil {
+		return err
+	}
+	credINI.DeleteSection(name)
+	cfgINI.DeleteSection("profile " + name)
+
+	csec, cfgSec := credINI.Section(name), cfgINI.Section("profile "+name)
+	for k, v := range fields {
+		if slices.Contains(credentialKeys, k) {
+			csec.Key(k).SetValue(v)
+		} else {
+			cfgSec.Key(k).SetValue(v)
+		}
+	}
+	if err := credINI.SaveTo(credentialsPath()); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if err := cfgINI.SaveTo(configPath()); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return nil
+}
+
+func (store) Delete(name string) error { return deleteAWSProfile(name) }
+
+func (store) Secret(field string) bool { return slices.Contains(credentialKeys, field) }
+
+func (store) Fields() []string { return sharedKeys }
+
+// loadINI reads path, or starts an empty file if it is missing or empty.
+// A file that doesn't parse is an error rather than something to overwrite.
+func loadINI(path string) (*ini.File, error) {
+	if fi, err := os.Stat(path); err != nil || fi.Size() == 0 {
+		return ini.Empty(), nil
+	}
+	f, err := ini.Load(path)
+	if err != nil {
+		return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("failed to read %s: %w", path, err))
+	}
+	return f, nil
+}
@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -10,10 +11,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
-func testAWSCreds(p credsInput) error {
+func testAWSCreds(ctx context.Context, p credsInput, out io.Writer) error {
 	prov := credentials.NewStaticCredentialsProvider(p.AccessKey, p.SecretKey, "")
 	cfg, err := config.LoadDefaultConfig(
-		context.TODO(),
+		ctx,
 		config.WithRegion(p.Region),
 		config.WithCredentialsProvider(aws.NewCredentialsCache(prov)),
 	)
@@ -22,10 +23,10 @@ func testAWSCreds(p credsInput) error {
 	}
 
 	client := sts.NewFromConfig(cfg)
-	if _, err := client.GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{}); err != nil {
+	if _, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
 		return fmt.Errorf("STS GetCallerIdentity failed: %w", err)
 	}
 
-	fmt.Println("✅ AWS credentials are valid")
+	_, _ = fmt.Fprintln(out, "✅ AWS credentials are valid")
 	return nil
 }
@@ -0,0 +1,193 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+// ---------- plugin wiring ----------
+
+type azurePlugin struct{}
+
+func (a *azurePlugin) Name() string { return "azure" }
+
+func (a *azurePlugin) Register(root *cobra.Command) {
+	root.AddCommand(engine.Command(driver))
+}
+
+var driver = &engine.Driver{
+	Name:  "azure",
+	Label: "Azure",
+	Related: []string{"AZURE_CLIENT_*", "AZURE_TENANT_ID", "AZURE_USERNAME", "AZURE_PASSWORD", "AZURE_FEDERATED_TOKEN_FILE", "AZURE_SUBSCRIPTION_ID",
+		"ARM_CLIENT_*", "ARM_TENANT_ID", "ARM_SUBSCRIPTION_ID", "ARM_USE_MSI", "ARM_USE_OIDC", "ARM_OIDC_*"},
+	Path: cfgPath,
+	Fields: []engine.Field{
+		{Key: "tenant_id", Title: "Tenant ID", Usage: "Microsoft Entra tenant ID"},
+		{Key: "client_id", Title: "Client (application) ID", Usage: "service principal application (client) ID"},
+		// Switching credential type replaces the other one.
+		{Key: "client_secret", Title: "Client secret (leave empty to use a certificate)", Usage: "client secret (or use --certificate-path)", Secret: true, Optional: true, Replaces: []string{"certificate_path"}},
+		{Key: "certificate_path", Title: "Certificate PEM path", Usage: "PEM file with the client certificate and private key", Optional: true, Path: true, Replaces: []string{"client_secret"}},
+		{Key: "subscription_id", Title: "Subscription ID", Usage: "default subscription ID", Optional: true},
+		{Key: "cloud", Title: "Cloud (public, gov or china)", Usage: "public, gov or china", Default: "public"},
+		{Key: "authority_host", Title: "Authority host", Usage: "override the login endpoint, e.g. https://login.microsoftonline.com/", Optional: true},
+	},
+	Summary: []string{"tenant_id", "subscription_id", "cloud"},
+	Help: map[string]string{
+		"":           "Manage Azure service principal profiles",
+		"set-config": "Interactively set Azure service principal credentials",
+		"export":     "Print AZURE_* and ARM_* exports for a profile",
+		"test-conn":  "Request a client-credentials token using a saved Azure profile",
+	},
+	Secrets: []string{"AZURE_CLIENT_SECRET", "ARM_CLIENT_SECRET"},
+	Validate: func(p engine.Settings) error {
+		ap := profileOf(p)
+		return ap.validate()
+	},
+	// Store the canonical name of --cloud.
+	Apply: func(_ string, _ *pflag.FlagSet, p engine.Settings) error {
+		if c, ok := cloudAliases[strings.ToLower(strings.TrimSpace(p["cloud"]))]; ok && p["cloud"] != "" {
+			p["cloud"] = c
+		}
+		return nil
+	},
+	Env: func(p engine.Settings) map[string]string { return exportVars(profileOf(p)) },
+	Test: func(ctx context.Context, p engine.Settings, out io.Writer) error {
+		return testAzure(ctx, profileOf(p), out)
+	},
+}
+
+func init() {
+	plugin.Register(&azurePlugin{})
+	engine.Register(driver)
+}
+
+// ---------- data types ----------
+
+// azureProfile is a resolved profile in the shape the exporter and tester
+// work with.
+type azureProfile struct {
+	TenantID        string
+	ClientID        string
+	ClientSecret    string
+	CertificatePath string
+	SubscriptionID  string
+	Cloud           string
+	AuthorityHost   string
+}
+
+func profileOf(p engine.Settings) azureProfile {
+	return azureProfile{
+		TenantID:        p["tenant_id"],
+		ClientID:        p["client_id"],
+		ClientSecret:    p["client_secret"],
+		CertificatePath: p["certificate_path"],
+		SubscriptionID:  p["subscription_id"],
+		Cloud:           p["cloud"],
+		AuthorityHost:   p["authority_host"],
+	}
+}
+
+// azureCloud describes one sovereign cloud's endpoints and the names the
+// Azure SDKs (AZURE_ENVIRONMENT) and Terraform (ARM_ENVIRONMENT) use for it.
+type azureCloud struct {
+	authority       string
+	resourceManager string
+	sdkEnv          string
+	armEnv          string
+}
+
+var clouds = map[string]azureCloud{
+	"public": {"https://login.microsoftonline.com/", "https://management.azure.com/", "AzurePublicCloud", "public"},
+	"gov":    {"https://login.microsoftonline.us/", "https://management.usgovcloudapi.net/", "AzureUSGovernmentCloud", "usgovernment"},
+	"china":  {"https://login.chinacloudapi.cn/", "https://management.chinacloudapi.cn/", "AzureChinaCloud", "china"},
+}
+
+// cloudAliases maps accepted --cloud spellings to keys of clouds.
+var cloudAliases = map[string]string{
+	"":             "public",
+	"public":       "public",
+	"azurecloud":   "public",
+	"gov":          "gov",
+	"usgov":        "gov",
+	"usgovernment": "gov",
+	"china":        "china",
+}
+
+func (p azureProfile) cloud() azureCloud {
+	return clouds[cloudAliases[strings.ToLower(strings.TrimSpace(p.Cloud))]]
+}
+
+// authorityHost is the explicit override, else the cloud's login endpoint.
+func (p azureProfile) authorityHost() string {
+	if p.AuthorityHost != "" {
+		return p.AuthorityHost
+	}
+	return p.cloud().authority
+}
+
+// validate canonicalises cloud and checks that exactly one credential is
+// set.
+func (p *azureProfile) validate() error {
+	c, ok := cloudAliases[strings.ToLower(strings.TrimSpace(p.Cloud))]
+	if !ok {
+		return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("unknown --cloud %q (want public, gov or china)", p.Cloud))
+	}
+	p.Cloud = c
+
+	switch {
+	case p.TenantID == "" || p.ClientID == "":
+		return exitcodes.New(exitcodes.InvalidArgs, "missing required flags: --tenant-id, --client-id")
+	case p.ClientSecret == "" && p.CertificatePath == "":
+		return exitcodes.New(exitcodes.InvalidArgs, "missing credential: pass --client-secret or --certificate-path")
+	case p.ClientSecret != "" && p.CertificatePath != "":
+		return exitcodes.New(exitcodes.InvalidArgs, "--client-secret and --certificate-path are mutually exclusive")
+	}
+	if p.AuthorityHost != "" {
+		if u, err := url.Parse(p.AuthorityHost); err != nil || u.Scheme == "" || u.Host == "" {
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid --authority-host %q", p.AuthorityHost))
+		}
+	}
+	return nil
+}
+
+// ExportVars returns the AZURE_* (Azure SDKs / CLI) and ARM_* (Terraform)
+// env map for a profile.
+func ExportVars(profile string) (map[string]string, error) {
+	return driver.ExportVars(profile)
+}
+
+func exportVars(p azureProfile) map[string]string {
+	c := p.cloud()
+	vars := map[string]string{
+		"AZURE_TENANT_ID":      p.TenantID,
+		"AZURE_CLIENT_ID":      p.ClientID,
+		"AZURE_AUTHORITY_HOST": p.authorityHost(),
+		"AZURE_ENVIRONMENT":    c.sdkEnv,
+		"ARM_TENANT_ID":        p.TenantID,
+		"ARM_CLIENT_ID":        p.ClientID,
+		"ARM_ENVIRONMENT":      c.armEnv,
+	}
+	if p.ClientSecret != "" {
+		vars["AZURE_CLIENT_SECRET"] = p.ClientSecret
+		vars["ARM_CLIENT_SECRET"] = p.ClientSecret
+	}
+	if p.CertificatePath != "" {
+		vars["AZURE_CLIENT_CERTIFICATE_PATH"] = p.CertificatePath
+		vars["ARM_CLIENT_CERTIFICATE_PATH"] = p.CertificatePath
+	}
+	if p.SubscriptionID != "" {
+		vars["AZURE_SUBSCRIPTION_ID"] = p.SubscriptionID
+		vars["ARM_SUBSCRIPTION_ID"] = p.SubscriptionID
+	}
+	return vars
+}
@@ -0,0 +1,169 @@
+package azure
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+)
+
+// fakeAuthority emulates the v2.0 token endpoint for tenant "t1": it accepts
+// client secret "s3cret" or any assertion signed by pub.
+func fakeAuthority(t *testing.T, pub *rsa.PublicKey) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/t1/oauth2/v2.0/token" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_tenant","error_description":"AADSTS90002: Tenant not found.\r\nTrace ID: x"}`))
+			return
+		}
+		_ = r.ParseForm()
+		ok := r.Form.Get("grant_type") == "client_credentials" &&
+			r.Form.Get("scope") == "https://management.azure.com/.default" &&
+			(r.Form.Get("client_secret") == "s3cret" || validAssertion(r.Form.Get("client_assertion"), pub))
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"AADSTS7000215: Invalid client secret provided."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"token_type":"Bearer","expires_in":3599,"access_token":"tok"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/"
+}
+
+func validAssertion(jwt string, pub *rsa.PublicKey) bool {
+	parts := strings.Split(jwt, ".")
+	if pub == nil || len(parts) != 3 {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+}
+
+// writeCertPEM writes a self-signed cert + PKCS#8 key bundle.
+func writeCertPEM(t *testing.T) (string, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "rdv-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	pk, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	out := append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pk})...)
+	path := filepath.Join(t.TempDir(), "sp.pem")
+	require.NoError(t, os.WriteFile(path, out, 0o600))
+	return path, key
+}
+
+func TestTestAzureClientSecret(t *testing.T) {
+	p := azureProfile{TenantID: "t1", ClientID: "app", ClientSecret: "s3cret", AuthorityHost: fakeAuthority(t, nil)}
+	require.NoError(t, testAzure(t.Context(), p, io.Discard))
+
+	p.ClientSecret = "wrong"
+	require.ErrorContains(t, testAzure(t.Context(), p, io.Discard), "invalid_client")
+
+	p.TenantID = "nope"
+	err := testAzure(t.Context(), p, io.Discard)
+	require.ErrorContains(t, err, "AADSTS90002: Tenant not found.")
+	require.NotContains(t, err.Error(), "Trace ID")
+}
+
+func TestTestAzureCertificate(t *testing.T) {
+	path, key := writeCertPEM(t)
+	p := azureProfile{TenantID: "t1", ClientID: "app", CertificatePath: path, AuthorityHost: fakeAuthority(t, &key.PublicKey)}
+	require.NoError(t, testAzure(t.Context(), p, io.Discard))
+
+	// Assertion claims target the token endpoint and carry the thumbprint.
+	jwt, err := clientAssertion(p, p.tokenEndpoint())
+	require.NoError(t, err)
+	parts := strings.Split(jwt, ".")
+	var header map[string]string
+	var claims map[string]any
+	b, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, json.Unmarshal(b, &header))
+	b, _ = base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, json.Unmarshal(b, &claims))
+	require.Equal(t, "RS256", header["alg"])
+	require.NotEmpty(t, header["x5t"])
+	require.Equal(t, p.tokenEndpoint(), claims["aud"])
+	require.Equal(t, "app", claims["sub"])
+
+	other, _ := writeCertPEM(t)
+	p.CertificatePath = other
+	require.ErrorContains(t, testAzure(t.Context(), p, io.Discard), "invalid_client")
+}
+
+func TestValidate(t *testing.T) {
+	p := azureProfile{TenantID: "t", ClientID: "c", ClientSecret: "s", Cloud: "USGovernment"}
+	require.NoError(t, p.validate())
+	require.Equal(t, "gov", p.Cloud)
+	require.Equal(t, "https://login.microsoftonline.us/", p.authorityHost())
+
+	require.Error(t, (&azureProfile{TenantID: "t", ClientID: "c"}).validate())
+	require.Error(t, (&azureProfile{TenantID: "t", ClientID: "c", ClientSecret: "s", CertificatePath: "x.pem"}).validate())
+	require.Error(t, (&azureProfile{TenantID: "t", ClientID: "c", ClientSecret: "s", Cloud: "mars"}).validate())
+	require.Error(t, (&azureProfile{TenantID: "t", ClientID: "c", ClientSecret: "s", AuthorityHost: "login"}).validate())
+}
+
+func TestExportVars(t *testing.T) {
+	t.Setenv("RDV_AZURE_DIR", t.TempDir())
+
+	in := engine.Settings{"tenant_id": "t1", "client_id": "app", "client_secret": "s3cret", "subscription_id": "sub", "cloud": "china"}
+	require.NoError(t, engine.SetConfig(driver, "dev", false, true, in))
+
+	vars, err := ExportVars("dev")
+	require.NoError(t, err)
+	require.Equal(t, "t1", vars["AZURE_TENANT_ID"])
+	require.Equal(t, "app", vars["ARM_CLIENT_ID"])
+	require.Equal(t, "s3cret", vars["AZURE_CLIENT_SECRET"])
+	require.Equal(t, "s3cret", vars["ARM_CLIENT_SECRET"])
+	require.Equal(t, "sub", vars["ARM_SUBSCRIPTION_ID"])
+	require.Equal(t, "china", vars["ARM_ENVIRONMENT"])
+	require.Equal(t, "AzureChinaCloud", vars["AZURE_ENVIRONMENT"])
+	require.Equal(t, "https://login.chinacloudapi.cn/", vars["AZURE_AUTHORITY_HOST"])
+	require.NotContains(t, vars, "AZURE_CLIENT_CERTIFICATE_PATH")
+}
+
+func TestModifySwitchesCredential(t *testing.T) {
+	t.Setenv("RDV_AZURE_DIR", t.TempDir())
+	require.NoError(t, engine.SetConfig(driver, "dev", false, true, engine.Settings{"tenant_id": "t1", "client_id": "app", "client_secret": "s3cret"}))
+
+	require.NoError(t, engine.Modify(driver, "dev", false, true, engine.Settings{"certificate_path": "/certs/app.pem"}))
+	vars, err := ExportVars("dev")
+	require.NoError(t, err)
+	require.Equal(t, "/certs/app.pem", vars["AZURE_CLIENT_CERTIFICATE_PATH"])
+	require.NotContains(t, vars, "AZURE_CLIENT_SECRET")
+	require.Equal(t, "AzurePublicCloud", vars["AZURE_ENVIRONMENT"], "the default cloud")
+}
@@ -0,0 +1,20 @@
+package azure
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// baseDir returns ~/.config/rdv (or override via RDV_AZURE_DIR for tests)
+func baseDir() string {
+	if v := os.Getenv("RDV_AZURE_DIR"); v != "" {
+		return v
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv")
+}
+
+// cfgPath returns the profile store, ~/.config/rdv/azure.yaml
+func cfgPath() string {
+	return filepath.Join(baseDir(), "azure.yaml")
+}
@@ -0,0 +1,167 @@
+package azure
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // x5t is defined as the SHA-1 thumbprint
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const assertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// tokenEndpoint is the tenant's OAuth2 v2.0 token URL on the profile's authority.
+func (p azureProfile) tokenEndpoint() string {
+	return strings.TrimRight(p.authorityHost(), "/") + "/" + url.PathEscape(p.TenantID) + "/oauth2/v2.0/token"
+}
+
+// testAzure performs a client-credentials grant for the cloud's Resource
+// Manager scope, using either the client secret or a signed certificate
+// assertion.
+func testAzure(ctx context.Context, p azureProfile, out io.Writer) error {
+	endpoint := p.tokenEndpoint()
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {p.ClientID},
+		"scope":      {p.cloud().resourceManager + ".default"},
+	}
+	if p.CertificatePath != "" {
+		assertion, err := clientAssertion(p, endpoint)
+		if err != nil {
+			return err
+		}
+		form.Set("client_assertion_type", assertionType)
+		form.Set("client_assertion", assertion)
+	} else {
+		form.Set("client_secret", p.ClientSecret)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("token response (%s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		if body.Error != "" {
+			// AAD descriptions are multi-line with trace IDs; the first line is enough.
+			desc, _, _ := strings.Cut(body.Description, "\r\n")
+			return fmt.Errorf("token request rejected (%s): %s: %s", resp.Status, body.Error, desc)
+		}
+		return fmt.Errorf("token request rejected (%s)", resp.Status)
+	}
+
+	_, _ = fmt.Fprintf(out, "✅ Azure token issued for client %s in tenant %s (expires in %ds)\n", p.ClientID, p.TenantID, body.ExpiresIn)
+	return nil
+}
+
+// loadCertificate reads a PEM bundle holding the certificate and its
+// unencrypted RSA private key (PKCS#1 or PKCS#8).
+func loadCertificate(path string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	var cert *x509.Certificate
+	var key *rsa.PrivateKey
+	for block, rest := pem.Decode(b); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "CERTIFICATE":
+			if cert == nil {
+				if cert, err = x509.ParseCertificate(block.Bytes); err != nil {
+					return nil, nil, fmt.Errorf("invalid certificate in %s: %w", path, err)
+				}
+			}
+		case "RSA PRIVATE KEY":
+			if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+				return nil, nil, fmt.Errorf("invalid private key in %s: %w", path, err)
+			}
+		case "PRIVATE KEY":
+			k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid private key in %s: %w", path, err)
+			}
+			rk, ok := k.(*rsa.PrivateKey)
+			if !ok {
+				return nil, nil, fmt.Errorf("private key in %s is not RSA", path)
+			}
+			key = rk
+		}
+	}
+	if cert == nil || key == nil {
+		return nil, nil, errors.New(path + " must contain a PEM certificate and its private key")
+	}
+	return cert, key, nil
+}
+
+// clientAssertion builds the RS256-signed JWT that authenticates a
+// certificate credential to the token endpoint.
+func clientAssertion(p azureProfile, audience string) (string, error) {
+	cert, key, err := loadCertificate(p.CertificatePath)
+	if err != nil {
+		return "", err
+	}
+	thumb := sha1.Sum(cert.Raw) //nolint:gosec // see import
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := time.Now()
+
+	enc := base64.RawURLEncoding
+	header, _ := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"x5t": enc.EncodeToString(thumb[:]),
+	})
+	claims, _ := json.Marshal(map[string]any{
+		"aud": audience,
+		"iss": p.ClientID,
+		"sub": p.ClientID,
+		"jti": hex.EncodeToString(jti),
+		"nbf": now.Unix(),
+		"iat": now.Unix(),
+		"exp": now.Add(10 * time.Minute).Unix(),
+	})
+	signing := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign client assertion: %w", err)
+	}
+	return signing + "." + enc.EncodeToString(sig), nil
+}
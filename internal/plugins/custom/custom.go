@@ -0,0 +1,263 @@
+package custom
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"github.com/yonasyiheyis/rdv/internal/cli"
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/envfile"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	fflags "github.com/yonasyiheyis/rdv/internal/flags"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
+	"github.com/yonasyiheyis/rdv/internal/ui"
+)
+
+// ---------- plugin wiring ----------
+
+type customPlugin struct{}
+
+func (c *customPlugin) Name() string { return "custom" }
+
+func (c *customPlugin) Register(root *cobra.Command) {
+	customCmd := engine.Command(driver)
+	// Variables are set by name rather than through a fixed set of flags.
+	for _, c := range customCmd.Commands() {
+		if c.Name() == "set-config" || c.Name() == "modify" {
+			customCmd.RemoveCommand(c)
+		}
+	}
+
+	var profile string
+
+	// -------- set ------------
+	var noPrompt bool
+	var in setInput
+
+	setCmd := &cobra.Command{
+		Use:   "set [KEY=VALUE ...]",
+		Short: "Add or update variables in a custom profile",
+		Example: `  rdv custom set --profile stripe-test STRIPE_SECRET_KEY=sk_test_123 STRIPE_WEBHOOK_SECRET=whsec_456
+  rdv custom set --profile flags FEATURE_BETA=true --plain FEATURE_BETA
+  rdv custom set --profile sentry --from-env .env.sentry`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in.pairs = args
+			return customSet(profile, noPrompt, in)
+		},
+	}
+	fflags.AddNoPromptFlag(setCmd.Flags(), &noPrompt)
+	setCmd.Flags().StringVarP(&profile, "profile", "p", "default", "profile name")
+	setCmd.Flags().StringVar(&in.fromEnv, "from-env", "", "import every KEY=VALUE from this .env file")
+	setCmd.Flags().StringSliceVar(&in.secret, "secret", nil, "keys to mark secret (redacted by show; the default for new keys)")
+	setCmd.Flags().StringSliceVar(&in.plain, "plain", nil, "keys to mark non-secret (shown in clear by show)")
+
+	// -------- unset ----------------
+	unsetCmd := &cobra.Command{
+		Use:   "unset KEY [KEY ...]",
+		Short: "Remove variables from a custom profile",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return customUnset(profile, args)
+		},
+	}
+	unsetCmd.Flags().StringVarP(&profile, "profile", "p", "default", "profile name")
+
+	customCmd.AddCommand(setCmd, unsetCmd)
+	root.AddCommand(customCmd)
+}
+
+// plainField lists the variables that aren't secrets; every other one is.
+const plainField = "plain"
+
+// Variables are saved as keys of their own next to plain, so a bundle
+// extends another and copies, backs up and shares like any profile.
+var driver = &engine.Driver{
+	Name:  "custom",
+	Label: "custom",
+	Path:  cfgPath,
+	Fields: []engine.Field{
+		{Key: plainField, Usage: "variables shown in clear by show", List: true, Optional: true},
+	},
+	Help: map[string]string{
+		"":       "Manage arbitrary key/value env bundles",
+		"export": "Print export lines for every variable in a profile",
+		"show":   "Show custom profile (secret keys redacted)",
+	},
+	Vars:  keyRe.MatchString,
+	Plain: func(p engine.Settings) []string { return p.Values(plainField) },
+}
+
+func init() {
+	plugin.Register(&customPlugin{})
+	engine.Register(driver)
+}
+
+// ---------- data types ----------
+
+// setInput collects everything `custom set` can receive.
+type setInput struct {
+	pairs   []string // KEY=VALUE args
+	fromEnv string
+	secret  []string
+	plain   []string
+}
+
+var keyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validKey(k string) error {
+	switch {
+	case !keyRe.MatchString(k):
+		return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid variable name %q (letters, digits and _ only, not starting with a digit)", k))
+	case reserved(k):
+		return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid variable name %q (reserved for the profile's own settings)", k))
+	}
+	return nil
+}
+
+// reserved reports whether k is a field of the profile itself, which a
+// variable can't be named.
+func reserved(k string) bool {
+	return k == plainField || k == profilestore.ExtendsField || k == resolve.InterpolateField
+}
+
+// parsePairs splits KEY=VALUE args; the value may itself contain '='.
+func parsePairs(pairs []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, kv := range pairs {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid argument %q, expected KEY=VALUE", kv))
+		}
+		if err := validKey(k); err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// ExportVars returns every variable stored in a custom profile.
+func ExportVars(profile string) (map[string]string, error) {
+	return driver.ExportVars(profile)
+}
+
+// promptVar asks for a single variable interactively.
+func promptVar() (key, value string, secret bool, err error) {
+	secret = true
+	form := ui.NewForm(
+		huh.NewGroup(
+			huh.NewInput().Title("Variable name").Value(&key).Validate(validKey),
+			huh.NewInput().Title("Value").EchoMode(huh.EchoModePassword).Value(&value),
+			huh.NewConfirm().Title("Secret? (redacted by show)").Value(&secret),
+		),
+	)
+	err = form.Run()
+	return key, value, secret, err
+}
+
+/* ------------ command impls ------------ */
+
+// current returns profile as resolved, or nothing if it isn't saved yet.
+func current(profile string) (engine.Settings, error) {
+	names, err := driver.Store().Names()
+	if err != nil || !slices.Contains(names, profile) {
+		return engine.Settings{}, err
+	}
+	return driver.Resolve(profile)
+}
+
+func customSet(profile string, noPrompt bool, in setInput) error {
+	if err := profilestore.ValidName(profile); err != nil {
+		return err
+	}
+	vals := engine.Settings{}
+	if in.fromEnv != "" {
+		m, err := envfile.ReadEnv(in.fromEnv)
+		if err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		for k, v := range m {
+			if err := validKey(k); err != nil {
+				return err
+			}
+			vals[k] = v
+		}
+	}
+	pairs, err := parsePairs(in.pairs)
+	if err != nil {
+		return err
+	}
+	for k, v := range pairs { // args win over the imported file
+		vals[k] = v
+	}
+	for _, k := range append(append([]string{}, in.secret...), in.plain...) {
+		if err := validKey(k); err != nil {
+			return err
+		}
+	}
+
+	p, err := current(profile)
+	if err != nil {
+		return err
+	}
+	plain := p.Values(plainField)
+
+	if len(vals) == 0 && len(in.secret) == 0 && len(in.plain) == 0 {
+		if noPrompt || !cli.IsInteractive() {
+			return exitcodes.New(exitcodes.InvalidArgs, "nothing to set: pass KEY=VALUE arguments or --from-env FILE")
+		}
+		k, v, secret, err := promptVar()
+		if err != nil {
+			return err
+		}
+		vals[k] = v
+		if secret {
+			in.secret = append(in.secret, k)
+		} else {
+			in.plain = append(in.plain, k)
+		}
+	}
+
+	// New keys are secret until marked --plain; updated ones keep their mark.
+	for _, k := range append(append([]string{}, in.secret...), in.plain...) {
+		if vals[k] == "" && p[k] == "" {
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("cannot mark %q: not set in profile %q", k, profile))
+		}
+	}
+	plain = slices.DeleteFunc(plain, func(k string) bool { return slices.Contains(in.secret, k) })
+	for _, k := range in.plain {
+		if !slices.Contains(plain, k) {
+			plain = append(plain, k)
+		}
+	}
+	slices.Sort(plain)
+	vals[plainField] = strings.Join(plain, ",")
+
+	return engine.Modify(driver, profile, false, true, vals)
+}
+
+func customUnset(profile string, keys []string) error {
+	p, err := driver.Store().Get(profile)
+	if err != nil {
+		return err
+	}
+	in := engine.Settings{}
+	for _, k := range keys {
+		if reserved(k) || p[k] == "" {
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("%q is not set in profile %q", k, profile))
+		}
+		in[k] = ""
+	}
+	if plain := engine.Settings(p).Values(plainField); len(plain) > 0 {
+		plain = slices.DeleteFunc(plain, func(k string) bool { return slices.Contains(keys, k) })
+		in[plainField] = strings.Join(plain, ",")
+	}
+	return engine.Modify(driver, profile, false, true, in)
+}
@@ -0,0 +1,116 @@
+package custom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/bundle"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+)
+
+// secretKeys is what exec treats as credentials in profile.
+func secretKeys(t *testing.T, profile string) []string {
+	ex, ok := plugin.LookupExporter("custom")
+	require.True(t, ok)
+	keys, err := ex.Secrets(profile)
+	require.NoError(t, err)
+	return keys
+}
+
+func TestSetAndExport(t *testing.T) {
+	t.Setenv("RDV_CUSTOM_DIR", t.TempDir())
+
+	require.NoError(t, customSet("stripe", true, setInput{
+		pairs: []string{"STRIPE_KEY=sk_test_123", "FEATURE_BETA=true", "DSN=https://a@b/1?x=y"},
+		plain: []string{"FEATURE_BETA"},
+	}))
+
+	vars, err := ExportVars("stripe")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"STRIPE_KEY":   "sk_test_123",
+		"FEATURE_BETA": "true",
+		"DSN":          "https://a@b/1?x=y",
+	}, vars)
+
+	require.Equal(t, []string{"DSN", "STRIPE_KEY"}, secretKeys(t, "stripe"))
+
+	// Updating a value keeps its marking; marks can be flipped on their own.
+	require.NoError(t, customSet("stripe", true, setInput{pairs: []string{"FEATURE_BETA=false"}}))
+	require.NoError(t, customSet("stripe", true, setInput{plain: []string{"DSN"}}))
+	require.Equal(t, []string{"STRIPE_KEY"}, secretKeys(t, "stripe"))
+	vars, _ = ExportVars("stripe")
+	require.Equal(t, "false", vars["FEATURE_BETA"])
+
+	require.NoError(t, customUnset("stripe", []string{"DSN"}))
+	vars, _ = ExportVars("stripe")
+	require.NotContains(t, vars, "DSN")
+	p, err := driver.Store().Get("stripe")
+	require.NoError(t, err)
+	require.Equal(t, "FEATURE_BETA", p[plainField])
+}
+
+func TestSetFromEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("RDV_CUSTOM_DIR", dir)
+	env := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(env, []byte("SENTRY_DSN=https://x@sentry/1\nexport LOG_LEVEL=debug\n"), 0o600))
+
+	require.NoError(t, customSet("sentry", true, setInput{
+		fromEnv: env,
+		pairs:   []string{"LOG_LEVEL=info"},
+		plain:   []string{"LOG_LEVEL"},
+	}))
+
+	vars, err := ExportVars("sentry")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"SENTRY_DSN": "https://x@sentry/1", "LOG_LEVEL": "info"}, vars)
+}
+
+func TestSetRejectsBadInput(t *testing.T) {
+	t.Setenv("RDV_CUSTOM_DIR", t.TempDir())
+
+	require.ErrorContains(t, customSet("x", true, setInput{}), "nothing to set")
+	require.ErrorContains(t, customSet("x", true, setInput{pairs: []string{"NOVALUE"}}), "expected KEY=VALUE")
+	require.ErrorContains(t, customSet("x", true, setInput{pairs: []string{"1BAD=v"}}), "invalid variable name")
+	require.ErrorContains(t, customSet("x", true, setInput{pairs: []string{"A=1"}, plain: []string{"B"}}), "not set")
+	require.ErrorContains(t, customSet("x", true, setInput{pairs: []string{"plain=A"}}), "reserved")
+
+	_, err := ExportVars("missing")
+	require.ErrorContains(t, err, "not found")
+}
+
+func TestSecretKeys(t *testing.T) {
+	t.Setenv("RDV_CUSTOM_DIR", t.TempDir())
+
+	require.NoError(t, customSet("app", true, setInput{pairs: []string{"B_TOKEN=x", "A_FLAG=on", "C_KEY=y"}, plain: []string{"A_FLAG"}}))
+
+	require.Equal(t, []string{"B_TOKEN", "C_KEY"}, secretKeys(t, "app"))
+}
+
+func TestExtendsAndCopy(t *testing.T) {
+	t.Setenv("RDV_CUSTOM_DIR", t.TempDir())
+	require.NoError(t, customSet("base", true, setInput{pairs: []string{"API_URL=https://api", "API_KEY=k1"}, plain: []string{"API_URL"}}))
+	require.NoError(t, driver.Store().Put("ci", map[string]string{"extends": "base", "API_KEY": "k2"}))
+
+	vars, err := ExportVars("ci")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"API_URL": "https://api", "API_KEY": "k2"}, vars)
+
+	require.NoError(t, profilestore.Copy(driver.Store(), "ci", "ci2", false))
+	require.Equal(t, []string{"API_KEY"}, secretKeys(t, "ci2"))
+}
+
+func TestRestrictKeepsVariables(t *testing.T) {
+	t.Setenv("RDV_CUSTOM_DIR", t.TempDir())
+	entries := []bundle.Entry{{Target: "custom", Profile: "app", Fields: map[string]string{"STRIPE_KEY": "sk", "plain": "", "extends": "prod"}}}
+	steps, err := bundle.Plan(entries, bundle.Skip, nil)
+	require.NoError(t, err)
+	steps = bundle.Restrict(steps)
+	require.Equal(t, []string{"extends"}, steps[0].Dropped)
+	require.Equal(t, map[string]string{"STRIPE_KEY": "sk", "plain": ""}, steps[0].Entry.Fields)
+}
@@ -0,0 +1,15 @@
+package custom
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// cfgPath returns ~/.config/rdv/custom.yaml (or override via RDV_CUSTOM_DIR for tests)
+func cfgPath() string {
+	if v := os.Getenv("RDV_CUSTOM_DIR"); v != "" {
+		return filepath.Join(v, "custom.yaml")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv", "custom.yaml")
+}
@@ -0,0 +1,35 @@
+package db
+
+import "github.com/yonasyiheyis/rdv/internal/engine"
+
+var clickhouse = &engine.Driver{
+	Name:    "clickhouse",
+	Label:   "ClickHouse",
+	Related: []string{"CLICKHOUSE_*"},
+	Fields: []engine.Field{
+		{Key: "host", Title: "Host", Usage: "db host", Example: "localhost"},
+		{Key: "port", Title: "Port", Usage: "native protocol port", Default: "9000"},
+		{Key: "dbname", Title: "Database", Usage: "db name", Default: "default"},
+		{Key: "user", Title: "Username", Usage: "db user", Default: "default"},
+		{Key: "password", Title: "Password", Usage: "db password", Secret: true, Optional: true},
+		{Key: "params", Title: "Params", Usage: "extra URL params (e.g. secure=true)", Example: "secure=true", Optional: true},
+	},
+	Env: func(p engine.Settings) map[string]string {
+		return map[string]string{
+			"CLICKHOUSE_DATABASE_URL": clickhouseURL(p),
+			"CLICKHOUSE_HOST":         p["host"],
+			"CLICKHOUSE_PORT":         p["port"],
+			"CLICKHOUSE_USER":         p["user"],
+			"CLICKHOUSE_PASSWORD":     p["password"],
+			"CLICKHOUSE_DATABASE":     p["dbname"],
+		}
+	},
+	Test: testSQLConn("ClickHouse", "clickhouse", clickhouseURL),
+}
+
+func init() { registerDriver(clickhouse) }
+
+// clickhouseURL is the native-protocol URL clickhouse-go accepts.
+func clickhouseURL(p engine.Settings) string {
+	return connURL("clickhouse", p, p["dbname"], p["params"])
+}
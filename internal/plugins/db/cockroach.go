@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+)
+
+var cockroach = &engine.Driver{
+	Name:    "cockroach",
+	Aliases: []string{"crdb"},
+	Label:   "CockroachDB",
+	Related: []string{"COCKROACH_*"},
+	Fields: []engine.Field{
+		{Key: "host", Title: "Host", Usage: "db host", Example: "localhost"},
+		{Key: "port", Title: "Port", Usage: "db port", Default: "26257"},
+		{Key: "dbname", Title: "Database", Usage: "db name", Default: "defaultdb"},
+		{Key: "user", Title: "Username", Usage: "db user", Default: "root"},
+		{Key: "password", Title: "Password", Usage: "db password (empty for insecure clusters)", Secret: true, Optional: true},
+		{Key: "sslmode", Title: "SSL mode", Usage: "sslmode (disable, require, verify-full)", Default: "disable"},
+	},
+	Env: func(p engine.Settings) map[string]string {
+		return map[string]string{
+			"COCKROACH_URL":      cockroachURL(p),
+			"COCKROACH_HOST":     p["host"],
+			"COCKROACH_PORT":     p["port"],
+			"COCKROACH_USER":     p["user"],
+			"COCKROACH_PASSWORD": p["password"],
+			"COCKROACH_DATABASE": p["dbname"],
+		}
+	},
+	Test: func(ctx context.Context, p engine.Settings, out io.Writer) error {
+		if err := pingPgx(ctx, cockroachURL(p)); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(out, "✅ CockroachDB connection successful")
+		return nil
+	},
+}
+
+func init() { registerDriver(cockroach) }
+
+func cockroachURL(p engine.Settings) string {
+	return connURL("postgresql", p, p["dbname"], "sslmode="+p["sslmode"])
+}
@@ -13,6 +13,6 @@ func TestMySQLPathOverride(t *testing.T) {
 	require.NoError(t, os.Setenv("RDV_DB_DIR", tmp))
 	defer func() { _ = os.Unsetenv("RDV_DB_DIR") }()
 
-	require.Contains(t, mysqlPath(), tmp)
-	require.Equal(t, filepath.Join(tmp, "mysql.yaml"), mysqlPath())
+	require.Contains(t, mysql.Path(), tmp)
+	require.Equal(t, filepath.Join(tmp, "mysql.yaml"), mysql.Path())
 }
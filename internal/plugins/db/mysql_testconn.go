@@ -1,43 +1,38 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+	"io"
 
 	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
 )
 
-func buildMySQLDSN(p mysqlProfile) string {
-	params := p.Params
+func buildMySQLDSN(p engine.Settings) string {
+	params := p["params"]
 	if params == "" {
 		params = "parseTime=true"
 	}
 	// DSN for driver
 	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?%s",
-		p.User, p.Password, p.Host, p.Port, p.DBName, params)
+		p["user"], p["password"], p["host"], p["port"], p["dbname"], params)
 }
 
-func buildMySQLURL(p mysqlProfile) string {
-	params := p.Params
+func buildMySQLURL(p engine.Settings) string {
+	params := p["params"]
 	if params == "" {
 		params = "parseTime=true"
 	}
 	// URL form for DATABASE_URL
-	return fmt.Sprintf("mysql://%s:%s@%s:%s/%s?%s",
-		p.User, p.Password, p.Host, p.Port, p.DBName, params)
+	return connURL("mysql", p, p["dbname"], params)
 }
 
-func testMySQLConn(p mysqlProfile) error {
-	dsn := buildMySQLDSN(p)
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return fmt.Errorf("open failed: %w", err)
-	}
-	defer func() { _ = db.Close() }() // satisfy errcheck
-
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("ping failed: %w", err)
+func testMySQLConn(ctx context.Context, p engine.Settings, out io.Writer) error {
+	if err := pingSQL(ctx, "mysql", buildMySQLDSN(p)); err != nil {
+		return err
 	}
-	fmt.Println("✅ MySQL connection successful")
+	_, _ = fmt.Fprintln(out, "✅ MySQL connection successful")
 	return nil
 }
@@ -13,11 +13,3 @@ func configDir() string {
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".config", "rdv", "db")
 }
-
-func postgresPath() string {
-	return filepath.Join(configDir(), "postgres.yaml")
-}
-
-func mysqlPath() string {
-	return filepath.Join(configDir(), "mysql.yaml")
-}
@@ -3,14 +3,27 @@ package db
 import (
 	"context"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
 )
 
-func testPgConn(p pgProfile) error {
-	url := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		p.User, p.Password, p.Host, p.Port, p.DBName)
-	conn, err := pgx.Connect(context.Background(), url)
+func testPgConn(ctx context.Context, p engine.Settings, out io.Writer) error {
+	if err := pingPgx(ctx, pgURL(p)); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(out, "✅ Postgres connection successful")
+	return nil
+}
+
+// pingPgx connects with pgx and pings; shared by Postgres-wire engines.
+func pingPgx(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	conn, err := pgx.Connect(ctx, url)
 	if err != nil {
 		return fmt.Errorf("connect failed: %w", err)
 	}
@@ -19,9 +32,8 @@ func testPgConn(p pgProfile) error {
 		_ = conn.Close(context.Background())
 	}()
 
-	if err = conn.Ping(context.Background()); err != nil {
+	if err = conn.Ping(ctx); err != nil {
 		return fmt.Errorf("ping failed: %w", err)
 	}
-	fmt.Println("✅ Postgres connection successful")
 	return nil
 }
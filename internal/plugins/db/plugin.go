@@ -3,6 +3,7 @@ package db
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/yonasyiheyis/rdv/internal/engine"
 	"github.com/yonasyiheyis/rdv/internal/plugin"
 )
 
@@ -16,10 +17,10 @@ func (d *dbPlugin) Register(root *cobra.Command) {
 		Short: "Manage database connection settings",
 	}
 
-	// attach the postgres sub‑command
-	dbCmd.AddCommand(newPostgresCmd())
-	// attach the mysql sub‑command
-	dbCmd.AddCommand(newMySQLCmd())
+	// attach one sub‑command per engine (postgres, mysql, ...)
+	for _, drv := range drivers {
+		dbCmd.AddCommand(engine.Command(drv))
+	}
 
 	root.AddCommand(dbCmd)
 }
@@ -16,5 +16,5 @@ func TestConfigDirOverride(t *testing.T) {
 	}()
 
 	require.Contains(t, configDir(), tmp)
-	require.Equal(t, filepath.Join(tmp, "postgres.yaml"), postgresPath())
+	require.Equal(t, filepath.Join(tmp, "postgres.yaml"), postgres.Path())
 }
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	_ "github.com/ClickHouse/clickhouse-go/v2"
+	_ "github.com/microsoft/go-mssqldb"
+	_ "github.com/sijms/go-ora/v2"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+)
+
+// pingSQL opens dsn with a database/sql driver and pings it, so bad
+// credentials fail the test and not just an unreachable port.
+func pingSQL(ctx context.Context, driverName, dsn string) error {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return fmt.Errorf("open failed: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	return nil
+}
+
+// testSQLConn returns a tester pinging the URL dsn builds with driverName.
+func testSQLConn(label, driverName string, dsn func(p engine.Settings) string) func(context.Context, engine.Settings, io.Writer) error {
+	return func(ctx context.Context, p engine.Settings, out io.Writer) error {
+		if err := pingSQL(ctx, driverName, dsn(p)); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "✅ %s connection successful\n", label)
+		return nil
+	}
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+)
+
+// fileFields are the settings shared by file-based engines.
+func fileFields(example string) []engine.Field {
+	return []engine.Field{
+		{Key: "path", Title: "Database file", Usage: "path to the database file", Example: example, Path: true},
+		{Key: "read_only", Title: "Open read-only?", Usage: "open the database read-only", Bool: true},
+		{Key: "pragmas", Title: "Pragmas", Usage: "comma-separated pragmas (e.g. foreign_keys=on,busy_timeout=5000)", Example: "foreign_keys=on", Optional: true},
+	}
+}
+
+var sqlite = &engine.Driver{
+	Name:   "sqlite",
+	Label:  "SQLite",
+	Fields: fileFields("./dev.db"),
+	Env: func(p engine.Settings) map[string]string {
+		vars := map[string]string{
+			"SQLITE_PATH":  p["path"],
+			"DATABASE_URL": fileURL("sqlite", p),
+		}
+		if p["pragmas"] != "" {
+			vars["SQLITE_PRAGMAS"] = p["pragmas"]
+		}
+		return vars
+	},
+	Test: testSQLiteConn,
+}
+
+func init() { registerDriver(sqlite) }
+
+// fileURL builds scheme:///abs/path, adding mode=ro for read-only profiles.
+func fileURL(scheme string, p engine.Settings) string {
+	u := scheme + "://" + p["path"]
+	if p["read_only"] == "true" {
+		u += "?mode=ro"
+	}
+	return u
+}
+
+// sqliteDSN builds a file: URI for path. The path is percent-escaped so
+// "?", "#" or "%" in a file name can't end it early or lose the mode.
+func sqliteDSN(path, mode string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // file:///C:/db on Windows
+	}
+	u := url.URL{Scheme: "file", Path: path, RawQuery: "mode=" + mode}
+	return u.String()
+}
+
+// splitPragmas turns "a=1, b=2" into ["a=1", "b=2"].
+func splitPragmas(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func testSQLiteConn(ctx context.Context, p engine.Settings, out io.Writer) error {
+	path := p["path"]
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("database file: %w", err)
+	}
+
+	// mode=rw (instead of rwc) keeps a typo from creating an empty database.
+	mode := "rw"
+	if p["read_only"] == "true" {
+		mode = "ro"
+	}
+	db, err := sql.Open("sqlite", sqliteDSN(path, mode))
+	if err != nil {
+		return fmt.Errorf("open failed: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+	db.SetMaxOpenConns(1) // pragmas are per connection
+
+	for _, pragma := range splitPragmas(p["pragmas"]) {
+		if _, err := db.ExecContext(ctx, "PRAGMA "+pragma); err != nil {
+			return fmt.Errorf("pragma %q failed: %w", pragma, err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check reported problems: %s", strings.Join(problems, "; "))
+	}
+
+	_, _ = fmt.Fprintf(out, "✅ SQLite database %s passed integrity_check\n", path)
+	return nil
+}
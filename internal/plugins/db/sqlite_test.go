@@ -0,0 +1,77 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+)
+
+func TestSQLiteProfile(t *testing.T) {
+	t.Setenv("RDV_DB_DIR", t.TempDir())
+	path := filepath.Join(t.TempDir(), "dev.db")
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE t (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	in := engine.Settings{"path": path, "read_only": "true", "pragmas": "foreign_keys=on, busy_timeout=1000"}
+	require.NoError(t, engine.SetConfig(sqlite, "dev", true, true, in))
+
+	vars, err := ExportVars("sqlite", "dev")
+	require.NoError(t, err)
+	require.Equal(t, path, vars["SQLITE_PATH"])
+	require.Equal(t, "sqlite://"+path+"?mode=ro", vars["DATABASE_URL"])
+}
+
+func TestSQLiteTestConnMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.db")
+	require.Error(t, testSQLiteConn(t.Context(), engine.Settings{"path": path}, io.Discard))
+
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err), "test-conn must not create the file")
+}
+
+func TestSQLiteTestConnOddPath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my docs")
+	require.NoError(t, os.Mkdir(dir, 0o700))
+	path := filepath.Join(dir, "a?b#c%20.db")
+
+	db, err := sql.Open("sqlite", sqliteDSN(path, "rwc"))
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE t (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	require.NoError(t, testSQLiteConn(t.Context(), engine.Settings{"path": path, "read_only": "true"}, io.Discard))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no stray file opened under a truncated name")
+}
+
+func TestDuckDBHeader(t *testing.T) {
+	dir := t.TempDir()
+
+	good := filepath.Join(dir, "good.duckdb")
+	hdr := make([]byte, 3*duckdbHeaderSize)
+	copy(hdr[8:], "DUCK")
+	binary.LittleEndian.PutUint64(hdr[12:], 64)
+	require.NoError(t, os.WriteFile(good, hdr, 0o600))
+	require.NoError(t, testDuckDBFile(t.Context(), engine.Settings{"path": good}, io.Discard))
+
+	truncated := filepath.Join(dir, "truncated.duckdb")
+	require.NoError(t, os.WriteFile(truncated, hdr[:duckdbHeaderSize], 0o600))
+	require.Error(t, testDuckDBFile(t.Context(), engine.Settings{"path": truncated}, io.Discard))
+
+	bad := filepath.Join(dir, "bad.duckdb")
+	require.NoError(t, os.WriteFile(bad, []byte("SQLite format 3\x00 and more bytes"), 0o600))
+	require.Error(t, testDuckDBFile(t.Context(), engine.Settings{"path": bad}, io.Discard))
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"net/url"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+)
+
+var sqlserver = &engine.Driver{
+	Name:    "sqlserver",
+	Aliases: []string{"mssql"},
+	Label:   "SQL Server",
+	Related: []string{"MSSQL_*"},
+	Fields: append(connFields("1433", "master"),
+		engine.Field{Key: "params", Title: "Params", Usage: "extra URL params (e.g. encrypt=disable)", Example: "encrypt=disable", Optional: true},
+	),
+	Env: func(p engine.Settings) map[string]string {
+		return map[string]string{
+			"MSSQL_DATABASE_URL": sqlserverURL(p),
+			"MSSQL_HOST":         p["host"],
+			"MSSQL_PORT":         p["port"],
+			"MSSQL_USER":         p["user"],
+			"MSSQL_PASSWORD":     p["password"],
+			"MSSQL_DATABASE":     p["dbname"],
+		}
+	},
+	Test: testSQLConn("SQL Server", "sqlserver", sqlserverURL),
+}
+
+func init() { registerDriver(sqlserver) }
+
+// sqlserverURL uses the go-mssqldb URL form, which carries the database as a
+// query parameter.
+func sqlserverURL(p engine.Settings) string {
+	q := "database=" + url.QueryEscape(p["dbname"])
+	if p["params"] != "" {
+		q += "&" + p["params"]
+	}
+	return connURL("sqlserver", p, "", q)
+}
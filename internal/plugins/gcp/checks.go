@@ -0,0 +1,57 @@
+package gcp
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+// profileFiles returns every file rdv keeps per GCP profile: its YAML, the
+// env file and a copied key, whether or not they exist yet.
+func profileFiles() []string {
+	profiles, _ := listProfiles()
+	var out []string
+	for _, p := range profiles {
+		out = append(out, getConfigPath(p), getEnvPath(p), getCopiedKeyPath(p))
+	}
+	return out
+}
+
+// checkProfiles reports service-account profiles whose key file is gone and
+// a missing gcloud CLI, which every GCP connection test runs.
+func checkProfiles() ([]plugin.Issue, error) {
+	profiles, err := listProfiles()
+	if err != nil {
+		return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	var issues []plugin.Issue
+	for _, p := range profiles {
+		c, err := resolveConfig(p)
+		if err != nil {
+			issues = append(issues, plugin.Issue{Profile: p, Check: "config", Detail: exitcodes.Message(err)})
+			continue
+		}
+		if c.Auth != "service-account-json" {
+			continue
+		}
+		keyPath := c.KeyFile
+		if c.CopiedKeyFile != "" {
+			keyPath = c.CopiedKeyFile
+		}
+		switch _, err := os.Stat(keyPath); {
+		case keyPath == "":
+			issues = append(issues, plugin.Issue{Profile: p, Check: "key-file", Detail: "no key file configured"})
+		case os.IsNotExist(err):
+			issues = append(issues, plugin.Issue{Profile: p, Check: "key-file", Detail: fmt.Sprintf("key file %s does not exist", keyPath)})
+		}
+	}
+	if len(profiles) > 0 {
+		if _, err := exec.LookPath("gcloud"); err != nil {
+			issues = append(issues, plugin.Issue{Check: "gcloud", Detail: "gcloud CLI is not installed or not in PATH; GCP connection tests need it"})
+		}
+	}
+	return issues, nil
+}
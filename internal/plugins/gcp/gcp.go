@@ -1,7 +1,9 @@
 package gcp
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -19,6 +21,8 @@ import (
 	"github.com/yonasyiheyis/rdv/internal/logger"
 	"github.com/yonasyiheyis/rdv/internal/plugin"
 	iprint "github.com/yonasyiheyis/rdv/internal/print"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
 	"github.com/yonasyiheyis/rdv/internal/ui"
 )
 
@@ -115,15 +119,17 @@ func (g *gcpPlugin) Register(root *cobra.Command) {
 
 	// -------- show ----------------
 	var showProfile string
+	var showResolved bool
 
 	showCmd := &cobra.Command{
 		Use:   "show",
 		Short: "Show GCP profile configuration (sanitized)",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return runShow(showProfile)
+			return runShow(showProfile, showResolved)
 		},
 	}
 	showCmd.Flags().StringVarP(&showProfile, "profile", "p", "dev", "GCP profile")
+	showCmd.Flags().BoolVar(&showResolved, "resolved", false, "Show effective values after extends and ${VAR} / {{ .profile }} interpolation")
 
 	// -------- export ----------------
 	var expProfile string
@@ -150,23 +156,28 @@ func (g *gcpPlugin) Register(root *cobra.Command) {
 		Use:   "test-conn",
 		Short: "Test GCP connection",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return runTestConn(testProfile)
+			return runTestConn(context.Background(), testProfile, os.Stdout)
 		},
 	}
 	testConnCmd.Flags().StringVarP(&testProfile, "profile", "p", "dev", "GCP profile")
 
 	gcpCmd.AddCommand(setCmd, modifyCmd, deleteCmd, listCmd, showCmd, exportCmd, testConnCmd)
+	gcpCmd.AddCommand(profilestore.Commands(store{}, "GCP")...)
 	root.AddCommand(gcpCmd)
 }
 
 func init() {
 	plugin.Register(&gcpPlugin{})
+	plugin.RegisterExporter(plugin.Exporter{Name: "gcp", Export: ExportVars, List: listSummaries,
+		Test: runTestConn, Files: profileFiles, Check: checkProfiles, Store: store{}})
 }
 
 // ---------- data types ----------
 
 type gcpConfig struct {
-	Auth          string    `yaml:"auth"` // service-account-json or gcloud-adc
+	Extends       string    `yaml:"extends,omitempty"`     // base profile for unset fields
+	Interpolate   bool      `yaml:"interpolate,omitempty"` // expand ${VAR} and {{ .profile }} in this profile's values
+	Auth          string    `yaml:"auth"`                  // service-account-json or gcloud-adc
 	KeyFile       string    `yaml:"key_file,omitempty"`
 	CopiedKeyFile string    `yaml:"copied_key_file,omitempty"`
 	ProjectID     string    `yaml:"project_id"`
@@ -229,10 +240,79 @@ func saveGCPConfig(profile string, config gcpConfig) error {
 	return nil
 }
 
-// normalizePath converts relative paths and ~ to absolute paths
+// exists reports whether a profile was loaded from disk; a child profile
+// may leave even auth to its base.
+func (c gcpConfig) exists() bool { return c.Auth != "" || c.Extends != "" }
+
+// resolveConfig loads a profile merged over the profiles it extends, with
+// ${VAR} and {{ .profile }} interpolated in the values of profiles that opt
+// in.
+func resolveConfig(profile string) (gcpConfig, error) {
+	configs := map[string]gcpConfig{}
+	chain, err := resolve.Chain(profile, func(n string) (string, error) {
+		c, err := loadGCPConfig(n)
+		if err != nil {
+			return "", exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		if !c.exists() {
+			return "", exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found", n))
+		}
+		configs[n] = c
+		return c.Extends, nil
+	})
+	if err != nil {
+		return gcpConfig{}, err
+	}
+
+	out := gcpConfig{UpdatedAt: configs[profile].UpdatedAt}
+	for i := len(chain) - 1; i >= 0; i-- {
+		c := configs[chain[i]]
+		if !c.Interpolate {
+			for _, v := range []*string{&c.Auth, &c.KeyFile, &c.CopiedKeyFile, &c.ProjectID, &c.Region, &c.Zone} {
+				*v = resolve.Literal(*v)
+			}
+		}
+		override(&out.Auth, c.Auth)
+		override(&out.KeyFile, c.KeyFile)
+		override(&out.CopiedKeyFile, c.CopiedKeyFile)
+		override(&out.ProjectID, c.ProjectID)
+		override(&out.Region, c.Region)
+		override(&out.Zone, c.Zone)
+	}
+	err = resolve.ExpandFields(profile, map[string]*string{
+		"auth": &out.Auth, "key_file": &out.KeyFile, "copied_key_file": &out.CopiedKeyFile,
+		"project_id": &out.ProjectID, "region": &out.Region, "zone": &out.Zone,
+	})
+	if err != nil {
+		return gcpConfig{}, err
+	}
+	if out.Auth == "" {
+		return gcpConfig{}, exitcodes.New(exitcodes.ConfigReadWrite, fmt.Sprintf("profile %q has no auth method (set it or extend a profile that does)", profile))
+	}
+	return out, nil
+}
+
+// override sets *dst to v unless v is empty.
+func override(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+// ExportVars returns the GOOGLE_*/CLOUDSDK_* variables for the given profile.
+func ExportVars(profile string) (map[string]string, error) {
+	config, err := resolveConfig(profile)
+	if err != nil {
+		return nil, err
+	}
+	return generateEnvVars(config)
+}
+
+// normalizePath converts relative paths and ~ to absolute paths. A path
+// starting with ${VAR} is left for interpolation to resolve.
 func normalizePath(path string) (string, error) {
-	if path == "" {
-		return "", nil
+	if path == "" || resolve.RootedInRef(path) {
+		return path, nil
 	}
 
 	// Handle ~
@@ -365,7 +445,7 @@ func runSetConfig(profile, auth, projectID, region, zone, keyFile string, copyKe
 
 	// Test connection if requested
 	if testConn {
-		if err := testGCPConnection(config); err != nil {
+		if err := testGCPConnection(context.Background(), config, os.Stdout); err != nil {
 			return exitcodes.Wrap(exitcodes.ConnectionFailed, err)
 		}
 	}
@@ -381,7 +461,7 @@ func runModify(profile, auth, projectID, region, zone, keyFile string, copyKey,
 	}
 
 	// Check if profile exists
-	if current.Auth == "" {
+	if !current.exists() {
 		return exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found", profile))
 	}
 
@@ -415,26 +495,35 @@ func runModify(profile, auth, projectID, region, zone, keyFile string, copyKey,
 			input.KeyFile = keyFile
 		}
 	} else {
-		// Interactive mode - show current values
+		// Interactive mode - show current values. A child profile only
+		// stores what it overrides, so it may leave any field to its base.
+		authOpts := []huh.Option[string]{
+			huh.NewOption("Service Account JSON", "service-account-json"),
+			huh.NewOption("gcloud Application Default Credentials", "gcloud-adc"),
+		}
+		required := huh.ValidateNotEmpty()
+		projectTitle, optional := "GCP Project ID", "optional"
+		if current.Extends != "" {
+			authOpts = append([]huh.Option[string]{huh.NewOption("Inherit from "+current.Extends, "")}, authOpts...)
+			required = func(string) error { return nil }
+			projectTitle += " (empty inherits from " + current.Extends + ")"
+			optional += ", empty inherits from " + current.Extends
+		}
 		form := ui.NewForm(
 			huh.NewGroup(
 				huh.NewSelect[string]().
 					Title("Authentication Method").
-					Options(
-						huh.NewOption("Service Account JSON", "service-account-json"),
-						huh.NewOption("gcloud Application Default Credentials", "gcloud-adc"),
-					).
-					Value(&input.Auth).
-					Validate(huh.ValidateNotEmpty()),
+					Options(authOpts...).
+					Value(&input.Auth),
 				huh.NewInput().
-					Title("GCP Project ID").
+					Title(projectTitle).
 					Value(&input.ProjectID).
-					Validate(huh.ValidateNotEmpty()),
+					Validate(required),
 				huh.NewInput().
-					Title("GCP Region (optional)").
+					Title("GCP Region ("+optional+")").
 					Value(&input.Region),
 				huh.NewInput().
-					Title("GCP Zone (optional)").
+					Title("GCP Zone ("+optional+")").
 					Value(&input.Zone),
 			),
 		)
@@ -444,13 +533,13 @@ func runModify(profile, auth, projectID, region, zone, keyFile string, copyKey,
 		}
 
 		// Additional form for service account JSON
-		if input.Auth == "service-account-json" {
+		if effectiveAuth(current, input.Auth) == "service-account-json" {
 			keyForm := ui.NewForm(
 				huh.NewGroup(
 					huh.NewInput().
 						Title("Path to Service Account JSON Key File").
 						Value(&input.KeyFile).
-						Validate(huh.ValidateNotEmpty()),
+						Validate(required),
 					huh.NewConfirm().
 						Title("Copy key file to config directory?").
 						Value(&input.CopyKey),
@@ -472,6 +561,8 @@ func runModify(profile, auth, projectID, region, zone, keyFile string, copyKey,
 
 	// Create updated config
 	config := gcpConfig{
+		Extends:       current.Extends,
+		Interpolate:   current.Interpolate,
 		Auth:          input.Auth,
 		KeyFile:       input.KeyFile,
 		CopiedKeyFile: current.CopiedKeyFile, // preserve existing copied key file
@@ -481,7 +572,7 @@ func runModify(profile, auth, projectID, region, zone, keyFile string, copyKey,
 	}
 
 	// Handle key file copying
-	if input.Auth == "service-account-json" && input.CopyKey {
+	if effectiveAuth(current, input.Auth) == "service-account-json" && input.CopyKey && input.KeyFile != "" {
 		copiedPath := getCopiedKeyPath(profile)
 		if err := copyKeyFile(input.KeyFile, copiedPath); err != nil {
 			return fmt.Errorf("failed to copy key file: %w", err)
@@ -497,9 +588,13 @@ func runModify(profile, auth, projectID, region, zone, keyFile string, copyKey,
 	logger.L.Infow("gcp profile modified", "profile", profile)
 	fmt.Printf("✅ Updated profile %q\n", profile)
 
-	// Test connection if requested
+	// Test connection if requested (with anything inherited filled in)
 	if testConn {
-		if err := testGCPConnection(config); err != nil {
+		resolved, err := resolveConfig(profile)
+		if err != nil {
+			return err
+		}
+		if err := testGCPConnection(context.Background(), resolved, os.Stdout); err != nil {
 			return exitcodes.Wrap(exitcodes.ConnectionFailed, err)
 		}
 	}
@@ -507,13 +602,26 @@ func runModify(profile, auth, projectID, region, zone, keyFile string, copyKey,
 	return nil
 }
 
+// effectiveAuth is the auth method a profile ends up with when it stores
+// auth: its own, or for a child leaving it empty, its base's.
+func effectiveAuth(current gcpConfig, auth string) string {
+	if auth != "" || current.Extends == "" {
+		return auth
+	}
+	base, err := resolveConfig(current.Extends)
+	if err != nil {
+		return ""
+	}
+	return base.Auth
+}
+
 func runDelete(profile string, purgeKey bool) error {
 	config, err := loadGCPConfig(profile)
 	if err != nil {
 		return err
 	}
 
-	if config.Auth == "" {
+	if !config.exists() {
 		return exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found", profile))
 	}
 
@@ -550,18 +658,25 @@ func runDelete(profile string, purgeKey bool) error {
 	return nil
 }
 
-func runShow(profile string) error {
+func runShow(profile string, resolved bool) error {
 	config, err := loadGCPConfig(profile)
 	if err != nil {
 		return err
 	}
 
-	if config.Auth == "" {
+	if !config.exists() {
 		return exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found", profile))
 	}
+	if resolved {
+		if config, err = resolveConfig(profile); err != nil {
+			return err
+		}
+	}
 
 	// Create sanitized version for display
 	sanitized := gcpConfig{
+		Extends:       config.Extends,
+		Interpolate:   config.Interpolate,
 		Auth:          config.Auth,
 		KeyFile:       iprint.Redact(config.KeyFile),
 		CopiedKeyFile: iprint.Redact(config.CopiedKeyFile),
@@ -579,6 +694,12 @@ func runShow(profile string) error {
 	}
 
 	fmt.Printf("profile: %s\n", profile)
+	if sanitized.Extends != "" {
+		fmt.Printf("  extends: %s\n", sanitized.Extends)
+	}
+	if sanitized.Interpolate {
+		fmt.Println("  interpolate: true")
+	}
 	fmt.Printf("  auth: %s\n", sanitized.Auth)
 	if sanitized.KeyFile != "" {
 		fmt.Printf("  key_file: %s\n", sanitized.KeyFile)
@@ -599,15 +720,11 @@ func runShow(profile string) error {
 }
 
 func runExport(profile string, print bool, style string, envPath string) error {
-	config, err := loadGCPConfig(profile)
+	config, err := resolveConfig(profile)
 	if err != nil {
 		return err
 	}
 
-	if config.Auth == "" {
-		return exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found", profile))
-	}
-
 	// Generate environment variables
 	vars, err := generateEnvVars(config)
 	if err != nil {
@@ -660,38 +777,31 @@ func runExport(profile string, print bool, style string, envPath string) error {
 	return nil
 }
 
-func runTestConn(profile string) error {
-	config, err := loadGCPConfig(profile)
+func runTestConn(ctx context.Context, profile string, out io.Writer) error {
+	config, err := resolveConfig(profile)
 	if err != nil {
 		return err
 	}
 
-	if config.Auth == "" {
-		return exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found", profile))
-	}
-
-	return testGCPConnection(config)
+	return testGCPConnection(ctx, config, out)
 }
 
-func runList() error {
+// listProfiles returns the names of the saved profile files, sorted.
+func listProfiles() ([]string, error) {
 	configDir := getConfigDir()
 
 	// Check if config directory exists
 	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		if iprint.JSON {
-			return iprint.Out(map[string]any{"profiles": []string{}})
-		}
-		fmt.Println("(no profiles)")
-		return nil
+		return []string{}, nil
 	}
 
 	// Read directory contents
 	entries, err := os.ReadDir(configDir)
 	if err != nil {
-		return fmt.Errorf("failed to read config directory: %w", err)
+		return nil, fmt.Errorf("failed to read config directory: %w", err)
 	}
 
-	var profiles []string
+	profiles := []string{}
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -707,6 +817,35 @@ func runList() error {
 
 	// Sort profiles for consistent output
 	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// listSummaries describes each profile for `rdv list --all`.
+func listSummaries() ([]plugin.Summary, error) {
+	profiles, err := listProfiles()
+	if err != nil {
+		return nil, err
+	}
+	var out []plugin.Summary
+	for _, n := range profiles {
+		c, err := loadGCPConfig(n)
+		if err != nil {
+			return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		out = append(out, plugin.Summary{
+			Profile:  n,
+			Meta:     map[string]string{"project": c.ProjectID, "region": c.Region, "auth": c.Auth, "extends": c.Extends},
+			Modified: c.UpdatedAt,
+		})
+	}
+	return out, nil
+}
+
+func runList() error {
+	profiles, err := listProfiles()
+	if err != nil {
+		return err
+	}
 
 	if iprint.JSON {
 		return iprint.Out(map[string]any{"profiles": profiles})
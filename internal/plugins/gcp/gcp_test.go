@@ -6,6 +6,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
 )
 
 func TestPaths(t *testing.T) {
@@ -43,6 +47,12 @@ func TestNormalizePath(t *testing.T) {
 			expected: "/tmp/test.json",
 			hasError: false,
 		},
+		{
+			name:     "rooted in an env var",
+			input:    "${GCP_KEYS}/sa.json",
+			expected: "${GCP_KEYS}/sa.json",
+			hasError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -55,8 +65,8 @@ func TestNormalizePath(t *testing.T) {
 				switch tt.input {
 				case "":
 					require.Equal(t, "", result)
-				case "/tmp/test.json":
-					require.Equal(t, "/tmp/test.json", result)
+				case "/tmp/test.json", "${GCP_KEYS}/sa.json":
+					require.Equal(t, tt.expected, result)
 				default:
 					// For relative paths, can't predict the exact result
 				}
@@ -132,3 +142,80 @@ func TestRunList(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestExportVarsExtends(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GCP_REGION", "europe-west1")
+	require.NoError(t, saveGCPConfig("base", gcpConfig{Interpolate: true, Auth: "gcloud-adc", ProjectID: "acme-{{ .profile }}", Region: "us-central1"}))
+	require.NoError(t, saveGCPConfig("eu", gcpConfig{Extends: "base", Interpolate: true, Region: "${GCP_REGION}"}))
+	require.NoError(t, saveGCPConfig("plain", gcpConfig{Extends: "base", Region: "${GCP_REGION}"}))
+	require.NoError(t, saveGCPConfig("cycle", gcpConfig{Extends: "cycle"}))
+
+	vars, err := ExportVars("eu")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"CLOUDSDK_CORE_PROJECT": "acme-eu",
+		"GOOGLE_CLOUD_PROJECT":  "acme-eu",
+		"GOOGLE_CLOUD_REGION":   "europe-west1",
+	}, vars)
+
+	vars, err = ExportVars("plain")
+	require.NoError(t, err)
+	require.Equal(t, "${GCP_REGION}", vars["GOOGLE_CLOUD_REGION"], "no interpolate: its own region is used as stored")
+	require.Equal(t, "acme-plain", vars["GOOGLE_CLOUD_PROJECT"], "the base still interpolates its own")
+
+	_, err = ExportVars("cycle")
+	require.Equal(t, exitcodes.ConfigReadWrite, exitcodes.FromError(err))
+	_, err = ExportVars("missing")
+	require.Equal(t, exitcodes.ProfileNotFound, exitcodes.FromError(err))
+}
+
+func TestModifyChildKeepsInheriting(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	require.NoError(t, saveGCPConfig("base", gcpConfig{Auth: "gcloud-adc", ProjectID: "acme"}))
+	require.NoError(t, saveGCPConfig("eu", gcpConfig{Extends: "base", Interpolate: true}))
+
+	require.NoError(t, runModify("eu", "", "", "europe-west1", "", "${GCP_KEYS}/eu.json", false, false, true))
+	c, err := loadGCPConfig("eu")
+	require.NoError(t, err)
+	require.Equal(t, "", c.Auth, "auth stays inherited")
+	require.Equal(t, "", c.ProjectID, "project stays inherited")
+	require.Equal(t, "europe-west1", c.Region)
+	require.Equal(t, "${GCP_KEYS}/eu.json", c.KeyFile)
+	require.True(t, c.Interpolate)
+}
+
+func TestStoreRenameMovesCopiedKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	require.NoError(t, os.MkdirAll(getConfigDir(), 0o700))
+	require.NoError(t, os.WriteFile(getCopiedKeyPath("dev"), []byte(`{"type":"service_account"}`), 0o600))
+	require.NoError(t, saveGCPConfig("dev", gcpConfig{Auth: "service-account-json", CopiedKeyFile: getCopiedKeyPath("dev"), ProjectID: "acme-dev"}))
+	require.NoError(t, saveGCPConfig("eu", gcpConfig{Extends: "dev", Region: "europe-west1"}))
+
+	require.NoError(t, profilestore.Rename(store{}, "dev", "staging", false))
+
+	names, err := listProfiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{"eu", "staging"}, names)
+	require.NoFileExists(t, getCopiedKeyPath("dev"))
+	require.FileExists(t, getCopiedKeyPath("staging"))
+
+	vars, err := ExportVars("eu")
+	require.NoError(t, err)
+	require.Equal(t, getCopiedKeyPath("staging"), vars["GOOGLE_APPLICATION_CREDENTIALS"])
+}
+
+func TestCheckProfilesFindsDanglingKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PATH", t.TempDir()) // no gcloud
+	require.NoError(t, saveGCPConfig("adc", gcpConfig{Auth: "gcloud-adc", ProjectID: "p"}))
+	require.NoError(t, saveGCPConfig("sa", gcpConfig{Auth: "service-account-json", KeyFile: "/nonexistent/key.json", ProjectID: "p"}))
+
+	issues, err := checkProfiles()
+	require.NoError(t, err)
+	require.Equal(t, []plugin.Issue{
+		{Profile: "sa", Check: "key-file", Detail: "key file /nonexistent/key.json does not exist"},
+		{Check: "gcloud", Detail: "gcloud CLI is not installed or not in PATH; GCP connection tests need it"},
+	}, issues)
+	require.Contains(t, profileFiles(), getConfigPath("sa"))
+}
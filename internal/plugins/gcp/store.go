@@ -0,0 +1,87 @@
+package gcp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
+)
+
+// store exposes the per-profile GCP files to copy/rename/diff and backups. A
+// key copied into the config dir belongs to its profile, so it is copied (and
+// removed, and backed up) along with it.
+type store struct{}
+
+func (store) Names() ([]string, error) { return listProfiles() }
+
+func (store) Get(name string) (map[string]string, error) {
+	c, err := loadGCPConfig(name)
+	if err != nil {
+		return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if !c.exists() {
+		return nil, exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found", name))
+	}
+	fields := map[string]string{
+		"extends": c.Extends, "auth": c.Auth, "key_file": c.KeyFile, "copied_key_file": c.CopiedKeyFile,
+		"project_id": c.ProjectID, "region": c.Region, "zone": c.Zone,
+	}
+	if c.Interpolate {
+		fields[resolve.InterpolateField] = "true"
+	}
+	for k, v := range fields {
+		if v == "" {
+			delete(fields, k)
+		}
+	}
+	return fields, nil
+}
+
+func (store) Put(name string, fields map[string]string) error {
+	c := gcpConfig{
+		Extends:       fields["extends"],
+		Interpolate:   fields[resolve.InterpolateField] == "true",
+		Auth:          fields["auth"],
+		KeyFile:       fields["key_file"],
+		CopiedKeyFile: fields["copied_key_file"],
+		ProjectID:     fields["project_id"],
+		Region:        fields["region"],
+		Zone:          fields["zone"],
+	}
+	if c.CopiedKeyFile != "" && c.CopiedKeyFile != getCopiedKeyPath(name) {
+		if err := copyKeyFile(c.CopiedKeyFile, getCopiedKeyPath(name)); err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("failed to copy key file: %w", err))
+		}
+		c.CopiedKeyFile = getCopiedKeyPath(name)
+	}
+	if err := saveGCPConfig(name, c); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return nil
+}
+
+func (store) Delete(name string) error {
+	c, err := loadGCPConfig(name)
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	paths := []string{getConfigPath(name), getEnvPath(name)}
+	if c.CopiedKeyFile == getCopiedKeyPath(name) {
+		paths = append(paths, c.CopiedKeyFile)
+	}
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+	}
+	return nil
+}
+
+func (store) Secret(field string) bool { return field == "key_file" || field == "copied_key_file" }
+
+func (store) Fields() []string {
+	return []string{"extends", resolve.InterpolateField, "auth", "key_file", "copied_key_file", "project_id", "region", "zone"}
+}
+
+func (store) FileFields() []string { return []string{"copied_key_file"} }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -24,18 +25,18 @@ type ServiceAccountKey struct {
 	ClientX509CertURL       string `json:"client_x509_cert_url"`
 }
 
-func testGCPConnection(config gcpConfig) error {
+func testGCPConnection(ctx context.Context, config gcpConfig, out io.Writer) error {
 	switch config.Auth {
 	case "service-account-json":
-		return testServiceAccountAuth(config)
+		return testServiceAccountAuth(ctx, config, out)
 	case "gcloud-adc":
-		return testGcloudADC()
+		return testGcloudADC(ctx, out)
 	default:
 		return fmt.Errorf("unsupported authentication method: %s", config.Auth)
 	}
 }
 
-func testServiceAccountAuth(config gcpConfig) error {
+func testServiceAccountAuth(ctx context.Context, config gcpConfig, out io.Writer) error {
 	// Determine which key file to use
 	keyPath := config.KeyFile
 	if config.CopiedKeyFile != "" {
@@ -73,7 +74,7 @@ func testServiceAccountAuth(config gcpConfig) error {
 	}
 
 	// Test with gcloud auth activate-service-account
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// Create a temporary directory for the key file
@@ -84,7 +85,7 @@ func testServiceAccountAuth(config gcpConfig) error {
 	defer func() {
 		if err := os.RemoveAll(tmpDir); err != nil {
 			// Log error but don't fail the test
-			fmt.Printf("Warning: failed to clean up temp directory %s: %v\n", tmpDir, err)
+			_, _ = fmt.Fprintf(out, "Warning: failed to clean up temp directory %s: %v\n", tmpDir, err)
 		}
 	}()
 
@@ -110,18 +111,18 @@ func testServiceAccountAuth(config gcpConfig) error {
 		return fmt.Errorf("gcloud auth print-access-token returned empty token")
 	}
 
-	fmt.Println("✅ GCP service account authentication is valid")
+	_, _ = fmt.Fprintln(out, "✅ GCP service account authentication is valid")
 	return nil
 }
 
-func testGcloudADC() error {
+func testGcloudADC(ctx context.Context, out io.Writer) error {
 	// Check if gcloud is installed
 	if _, err := exec.LookPath("gcloud"); err != nil {
 		return fmt.Errorf("gcloud CLI is not installed or not in PATH")
 	}
 
 	// Test getting access token with application default credentials
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "gcloud", "auth", "application-default", "print-access-token")
@@ -134,6 +135,6 @@ func testGcloudADC() error {
 		return fmt.Errorf("gcloud auth application-default print-access-token returned empty token")
 	}
 
-	fmt.Println("✅ GCP application default credentials are valid")
+	_, _ = fmt.Fprintln(out, "✅ GCP application default credentials are valid")
 	return nil
 }
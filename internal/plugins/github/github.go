@@ -1,9 +1,13 @@
 package github
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 
 	"github.com/charmbracelet/huh"
@@ -17,6 +21,8 @@ import (
 	"github.com/yonasyiheyis/rdv/internal/logger"
 	"github.com/yonasyiheyis/rdv/internal/plugin"
 	iprint "github.com/yonasyiheyis/rdv/internal/print"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
 	"github.com/yonasyiheyis/rdv/internal/ui"
 )
 
@@ -101,25 +107,39 @@ func (g *ghPlugin) Register(root *cobra.Command) {
 
 	// -------- show ----------------
 	var showName string
+	var showResolved bool
 	showCmd := &cobra.Command{
 		Use:   "show",
 		Short: "Show GitHub profile (redacted)",
-		RunE:  func(cmd *cobra.Command, _ []string) error { return ghShow(showName) },
+		RunE:  func(cmd *cobra.Command, _ []string) error { return ghShow(showName, showResolved) },
 	}
 	showCmd.Flags().StringVarP(&showName, "profile", "p", "default", "profile name")
+	showCmd.Flags().BoolVar(&showResolved, "resolved", false, "show the effective values after extends and ${VAR} / {{ .profile }} interpolation")
 
 	ghCmd.AddCommand(setCmd, modCmd, delCmd, expCmd, listCmd, showCmd)
+	ghCmd.AddCommand(profilestore.Commands(store{}, "GitHub")...)
 	root.AddCommand(ghCmd)
 }
 
-func init() { plugin.Register(&ghPlugin{}) }
+func init() {
+	plugin.Register(&ghPlugin{})
+	plugin.RegisterExporter(plugin.Exporter{Name: "github", Aliases: []string{"gh"}, Export: ExportVars,
+		Secrets: plugin.StaticSecrets("GITHUB_TOKEN"),
+		List:    listSummaries,
+		Test:    ghTestConn,
+		Files:   func() []string { return []string{cfgPath()} },
+		Store:   store{},
+		Related: []string{"GITHUB_TOKEN", "GH_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN", "GH_HOST", "GITHUB_API_BASE", "GITHUB_USER"}})
+}
 
 // ---------- data types ----------
 
 type ghProfile struct {
-	Token   string `yaml:"token"`
-	APIBase string `yaml:"api_base,omitempty"` // e.g. GitHub Enterprise API URL
-	User    string `yaml:"user,omitempty"`     // filled after test-conn
+	Extends     string `yaml:"extends,omitempty"`     // base profile for unset fields
+	Interpolate bool   `yaml:"interpolate,omitempty"` // expand ${VAR} and {{ .profile }} in this profile's values
+	Token       string `yaml:"token"`
+	APIBase     string `yaml:"api_base,omitempty"` // e.g. GitHub Enterprise API URL
+	User        string `yaml:"user,omitempty"`     // filled after test-conn
 }
 
 type ghConfig struct {
@@ -146,15 +166,49 @@ func saveCfg(cfg ghConfig) error {
 	return os.WriteFile(cfgPath(), out, 0o600)
 }
 
+// resolveProfile returns a profile merged over the ones it extends, with
+// ${VAR} and {{ .profile }} interpolated in the values of profiles that opt
+// in.
+func resolveProfile(cfg ghConfig, name string) (ghProfile, error) {
+	chain, err := resolve.Chain(name, func(n string) (string, error) {
+		p, ok := cfg.Profiles[n]
+		if !ok {
+			return "", exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found in %s", n, cfgPath()))
+		}
+		return p.Extends, nil
+	})
+	if err != nil {
+		return ghProfile{}, err
+	}
+	var out ghProfile
+	for i := len(chain) - 1; i >= 0; i-- {
+		p := cfg.Profiles[chain[i]]
+		if !p.Interpolate {
+			p.Token, p.APIBase, p.User = resolve.Literal(p.Token), resolve.Literal(p.APIBase), resolve.Literal(p.User)
+		}
+		if p.Token != "" {
+			out.Token = p.Token
+		}
+		if p.APIBase != "" {
+			out.APIBase = p.APIBase
+		}
+		if p.User != "" {
+			out.User = p.User
+		}
+	}
+	err = resolve.ExpandFields(name, map[string]*string{"token": &out.Token, "api_base": &out.APIBase, "user": &out.User})
+	return out, err
+}
+
 // ExportVars returns GitHub env map for a profile.
 func ExportVars(profile string) (map[string]string, error) {
 	cfg, err := loadCfg()
 	if err != nil {
 		return nil, err
 	}
-	p, ok := cfg.Profiles[profile]
-	if !ok {
-		return nil, exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found in %s", profile, cfgPath()))
+	p, err := resolveProfile(cfg, profile)
+	if err != nil {
+		return nil, err
 	}
 
 	vars := map[string]string{
@@ -205,7 +259,7 @@ func ghSetConfig(profile string, testConn, noPrompt bool, tok, api string) error
 	}
 
 	if testConn {
-		if err := testToken(&p); err != nil {
+		if err := testToken(context.Background(), &p, os.Stdout); err != nil {
 			return exitcodes.Wrap(exitcodes.ConnectionFailed, err)
 		}
 		cfg.Profiles[profile] = p // user may be populated by testToken
@@ -231,7 +285,7 @@ func ghModify(profile string, testConn, noPrompt bool, tok, api string) error {
 		if api != "" {
 			p.APIBase = api
 		}
-		if p.Token == "" {
+		if p.Token == "" && p.Extends == "" {
 			return exitcodes.New(exitcodes.InvalidArgs, "missing values; provide --token or run interactively")
 		}
 	} else {
@@ -252,7 +306,7 @@ func ghModify(profile string, testConn, noPrompt bool, tok, api string) error {
 	}
 
 	if testConn {
-		if err := testToken(&p); err != nil {
+		if err := testToken(context.Background(), &p, os.Stdout); err != nil {
 			return exitcodes.Wrap(exitcodes.ConnectionFailed, err)
 		}
 		cfg.Profiles[profile] = p
@@ -321,6 +375,40 @@ func ghExport(profile string, envPath string) error {
 	return nil
 }
 
+// ghTestConn validates a saved profile's token against the GitHub API.
+func ghTestConn(ctx context.Context, name string, out io.Writer) error {
+	cfg, err := loadCfg()
+	if err != nil {
+		return err
+	}
+	p, err := resolveProfile(cfg, name)
+	if err != nil {
+		return err
+	}
+	if err := testToken(ctx, &p, out); err != nil {
+		return exitcodes.Wrap(exitcodes.ConnectionFailed, err)
+	}
+	return nil
+}
+
+// listSummaries describes each profile for `rdv list --all`.
+func listSummaries() ([]plugin.Summary, error) {
+	cfg, err := loadCfg()
+	if err != nil {
+		return nil, err
+	}
+	var out []plugin.Summary
+	for _, n := range slices.Sorted(maps.Keys(cfg.Profiles)) {
+		p := cfg.Profiles[n]
+		out = append(out, plugin.Summary{
+			Profile:  n,
+			Meta:     map[string]string{"user": p.User, "api_base": p.APIBase, "extends": p.Extends},
+			Modified: plugin.ModTime(cfgPath()),
+		})
+	}
+	return out, nil
+}
+
 func ghList() error {
 	cfg, _ := loadCfg()
 	if len(cfg.Profiles) == 0 {
@@ -344,26 +432,50 @@ func ghList() error {
 	return nil
 }
 
-func ghShow(name string) error {
+func ghShow(name string, resolved bool) error {
 	cfg, _ := loadCfg()
 	p, ok := cfg.Profiles[name]
 	if !ok {
 		return fmt.Errorf("profile %q not found", name)
 	}
+	if resolved {
+		var err error
+		if p, err = resolveProfile(cfg, name); err != nil {
+			return err
+		}
+	}
 
+	token := p.Token
+	if !resolve.IsEnvRef(token) {
+		token = iprint.Redact(token)
+	}
 	payload := map[string]any{
 		"profile":  name,
 		"api_base": p.APIBase,
 		"user":     p.User,
 		// Redact
-		"token": iprint.Redact(p.Token),
+		"token": token,
+	}
+	if p.Extends != "" {
+		payload["extends"] = p.Extends
+	}
+	if p.Interpolate {
+		payload["interpolate"] = true
 	}
 
 	if iprint.JSON {
 		return iprint.Out(payload)
 	}
 	fmt.Printf("profile: %s\n", name)
-	fmt.Printf("  token   : %s\n", iprint.Redact(p.Token))
+	if p.Extends != "" {
+		fmt.Printf("  extends : %s\n", p.Extends)
+	}
+	if p.Interpolate {
+		fmt.Println("  interpolate: true")
+	}
+	if p.Token != "" || p.Extends == "" {
+		fmt.Printf("  token   : %s\n", token)
+	}
 	if p.APIBase != "" {
 		fmt.Printf("  api_base: %s\n", p.APIBase)
 	}
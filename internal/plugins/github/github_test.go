@@ -0,0 +1,55 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+func TestExportVarsExtends(t *testing.T) {
+	t.Setenv("RDV_GH_DIR", t.TempDir())
+	t.Setenv("CI_BOT_TOKEN", "ghp_fromenv")
+	require.NoError(t, saveCfg(ghConfig{Profiles: map[string]ghProfile{
+		"ghe":   {Interpolate: true, Token: "ghp_base", APIBase: "https://ghe.acme.dev/api/v3/", User: "{{ .profile }}"},
+		"bot":   {Extends: "ghe", Interpolate: true, Token: "${CI_BOT_TOKEN}"},
+		"plain": {Token: "ghp_{{x}}${CI_BOT_TOKEN}"},
+		"loop":  {Extends: "loop"},
+		"stray": {Extends: "missing"},
+	}}))
+
+	vars, err := ExportVars("bot")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"GITHUB_TOKEN":    "ghp_fromenv",
+		"GITHUB_API_BASE": "https://ghe.acme.dev/api/v3/",
+		"GITHUB_USER":     "bot",
+	}, vars)
+
+	vars, err = ExportVars("plain")
+	require.NoError(t, err)
+	require.Equal(t, "ghp_{{x}}${CI_BOT_TOKEN}", vars["GITHUB_TOKEN"], "no interpolate: the token is used as stored")
+
+	_, err = ExportVars("loop")
+	require.ErrorContains(t, err, "loop -> loop")
+	_, err = ExportVars("stray")
+	require.Equal(t, exitcodes.ProfileNotFound, exitcodes.FromError(err))
+	_, err = ExportVars("nope")
+	require.Equal(t, exitcodes.ProfileNotFound, exitcodes.FromError(err))
+}
+
+func TestListSummaries(t *testing.T) {
+	t.Setenv("RDV_GH_DIR", t.TempDir())
+	require.NoError(t, saveCfg(ghConfig{Profiles: map[string]ghProfile{
+		"work": {Token: "ghp_secret", User: "octocat"},
+		"bot":  {Extends: "work"},
+	}}))
+
+	sums, err := listSummaries()
+	require.NoError(t, err)
+	require.Len(t, sums, 2)
+	require.Equal(t, "bot", sums[0].Profile)
+	require.Equal(t, "octocat", sums[1].Meta["user"])
+	require.NotContains(t, sums[1].Meta, "token")
+}
@@ -0,0 +1,85 @@
+package github
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
+)
+
+// store exposes github.yaml to copy/rename/diff and backups.
+type store struct{}
+
+func (store) Names() ([]string, error) {
+	cfg, err := loadCfg()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for n := range cfg.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (store) Get(name string) (map[string]string, error) {
+	cfg, err := loadCfg()
+	if err != nil {
+		return nil, err
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return nil, exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q not found in %s", name, cfgPath()))
+	}
+	fields := map[string]string{"extends": p.Extends, "token": p.Token, "api_base": p.APIBase, "user": p.User}
+	if p.Interpolate {
+		fields[resolve.InterpolateField] = "true"
+	}
+	for k, v := range fields {
+		if v == "" {
+			delete(fields, k)
+		}
+	}
+	return fields, nil
+}
+
+func (store) Put(name string, fields map[string]string) error {
+	cfg, err := loadCfg()
+	if err != nil {
+		return err
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]ghProfile{}
+	}
+	cfg.Profiles[name] = ghProfile{
+		Extends:     fields["extends"],
+		Interpolate: fields[resolve.InterpolateField] == "true",
+		Token:       fields["token"],
+		APIBase:     fields["api_base"],
+		User:        fields["user"],
+	}
+	if err := saveCfg(cfg); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return nil
+}
+
+func (store) Delete(name string) error {
+	cfg, err := loadCfg()
+	if err != nil {
+		return err
+	}
+	delete(cfg.Profiles, name)
+	if err := saveCfg(cfg); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return nil
+}
+
+func (store) Secret(field string) bool { return field == "token" }
+
+func (store) Fields() []string {
+	return []string{"extends", resolve.InterpolateField, "token", "api_base", "user"}
+}
@@ -3,6 +3,7 @@ package github
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 
@@ -10,13 +11,12 @@ import (
 	"golang.org/x/oauth2"
 )
 
-func testToken(p *ghProfile) error {
+func testToken(ctx context.Context, p *ghProfile, out io.Writer) error {
 	base := p.APIBase
 	if base == "" {
 		base = "https://api.github.com/"
 	}
 
-	ctx := context.Background()
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: p.Token})
 	tc := oauth2.NewClient(ctx, ts)
 
@@ -43,6 +43,6 @@ func testToken(p *ghProfile) error {
 	}
 
 	p.User = u.GetLogin()
-	fmt.Printf("✅ GitHub token valid for user %s\n", p.User)
+	_, _ = fmt.Fprintf(out, "✅ GitHub token valid for user %s\n", p.User)
 	return nil
 }
@@ -0,0 +1,379 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/logger"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	iprint "github.com/yonasyiheyis/rdv/internal/print"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+)
+
+// ---------- plugin wiring ----------
+
+type k8sPlugin struct{}
+
+func (k *k8sPlugin) Name() string { return "k8s" }
+
+func (k *k8sPlugin) Register(root *cobra.Command) {
+	k8sCmd := engine.Command(driver)
+
+	// -------- list --kubeconfig / --import ----------------
+	var listFrom string
+	var doImport, importEmbed bool
+	for _, c := range k8sCmd.Commands() {
+		if c.Name() != "list" {
+			continue
+		}
+		listProfiles := c.RunE
+		c.Short = "List Kubernetes profiles, or the contexts of a kubeconfig (--kubeconfig, --import)"
+		c.RunE = func(cmd *cobra.Command, args []string) error {
+			if doImport && listFrom == "" {
+				listFrom = defaultKubeconfig()
+			}
+			if listFrom == "" {
+				return listProfiles(cmd, args)
+			}
+			return k8sListContexts(listFrom, doImport, importEmbed)
+		}
+		c.Flags().StringVar(&listFrom, "kubeconfig", "", "list the contexts of this kubeconfig instead of saved profiles")
+		c.Flags().BoolVar(&doImport, "import", false, "save each context as a profile of the same name (existing profiles are kept)")
+		c.Flags().BoolVar(&importEmbed, "embed", false, "with --import, copy connection info instead of referencing the kubeconfig")
+	}
+
+	root.AddCommand(k8sCmd)
+}
+
+// embeddedField holds the single-context kubeconfig a profile saved with
+// --embed owns; it carries what flat fields can't, such as exec blocks and
+// inline certificate data.
+const embeddedField = "embedded_kubeconfig"
+
+// embedFlag is the --embed flag of set-config and modify.
+const embedFlag = "embed"
+
+var driver = &engine.Driver{
+	Name:    "k8s",
+	Aliases: []string{"kube"},
+	Label:   "Kubernetes",
+	Related: []string{"KUBECONFIG"},
+	Path:    cfgPath,
+	Fields: []engine.Field{
+		// Switching between reference and embedded mode drops the other side.
+		{Key: "kubeconfig", Title: "Kubeconfig to reference (leave empty to embed)", Usage: "reference a context in this kubeconfig file", Optional: true, Path: true,
+			Replaces: []string{"server", "certificate_authority", "insecure_skip_tls_verify", "token", "client_certificate", "client_key", embeddedField}},
+		{Key: "context", Title: "Context", Usage: "context name in --kubeconfig (default: its current-context)", Optional: true},
+		{Key: "server", Title: "API server URL (embedded)", Usage: "API server URL (embedded profiles)", Example: "https://127.0.0.1:6443", Optional: true,
+			Replaces: []string{"kubeconfig", "context", embeddedField}},
+		{Key: "certificate_authority", Title: "CA file", Usage: "PEM CA file for the API server", Optional: true, Path: true},
+		{Key: "insecure_skip_tls_verify", Title: "Skip API server certificate verification?", Usage: "skip API server certificate verification", Bool: true},
+		{Key: "token", Title: "Bearer token", Usage: "bearer token", Secret: true, Optional: true},
+		{Key: "client_certificate", Title: "Client certificate file", Usage: "PEM client certificate file", Optional: true, Path: true},
+		{Key: "client_key", Title: "Client key file", Usage: "PEM client key file", Optional: true, Path: true},
+		{Key: "namespace", Title: "Namespace", Usage: "default namespace", Optional: true},
+		{Key: embeddedField, File: true,
+			Replaces: []string{"kubeconfig", "context", "server", "certificate_authority", "insecure_skip_tls_verify", "token", "client_certificate", "client_key"}},
+	},
+	Summary: []string{"context", "server", "namespace"},
+	Help: map[string]string{
+		"":           "Manage Kubernetes cluster context profiles",
+		"set-config": "Save a Kubernetes context (reference a kubeconfig or embed connection info)",
+		"export":     "Write a minimal kubeconfig for a profile and print KUBECONFIG",
+		"test-conn":  "Call /version on the API server using a saved profile",
+	},
+	Validate: func(p engine.Settings) error {
+		kp := profileOf(p)
+		return kp.validate()
+	},
+	Flags: func(fs *pflag.FlagSet) {
+		fs.Bool(embedFlag, false, "copy the referenced context into the profile instead of referencing the kubeconfig")
+	},
+	Export: func(name string, p engine.Settings) (map[string]string, error) {
+		path := kubeconfigPath(name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		if err := writeKubeconfig(path, name, profileOf(p)); err != nil {
+			return nil, err
+		}
+		return map[string]string{"KUBECONFIG": path}, nil
+	},
+	Exec: func(name string, p engine.Settings) (map[string]string, func(), error) {
+		path, cleanup, err := tempKubeconfig(name, profileOf(p))
+		if err != nil {
+			return nil, nil, err
+		}
+		return map[string]string{"KUBECONFIG": path}, cleanup, nil
+	},
+	Test: func(ctx context.Context, p engine.Settings, out io.Writer) error {
+		return testK8s(ctx, profileOf(p), out)
+	},
+	// The exported kubeconfig may hold credentials; remove it too.
+	Remove: func(name string) error {
+		if err := os.Remove(kubeconfigPath(name)); err != nil && !os.IsNotExist(err) {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	driver.Apply = applyEmbed // set here: it refers back to driver
+	plugin.Register(&k8sPlugin{})
+	engine.Register(driver)
+}
+
+// applyEmbed replaces a --kubeconfig reference by a copy the profile owns
+// when --embed is set.
+func applyEmbed(name string, fs *pflag.FlagSet, p engine.Settings) error {
+	if on, _ := fs.GetBool(embedFlag); !on {
+		return nil
+	}
+	if p["kubeconfig"] == "" {
+		return exitcodes.New(exitcodes.InvalidArgs, "--embed requires --kubeconfig")
+	}
+	kubeconfig, err := normalizePath(p["kubeconfig"])
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.InvalidArgs, err)
+	}
+	path, err := embedContext(name, k8sProfile{Kubeconfig: kubeconfig, Context: p["context"], Namespace: p["namespace"]})
+	if err != nil {
+		return err
+	}
+	p["kubeconfig"], p["context"], p[embeddedField] = "", "", path
+	return nil
+}
+
+// ---------- data types ----------
+
+// k8sProfile either references a context in a kubeconfig (Kubeconfig and
+// Context) or embeds the cluster and user directly. Profiles saved with
+// --embed reference the kubeconfig they own.
+type k8sProfile struct {
+	Kubeconfig string
+	Context    string
+	Cluster    kubeCluster
+	User       kubeUser
+	Namespace  string
+}
+
+func profileOf(p engine.Settings) k8sProfile {
+	kp := k8sProfile{
+		Kubeconfig: p["kubeconfig"],
+		Context:    p["context"],
+		Cluster: kubeCluster{
+			Server:                p["server"],
+			CertificateAuthority:  p["certificate_authority"],
+			InsecureSkipTLSVerify: p.Bool("insecure_skip_tls_verify"),
+		},
+		User: kubeUser{
+			Token:             p["token"],
+			ClientCertificate: p["client_certificate"],
+			ClientKey:         p["client_key"],
+		},
+		Namespace: p["namespace"],
+	}
+	if p[embeddedField] != "" {
+		kp = k8sProfile{Kubeconfig: p[embeddedField], Namespace: p["namespace"]}
+	}
+	return kp
+}
+
+// validate checks that p is either a reference or an embedded cluster.
+func (p *k8sProfile) validate() error {
+	switch {
+	case p.Kubeconfig != "" && p.Cluster.Server != "":
+		return exitcodes.New(exitcodes.InvalidArgs, "--kubeconfig and --server are mutually exclusive")
+	case p.Kubeconfig == "" && p.Cluster.Server == "":
+		return exitcodes.New(exitcodes.InvalidArgs, "missing required flags: --server (or --kubeconfig)")
+	case p.Context != "" && p.Kubeconfig == "":
+		return exitcodes.New(exitcodes.InvalidArgs, "--context requires --kubeconfig")
+	case (p.User.ClientCertificate == "") != (p.User.ClientKey == ""):
+		return exitcodes.New(exitcodes.InvalidArgs, "--client-certificate and --client-key go together")
+	}
+	return nil
+}
+
+// normalizePath converts relative paths and ~ to absolute paths
+func normalizePath(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	if strings.HasPrefix(path, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return filepath.Abs(path)
+}
+
+// resolve returns the effective cluster, user and namespace, reading the
+// referenced kubeconfig when needed. The profile's namespace wins over the
+// context's.
+func (p k8sProfile) resolve() (kubeCluster, kubeUser, string, error) {
+	if p.Kubeconfig == "" {
+		return p.Cluster, p.User, p.Namespace, nil
+	}
+	kc, err := loadKubeconfig(p.Kubeconfig)
+	if err != nil {
+		return kubeCluster{}, kubeUser{}, "", err
+	}
+	cluster, user, ns, err := kc.resolve(p.Context, filepath.Dir(p.Kubeconfig))
+	if err != nil {
+		return kubeCluster{}, kubeUser{}, "", fmt.Errorf("%s: %w", p.Kubeconfig, err)
+	}
+	if p.Namespace != "" {
+		ns = p.Namespace
+	}
+	return cluster, user, ns, nil
+}
+
+// embedContext writes ref's context as the kubeconfig profile name owns and
+// returns its path.
+func embedContext(name string, ref k8sProfile) (string, error) {
+	path := driver.FilePath(name, embeddedField)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if err := profilestore.ValidName(name); err != nil {
+		return "", err
+	}
+	if err := writeKubeconfig(path, name, ref); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// lookup resolves a single profile.
+func lookup(profile string) (k8sProfile, error) {
+	p, err := driver.Resolve(profile)
+	if err != nil {
+		return k8sProfile{}, err
+	}
+	return profileOf(p), nil
+}
+
+// writeKubeconfig renders profile's minimal kubeconfig to path (0600).
+func writeKubeconfig(path, profile string, p k8sProfile) error {
+	cluster, user, ns, err := p.resolve()
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	out, err := minimalKubeconfig(profile, cluster, user, ns)
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if err := os.WriteFile(path, out, 0o600); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return nil
+}
+
+// ExportVars writes the profile's minimal kubeconfig under the rdv config
+// dir and returns KUBECONFIG pointing at it.
+func ExportVars(profile string) (map[string]string, error) {
+	return driver.ExportVars(profile)
+}
+
+// TempKubeconfig materializes the profile's minimal kubeconfig in a
+// temporary file for a child process; cleanup deletes it.
+func TempKubeconfig(profile string) (path string, cleanup func(), err error) {
+	p, err := lookup(profile)
+	if err != nil {
+		return "", nil, err
+	}
+	return tempKubeconfig(profile, p)
+}
+
+func tempKubeconfig(profile string, p k8sProfile) (path string, cleanup func(), err error) {
+	f, err := os.CreateTemp("", "rdv-kubeconfig-*.yaml")
+	if err != nil {
+		return "", nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	_ = f.Close()
+	cleanup = func() { _ = os.Remove(f.Name()) }
+	if err := writeKubeconfig(f.Name(), profile, p); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return f.Name(), cleanup, nil
+}
+
+/* ------------ command impls ------------ */
+
+// k8sListContexts lists the contexts in a kubeconfig and, with doImport,
+// saves each one that has no profile of the same name yet.
+func k8sListContexts(path string, doImport, embed bool) error {
+	path, err := normalizePath(path)
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.InvalidArgs, err)
+	}
+	kc, err := loadKubeconfig(path)
+	if err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	store := driver.Store()
+	names, err := store.Names()
+	if err != nil {
+		return err
+	}
+
+	contexts := kc.contextNames()
+	imported := []string{}
+	if doImport {
+		for _, name := range contexts {
+			if slices.Contains(names, name) || profilestore.ValidName(name) != nil {
+				continue
+			}
+			p := map[string]string{"kubeconfig": path, "context": name}
+			if embed {
+				file, err := embedContext(name, k8sProfile{Kubeconfig: path, Context: name})
+				if err != nil {
+					return err
+				}
+				p = map[string]string{embeddedField: file}
+			}
+			if err := store.Put(name, p); err != nil {
+				return err
+			}
+			imported = append(imported, name)
+		}
+		logger.L.Infow("k8s contexts imported", "kubeconfig", path, "count", len(imported))
+	}
+
+	if iprint.JSON {
+		return iprint.Out(map[string]any{"kubeconfig": path, "contexts": contexts, "imported": imported})
+	}
+	if len(contexts) == 0 {
+		fmt.Printf("(no contexts in %s)\n", path)
+		return nil
+	}
+	for _, name := range contexts {
+		mark := ""
+		if slices.Contains(imported, name) {
+			mark = " (imported)"
+		} else if slices.Contains(names, name) {
+			mark = " (profile exists)"
+		} else if doImport {
+			mark = " (not a valid profile name)"
+		}
+		if name == kc.CurrentContext {
+			name += " *"
+		}
+		fmt.Println(name + mark)
+	}
+	return nil
+}
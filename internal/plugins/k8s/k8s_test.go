@@ -0,0 +1,174 @@
+package k8s
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+)
+
+// fakeAPIServer serves /version over TLS to callers presenting token, and
+// writes its CA to dir/ca.crt.
+func fakeAPIServer(t *testing.T, dir, token string) string {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" || r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"gitVersion":"v1.31.0","platform":"linux/amd64"}`))
+	}))
+	t.Cleanup(srv.Close)
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), ca, 0o600))
+	return srv.URL
+}
+
+// writeKubeconfig writes a two-context kubeconfig whose CA is referenced
+// relative to the file.
+func writeTestKubeconfig(t *testing.T, dir, server string) string {
+	t.Helper()
+	kc := `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: c1
+  cluster:
+    server: ` + server + `
+    certificate-authority: ca.crt
+users:
+- name: u1
+  user:
+    token: s3cret
+- name: u2
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: aws
+      args: [eks, get-token]
+contexts:
+- name: dev
+  context: {cluster: c1, user: u1, namespace: apps}
+- name: eks
+  context: {cluster: c1, user: u2}
+`
+	path := filepath.Join(dir, "config")
+	require.NoError(t, os.WriteFile(path, []byte(kc), 0o600))
+	return path
+}
+
+func TestTestK8sReferenceAndEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	kcPath := writeTestKubeconfig(t, dir, fakeAPIServer(t, dir, "s3cret"))
+
+	ref := k8sProfile{Kubeconfig: kcPath}
+	require.NoError(t, ref.validate())
+	require.NoError(t, testK8s(t.Context(), ref, io.Discard))
+
+	t.Setenv("RDV_K8S_DIR", t.TempDir())
+	path, err := embedContext("dev", ref)
+	require.NoError(t, err)
+	emb := k8sProfile{Kubeconfig: path}
+	cluster, user, ns, err := emb.resolve()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "ca.crt"), cluster.CertificateAuthority)
+	require.Equal(t, "apps", ns)
+	require.NoError(t, testK8s(t.Context(), emb, io.Discard))
+
+	emb = k8sProfile{Cluster: cluster, User: user}
+	emb.User.Token = "wrong"
+	require.ErrorContains(t, testK8s(t.Context(), emb, io.Discard), "401")
+}
+
+func TestTempKubeconfig(t *testing.T) {
+	t.Setenv("RDV_K8S_DIR", t.TempDir())
+	dir := t.TempDir()
+	kcPath := writeTestKubeconfig(t, dir, "https://example.invalid:6443")
+	require.NoError(t, engine.SetConfig(driver, "prod", false, true, engine.Settings{"kubeconfig": kcPath, "context": "eks", "namespace": "ops"}))
+
+	path, cleanup, err := TempKubeconfig("prod")
+	require.NoError(t, err)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var kc kubeconfig
+	require.NoError(t, yaml.Unmarshal(b, &kc))
+	require.Equal(t, "prod", kc.CurrentContext)
+	require.Len(t, kc.Contexts, 1)
+	require.Equal(t, "ops", kc.Contexts[0].Context.Namespace)
+	require.Equal(t, "https://example.invalid:6443", kc.Clusters[0].Cluster.Server)
+	require.Equal(t, filepath.Join(dir, "ca.crt"), kc.Clusters[0].Cluster.CertificateAuthority)
+	require.Equal(t, "aws", kc.Users[0].User.Exec["command"])
+
+	st, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), st.Mode().Perm())
+	cleanup()
+	require.NoFileExists(t, path)
+}
+
+func TestListImportContexts(t *testing.T) {
+	t.Setenv("RDV_K8S_DIR", t.TempDir())
+	dir := t.TempDir()
+	kcPath := writeTestKubeconfig(t, dir, "https://example.invalid:6443")
+
+	// an existing profile named like a context is left alone
+	require.NoError(t, engine.SetConfig(driver, "dev", false, true, engine.Settings{"server": "https://other:6443"}))
+	require.NoError(t, k8sListContexts(kcPath, true, false))
+
+	dev, err := lookup("dev")
+	require.NoError(t, err)
+	require.Equal(t, "https://other:6443", dev.Cluster.Server)
+	eks, err := lookup("eks")
+	require.NoError(t, err)
+	require.Equal(t, "eks", eks.Context)
+	require.Equal(t, kcPath, eks.Kubeconfig)
+}
+
+// TestEmbeddedKubeconfigMovesWithProfile covers --embed: the copied context
+// is a file the profile owns, so rename moves it and a switch back to a
+// reference removes it.
+func TestEmbeddedKubeconfigMovesWithProfile(t *testing.T) {
+	t.Setenv("RDV_K8S_DIR", t.TempDir())
+	kcPath := writeTestKubeconfig(t, t.TempDir(), "https://example.invalid:6443")
+
+	fs := pflag.NewFlagSet("set-config", pflag.ContinueOnError)
+	driver.Flags(fs)
+	require.NoError(t, fs.Set("embed", "true"))
+	in := engine.Settings{"kubeconfig": kcPath, "context": "eks"}
+	require.NoError(t, applyEmbed("dev", fs, in))
+	require.NoError(t, engine.SetConfig(driver, "dev", false, true, in))
+
+	s := driver.Store()
+	require.Equal(t, []string{embeddedField}, s.(profilestore.FileStore).FileFields())
+	require.NoError(t, profilestore.Rename(s, "dev", "prod", false))
+	require.NoDirExists(t, driver.Dir("dev"))
+
+	p, err := lookup("prod")
+	require.NoError(t, err)
+	require.Equal(t, driver.FilePath("prod", embeddedField), p.Kubeconfig)
+	_, user, _, err := p.resolve()
+	require.NoError(t, err)
+	require.Equal(t, "aws", user.Exec["command"])
+
+	require.NoError(t, engine.Modify(driver, "prod", false, true, engine.Settings{"kubeconfig": kcPath}))
+	require.NoDirExists(t, driver.Dir("prod"))
+}
+
+func TestValidate(t *testing.T) {
+	require.Error(t, (&k8sProfile{}).validate())
+	require.Error(t, (&k8sProfile{Kubeconfig: "a", Cluster: kubeCluster{Server: "https://x"}}).validate())
+	require.Error(t, (&k8sProfile{Context: "dev", Cluster: kubeCluster{Server: "https://x"}}).validate())
+	require.Error(t, (&k8sProfile{Cluster: kubeCluster{Server: "https://x"}, User: kubeUser{ClientCertificate: "c.pem"}}).validate())
+	require.NoError(t, (&k8sProfile{Cluster: kubeCluster{Server: "https://x"}, User: kubeUser{Token: "t"}}).validate())
+}
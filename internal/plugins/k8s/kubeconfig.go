@@ -0,0 +1,143 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The kubeconfig types below cover the fields rdv reads or writes; exec and
+// auth-provider blocks are carried through verbatim.
+
+type kubeCluster struct {
+	Server                   string `yaml:"server,omitempty"`
+	CertificateAuthority     string `yaml:"certificate-authority,omitempty"`
+	CertificateAuthorityData string `yaml:"certificate-authority-data,omitempty"`
+	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify,omitempty"`
+	TLSServerName            string `yaml:"tls-server-name,omitempty"`
+}
+
+type kubeUser struct {
+	Token                 string         `yaml:"token,omitempty"`
+	TokenFile             string         `yaml:"tokenFile,omitempty"`
+	ClientCertificate     string         `yaml:"client-certificate,omitempty"`
+	ClientCertificateData string         `yaml:"client-certificate-data,omitempty"`
+	ClientKey             string         `yaml:"client-key,omitempty"`
+	ClientKeyData         string         `yaml:"client-key-data,omitempty"`
+	Username              string         `yaml:"username,omitempty"`
+	Password              string         `yaml:"password,omitempty"`
+	Exec                  map[string]any `yaml:"exec,omitempty"`
+	AuthProvider          map[string]any `yaml:"auth-provider,omitempty"`
+}
+
+type kubeContext struct {
+	Cluster   string `yaml:"cluster"`
+	User      string `yaml:"user"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+type kubeconfig struct {
+	APIVersion     string `yaml:"apiVersion"`
+	Kind           string `yaml:"kind"`
+	CurrentContext string `yaml:"current-context,omitempty"`
+	Clusters       []struct {
+		Name    string      `yaml:"name"`
+		Cluster kubeCluster `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string   `yaml:"name"`
+		User kubeUser `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string      `yaml:"name"`
+		Context kubeContext `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+func loadKubeconfig(path string) (*kubeconfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var kc kubeconfig
+	if err := yaml.Unmarshal(b, &kc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &kc, nil
+}
+
+// contextNames lists the contexts defined in kc, in file order.
+func (kc *kubeconfig) contextNames() []string {
+	names := make([]string, 0, len(kc.Contexts))
+	for _, c := range kc.Contexts {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// resolve returns the cluster, user and namespace behind context name
+// (current-context when empty). Relative file references are made absolute
+// against dir, the kubeconfig's directory, so they survive being copied.
+func (kc *kubeconfig) resolve(name, dir string) (kubeCluster, kubeUser, string, error) {
+	if name == "" {
+		name = kc.CurrentContext
+	}
+	if name == "" {
+		return kubeCluster{}, kubeUser{}, "", fmt.Errorf("no context given and no current-context set")
+	}
+
+	var ctx *kubeContext
+	for i := range kc.Contexts {
+		if kc.Contexts[i].Name == name {
+			ctx = &kc.Contexts[i].Context
+		}
+	}
+	if ctx == nil {
+		return kubeCluster{}, kubeUser{}, "", fmt.Errorf("context %q not found", name)
+	}
+
+	var cluster kubeCluster
+	var user kubeUser
+	found := false
+	for _, c := range kc.Clusters {
+		if c.Name == ctx.Cluster {
+			cluster, found = c.Cluster, true
+		}
+	}
+	if !found {
+		return kubeCluster{}, kubeUser{}, "", fmt.Errorf("context %q: cluster %q not found", name, ctx.Cluster)
+	}
+	for _, u := range kc.Users {
+		if u.Name == ctx.User {
+			user = u.User
+		}
+	}
+
+	abs := func(p *string) {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	abs(&cluster.CertificateAuthority)
+	abs(&user.TokenFile)
+	abs(&user.ClientCertificate)
+	abs(&user.ClientKey)
+	return cluster, user, ctx.Namespace, nil
+}
+
+// minimalKubeconfig renders a single-context kubeconfig named after profile.
+func minimalKubeconfig(profile string, cluster kubeCluster, user kubeUser, namespace string) ([]byte, error) {
+	kc := map[string]any{
+		"apiVersion":      "v1",
+		"kind":            "Config",
+		"current-context": profile,
+		"clusters":        []any{map[string]any{"name": profile, "cluster": cluster}},
+		"users":           []any{map[string]any{"name": profile, "user": user}},
+		"contexts": []any{map[string]any{"name": profile, "context": kubeContext{
+			Cluster: profile, User: profile, Namespace: namespace,
+		}}},
+	}
+	return yaml.Marshal(kc)
+}
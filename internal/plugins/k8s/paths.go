@@ -0,0 +1,35 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// baseDir returns ~/.config/rdv (or override via RDV_K8S_DIR for tests)
+func baseDir() string {
+	if v := os.Getenv("RDV_K8S_DIR"); v != "" {
+		return v
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv")
+}
+
+// cfgPath returns the profile store, ~/.config/rdv/k8s.yaml
+func cfgPath() string {
+	return filepath.Join(baseDir(), "k8s.yaml")
+}
+
+// kubeconfigPath is the persistent kubeconfig written by `rdv k8s export`,
+// ~/.config/rdv/k8s/<profile>.kubeconfig
+func kubeconfigPath(profile string) string {
+	return filepath.Join(baseDir(), "k8s", profile+".kubeconfig")
+}
+
+// defaultKubeconfig is the first entry of $KUBECONFIG, else ~/.kube/config.
+func defaultKubeconfig() string {
+	if v := os.Getenv("KUBECONFIG"); v != "" {
+		return filepath.SplitList(v)[0]
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kube", "config")
+}
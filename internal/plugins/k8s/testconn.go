@@ -0,0 +1,183 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// pemData returns inline base64 data when set, else the file's contents.
+func pemData(data, file string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	return nil, nil
+}
+
+// transport builds the TLS client for cluster/user.
+func transport(cluster kubeCluster, user kubeUser) (*http.Transport, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: cluster.InsecureSkipTLSVerify, //nolint:gosec // mirrors kubeconfig insecure-skip-tls-verify
+		ServerName:         cluster.TLSServerName,
+	}
+	ca, err := pemData(cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate authority: %w", err)
+	}
+	if ca != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in certificate authority")
+		}
+		cfg.RootCAs = pool
+	}
+
+	cert, err := pemData(user.ClientCertificateData, user.ClientCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate: %w", err)
+	}
+	key, err := pemData(user.ClientKeyData, user.ClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client key: %w", err)
+	}
+	if cert != nil {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	return &http.Transport{TLSClientConfig: cfg}, nil
+}
+
+// bearerToken returns the user's static token, token file, or the token
+// printed by an exec credential plugin (EKS, GKE, ...).
+func bearerToken(ctx context.Context, user kubeUser) (string, error) {
+	switch {
+	case user.Token != "":
+		return user.Token, nil
+	case user.TokenFile != "":
+		b, err := os.ReadFile(user.TokenFile)
+		return strings.TrimSpace(string(b)), err
+	case user.Exec != nil:
+		return execToken(ctx, user.Exec)
+	case user.AuthProvider != nil:
+		return "", fmt.Errorf("auth-provider credentials are not supported; use an exec plugin or token")
+	}
+	return "", nil
+}
+
+// execToken runs a client.authentication.k8s.io exec plugin and returns
+// status.token from its ExecCredential output.
+func execToken(ctx context.Context, spec map[string]any) (string, error) {
+	command, _ := spec["command"].(string)
+	if command == "" {
+		return "", fmt.Errorf("exec credential plugin has no command")
+	}
+	var args []string
+	if list, ok := spec["args"].([]any); ok {
+		for _, a := range list {
+			args = append(args, fmt.Sprint(a))
+		}
+	}
+	apiVersion, _ := spec["apiVersion"].(string)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Env = os.Environ()
+	if list, ok := spec["env"].([]any); ok {
+		for _, e := range list {
+			if m, ok := e.(map[string]any); ok {
+				cmd.Env = append(cmd.Env, fmt.Sprintf("%v=%v", m["name"], m["value"]))
+			}
+		}
+	}
+	info, _ := json.Marshal(map[string]any{
+		"apiVersion": apiVersion,
+		"kind":       "ExecCredential",
+		"spec":       map[string]any{"interactive": false},
+	})
+	cmd.Env = append(cmd.Env, "KUBERNETES_EXEC_INFO="+string(info))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("exec credential plugin %s failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	var cred struct {
+		Status struct {
+			Token string `json:"token"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(out, &cred); err != nil {
+		return "", fmt.Errorf("exec credential plugin %s: %w", command, err)
+	}
+	return cred.Status.Token, nil
+}
+
+// testK8s calls GET /version on the API server with the profile's
+// credentials and reports the server's gitVersion.
+func testK8s(ctx context.Context, p k8sProfile, out io.Writer) error {
+	cluster, user, _, err := p.resolve()
+	if err != nil {
+		return err
+	}
+	if cluster.Server == "" {
+		return fmt.Errorf("no API server configured")
+	}
+	tr, err := transport(cluster, user)
+	if err != nil {
+		return err
+	}
+	token, err := bearerToken(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	u := strings.TrimRight(cluster.Server, "/") + "/version"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	switch {
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case user.Username != "":
+		req.SetBasicAuth(user.Username, user.Password)
+	}
+
+	resp, err := (&http.Client{Transport: tr}).Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s failed: %w", u, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	var v struct {
+		GitVersion string `json:"gitVersion"`
+		Platform   string `json:"platform"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return fmt.Errorf("GET %s: %w", u, err)
+	}
+	_, _ = fmt.Fprintf(out, "✅ Kubernetes API %s reachable (%s %s)\n", cluster.Server, v.GitVersion, v.Platform)
+	return nil
+}
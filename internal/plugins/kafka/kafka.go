@@ -0,0 +1,194 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+// ---------- plugin wiring ----------
+
+type kafkaPlugin struct{}
+
+func (k *kafkaPlugin) Name() string { return "kafka" }
+
+func (k *kafkaPlugin) Register(root *cobra.Command) {
+	root.AddCommand(engine.Command(driver))
+}
+
+var driver = &engine.Driver{
+	Name:    "kafka",
+	Label:   "Kafka",
+	Related: []string{"KAFKA_*", "SCHEMA_REGISTRY_*"},
+	Path:    cfgPath,
+	Fields: []engine.Field{
+		{Key: "bootstrap_servers", Title: "Bootstrap servers (comma-separated)", Usage: "broker host:port list (comma-separated)", Example: "localhost:9092", List: true},
+		{Key: "sasl_mechanism", Title: "SASL mechanism", Usage: "PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512 (empty: no SASL)", Example: "SCRAM-SHA-512", Optional: true},
+		{Key: "username", Title: "SASL username", Usage: "SASL username", Optional: true},
+		{Key: "password", Title: "SASL password", Usage: "SASL password", Secret: true, Optional: true},
+		{Key: "tls", Title: "Use TLS?", Usage: "connect to brokers with TLS", Bool: true},
+		{Key: "tls_ca_file", Title: "TLS CA file", Usage: "PEM CA bundle to verify the brokers", Optional: true, Path: true},
+		{Key: "tls_insecure", Title: "Skip TLS certificate verification?", Usage: "skip TLS certificate verification", Bool: true},
+		{Key: "schema_registry_url", Title: "Schema Registry URL", Usage: "Schema Registry URL", Optional: true},
+		{Key: "schema_registry_username", Title: "Schema Registry username", Usage: "Schema Registry basic-auth username / API key", Optional: true},
+		{Key: "schema_registry_password", Title: "Schema Registry password", Usage: "Schema Registry basic-auth password / API secret", Secret: true, Optional: true},
+	},
+	Summary: []string{"bootstrap_servers", "username"},
+	Help: map[string]string{
+		"":          "Manage Kafka cluster credentials",
+		"export":    "Print KAFKA_* exports and write a librdkafka properties file",
+		"test-conn": "Authenticate and request cluster metadata using a saved Kafka profile",
+	},
+	Secrets: []string{"KAFKA_SASL_PASSWORD", "SCHEMA_REGISTRY_PASSWORD", "SCHEMA_REGISTRY_BASIC_AUTH_USER_INFO"},
+	Validate: func(p engine.Settings) error {
+		kp := profileOf(p)
+		return kp.validate()
+	},
+	// Store the canonical spelling of --sasl-mechanism.
+	Apply: func(_ string, _ *pflag.FlagSet, p engine.Settings) error {
+		if mech, ok := mechanisms[strings.ToUpper(strings.TrimSpace(p["sasl_mechanism"]))]; ok && mech != "" {
+			p["sasl_mechanism"] = mech
+		}
+		return nil
+	},
+	Export: func(name string, p engine.Settings) (map[string]string, error) {
+		kp := profileOf(p)
+		vars := exportVars(kp)
+		path, err := writeProperties(name, kp)
+		if err != nil {
+			return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		vars["KAFKA_PROPERTIES_FILE"] = path
+		return vars, nil
+	},
+	Test: func(ctx context.Context, p engine.Settings, out io.Writer) error {
+		return testKafka(ctx, profileOf(p), out)
+	},
+	// The generated properties file holds the password; remove it too.
+	Remove: func(name string) error {
+		if err := os.Remove(propertiesPath(name)); err != nil && !os.IsNotExist(err) {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	plugin.Register(&kafkaPlugin{})
+	engine.Register(driver)
+}
+
+// ---------- data types ----------
+
+// kafkaProfile is a resolved profile in the shape the exporter and tester
+// work with.
+type kafkaProfile struct {
+	Bootstrap              []string
+	SASLMechanism          string
+	Username               string
+	Password               string
+	TLS                    bool
+	TLSCAFile              string
+	TLSInsecure            bool
+	SchemaRegistryURL      string
+	SchemaRegistryUsername string
+	SchemaRegistryPassword string
+}
+
+func profileOf(p engine.Settings) kafkaProfile {
+	return kafkaProfile{
+		Bootstrap:              p.Values("bootstrap_servers"),
+		SASLMechanism:          p["sasl_mechanism"],
+		Username:               p["username"],
+		Password:               p["password"],
+		TLS:                    p.Bool("tls"),
+		TLSCAFile:              p["tls_ca_file"],
+		TLSInsecure:            p.Bool("tls_insecure"),
+		SchemaRegistryURL:      p["schema_registry_url"],
+		SchemaRegistryUsername: p["schema_registry_username"],
+		SchemaRegistryPassword: p["schema_registry_password"],
+	}
+}
+
+// mechanisms maps accepted spellings to the canonical SASL mechanism name.
+var mechanisms = map[string]string{
+	"":              "",
+	"PLAIN":         "PLAIN",
+	"SCRAM-256":     "SCRAM-SHA-256",
+	"SCRAM-SHA-256": "SCRAM-SHA-256",
+	"SCRAM-512":     "SCRAM-SHA-512",
+	"SCRAM-SHA-512": "SCRAM-SHA-512",
+}
+
+// validate canonicalises the SASL mechanism and checks required fields.
+func (p *kafkaProfile) validate() error {
+	mech, ok := mechanisms[strings.ToUpper(strings.TrimSpace(p.SASLMechanism))]
+	if !ok {
+		return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("unsupported --sasl-mechanism %q (want PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512)", p.SASLMechanism))
+	}
+	p.SASLMechanism = mech
+
+	switch {
+	case len(p.Bootstrap) == 0:
+		return exitcodes.New(exitcodes.InvalidArgs, "missing required flags: --bootstrap-servers")
+	case p.SASLMechanism != "" && (p.Username == "" || p.Password == ""):
+		return exitcodes.New(exitcodes.InvalidArgs, "--sasl-mechanism requires --username and --password")
+	case p.SASLMechanism == "" && p.Username != "":
+		return exitcodes.New(exitcodes.InvalidArgs, "--username requires --sasl-mechanism")
+	}
+	return nil
+}
+
+// securityProtocol is the Kafka client security.protocol for p.
+func (p kafkaProfile) securityProtocol() string {
+	switch {
+	case p.SASLMechanism != "" && p.TLS:
+		return "SASL_SSL"
+	case p.SASLMechanism != "":
+		return "SASL_PLAINTEXT"
+	case p.TLS:
+		return "SSL"
+	}
+	return "PLAINTEXT"
+}
+
+// ExportVars returns the KAFKA_* env map for a profile. It also (re)writes
+// the profile's librdkafka properties file and points
+// KAFKA_PROPERTIES_FILE at it.
+func ExportVars(profile string) (map[string]string, error) {
+	return driver.ExportVars(profile)
+}
+
+func exportVars(p kafkaProfile) map[string]string {
+	vars := map[string]string{
+		"KAFKA_BOOTSTRAP_SERVERS": strings.Join(p.Bootstrap, ","),
+		"KAFKA_SECURITY_PROTOCOL": p.securityProtocol(),
+	}
+	if p.SASLMechanism != "" {
+		vars["KAFKA_SASL_MECHANISM"] = p.SASLMechanism
+		vars["KAFKA_SASL_USERNAME"] = p.Username
+		vars["KAFKA_SASL_PASSWORD"] = p.Password
+	}
+	if p.TLSCAFile != "" {
+		vars["KAFKA_SSL_CA_LOCATION"] = p.TLSCAFile
+	}
+	if p.SchemaRegistryURL != "" {
+		vars["SCHEMA_REGISTRY_URL"] = p.SchemaRegistryURL
+	}
+	if p.SchemaRegistryUsername != "" {
+		vars["SCHEMA_REGISTRY_USERNAME"] = p.SchemaRegistryUsername
+		vars["SCHEMA_REGISTRY_PASSWORD"] = p.SchemaRegistryPassword
+		// Confluent clients take "key:secret" in one variable.
+		vars["SCHEMA_REGISTRY_BASIC_AUTH_USER_INFO"] = p.SchemaRegistryUsername + ":" + p.SchemaRegistryPassword
+	}
+	return vars
+}
@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+)
+
+func TestTestKafkaSASLPlain(t *testing.T) {
+	c, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.EnableSASL(),
+		kfake.Superuser("PLAIN", "app", "s3cret"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	p := kafkaProfile{Bootstrap: c.ListenAddrs(), SASLMechanism: "PLAIN", Username: "app", Password: "s3cret"}
+	require.NoError(t, testKafka(t.Context(), p, io.Discard))
+
+	p.Password = "wrong"
+	require.Error(t, testKafka(t.Context(), p, io.Discard))
+}
+
+func TestValidateCanonicalisesMechanism(t *testing.T) {
+	p := kafkaProfile{Bootstrap: []string{"b:9092"}, SASLMechanism: "scram-512", Username: "u", Password: "p"}
+	require.NoError(t, p.validate())
+	require.Equal(t, "SCRAM-SHA-512", p.SASLMechanism)
+
+	require.Error(t, (&kafkaProfile{Bootstrap: []string{"b:9092"}, SASLMechanism: "GSSAPI"}).validate())
+	require.Error(t, (&kafkaProfile{Bootstrap: []string{"b:9092"}, SASLMechanism: "PLAIN"}).validate())
+	require.Error(t, (&kafkaProfile{}).validate())
+}
+
+func TestExportVarsWritesProperties(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("RDV_KAFKA_DIR", dir)
+
+	in := engine.Settings{
+		"bootstrap_servers":        "b1:9093,b2:9093",
+		"sasl_mechanism":           "SCRAM-SHA-256",
+		"username":                 "app",
+		"password":                 "s3cret",
+		"tls":                      "true",
+		"schema_registry_url":      "https://sr.local",
+		"schema_registry_username": "key",
+		"schema_registry_password": "secret",
+	}
+	require.NoError(t, engine.SetConfig(driver, "dev", false, true, in))
+
+	vars, err := ExportVars("dev")
+	require.NoError(t, err)
+	require.Equal(t, "b1:9093,b2:9093", vars["KAFKA_BOOTSTRAP_SERVERS"])
+	require.Equal(t, "SASL_SSL", vars["KAFKA_SECURITY_PROTOCOL"])
+	require.Equal(t, "SCRAM-SHA-256", vars["KAFKA_SASL_MECHANISM"])
+	require.Equal(t, "s3cret", vars["KAFKA_SASL_PASSWORD"])
+	require.Equal(t, "key:secret", vars["SCHEMA_REGISTRY_BASIC_AUTH_USER_INFO"])
+
+	path := vars["KAFKA_PROPERTIES_FILE"]
+	require.True(t, strings.HasPrefix(path, dir))
+	st, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), st.Mode().Perm())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "bootstrap.servers=b1:9093,b2:9093\n")
+	require.Contains(t, string(b), "security.protocol=SASL_SSL\n")
+	require.Contains(t, string(b), "sasl.mechanisms=SCRAM-SHA-256\n")
+	require.Contains(t, string(b), "sasl.password=s3cret\n")
+}
+
+func TestRenameRemovesProperties(t *testing.T) {
+	t.Setenv("RDV_KAFKA_DIR", t.TempDir())
+	require.NoError(t, engine.SetConfig(driver, "dev", false, true, engine.Settings{"bootstrap_servers": "b:9092"}))
+	_, err := ExportVars("dev")
+	require.NoError(t, err)
+	require.FileExists(t, propertiesPath("dev"))
+
+	e, _ := plugin.LookupExporter("kafka")
+	require.NoError(t, profilestore.Rename(e.Store, "dev", "prod", false))
+	require.NoFileExists(t, propertiesPath("dev"), "the old profile's password file goes with it")
+
+	vars, err := ExportVars("prod")
+	require.NoError(t, err)
+	require.Equal(t, "b:9092", vars["KAFKA_BOOTSTRAP_SERVERS"])
+}
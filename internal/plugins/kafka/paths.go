@@ -0,0 +1,26 @@
+package kafka
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// baseDir returns ~/.config/rdv (or override via RDV_KAFKA_DIR for tests)
+func baseDir() string {
+	if v := os.Getenv("RDV_KAFKA_DIR"); v != "" {
+		return v
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv")
+}
+
+// cfgPath returns the profile store, ~/.config/rdv/kafka.yaml
+func cfgPath() string {
+	return filepath.Join(baseDir(), "kafka.yaml")
+}
+
+// propertiesPath returns the generated librdkafka file for a profile,
+// ~/.config/rdv/kafka/<profile>.properties
+func propertiesPath(profile string) string {
+	return filepath.Join(baseDir(), "kafka", profile+".properties")
+}
@@ -0,0 +1,41 @@
+package kafka
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// properties renders p as a librdkafka / Java-client style properties file.
+func properties(p kafkaProfile) string {
+	var b strings.Builder
+	b.WriteString("# generated by rdv; do not edit\n")
+	fmt.Fprintf(&b, "bootstrap.servers=%s\n", strings.Join(p.Bootstrap, ","))
+	fmt.Fprintf(&b, "security.protocol=%s\n", p.securityProtocol())
+	if p.SASLMechanism != "" {
+		fmt.Fprintf(&b, "sasl.mechanisms=%s\n", p.SASLMechanism)
+		fmt.Fprintf(&b, "sasl.username=%s\n", p.Username)
+		fmt.Fprintf(&b, "sasl.password=%s\n", p.Password)
+	}
+	if p.TLSCAFile != "" {
+		fmt.Fprintf(&b, "ssl.ca.location=%s\n", p.TLSCAFile)
+	}
+	if p.TLSInsecure {
+		b.WriteString("enable.ssl.certificate.verification=false\n")
+	}
+	return b.String()
+}
+
+// writeProperties (re)generates the properties file for profile with 0600
+// permissions, since it contains the SASL password.
+func writeProperties(profile string, p kafkaProfile) (string, error) {
+	path := propertiesPath(profile)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(properties(p)), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
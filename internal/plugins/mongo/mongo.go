@@ -0,0 +1,181 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+// ---------- plugin wiring ----------
+
+type mongoPlugin struct{}
+
+func (m *mongoPlugin) Name() string { return "mongo" }
+
+func (m *mongoPlugin) Register(root *cobra.Command) {
+	root.AddCommand(engine.Command(driver))
+}
+
+var driver = &engine.Driver{
+	Name:    "mongo",
+	Aliases: []string{"mongodb"},
+	Label:   "MongoDB",
+	Related: []string{"MONGODB_*", "MONGO_URL", "MONGO_URI"},
+	Path:    cfgPath,
+	Fields: []engine.Field{
+		{Key: "hosts", Title: "Hosts (comma-separated host[:port])", Usage: "host[:port] list (comma-separated); a single hostname with --srv", Example: "localhost:27017", List: true},
+		{Key: "srv", Title: "Use mongodb+srv (DNS seed list)?", Usage: "use a mongodb+srv:// seed list", Bool: true},
+		{Key: "username", Title: "Username", Usage: "username", Optional: true},
+		{Key: "password", Title: "Password", Usage: "password", Secret: true, Optional: true},
+		{Key: "database", Title: "Default database", Usage: "default database", Optional: true},
+		{Key: "auth_source", Title: "Auth source", Usage: "authentication database (e.g. admin)", Example: "admin", Optional: true},
+		{Key: "auth_mechanism", Title: "Auth mechanism", Usage: "SCRAM-SHA-256, SCRAM-SHA-1, MONGODB-X509, MONGODB-AWS or PLAIN", Example: "SCRAM-SHA-256", Optional: true},
+		{Key: "replica_set", Title: "Replica set", Usage: "replica set name", Optional: true},
+		{Key: "tls", Title: "Use TLS?", Usage: "connect with TLS", Bool: true},
+		{Key: "tls_ca_file", Title: "TLS CA file", Usage: "PEM CA bundle to verify the server", Optional: true, Path: true},
+		{Key: "options", Title: "Extra URI options", Usage: "extra URI options (e.g. retryWrites=true&w=majority)", Example: "retryWrites=true&w=majority", Optional: true},
+	},
+	Summary: []string{"hosts", "username", "database"},
+	Help: map[string]string{
+		"":          "Manage MongoDB connection profiles",
+		"export":    "Print MONGODB_URI export for a profile",
+		"test-conn": "Ping MongoDB using a saved profile",
+	},
+	Secrets:  []string{"MONGODB_URI"},
+	Validate: func(p engine.Settings) error { return profileOf(p).validate() },
+	Env:      func(p engine.Settings) map[string]string { return exportVars(profileOf(p)) },
+	Test: func(ctx context.Context, p engine.Settings, out io.Writer) error {
+		return testMongo(ctx, profileOf(p), out)
+	},
+}
+
+func init() {
+	plugin.Register(&mongoPlugin{})
+	engine.Register(driver)
+}
+
+// ---------- data types ----------
+
+// mongoProfile is a resolved profile in the shape buildURI and the tester
+// work with.
+type mongoProfile struct {
+	Hosts         []string
+	SRV           bool
+	Username      string
+	Password      string
+	Database      string
+	AuthSource    string
+	AuthMechanism string
+	ReplicaSet    string
+	TLS           bool
+	TLSCAFile     string
+	Options       string
+}
+
+func profileOf(p engine.Settings) mongoProfile {
+	return mongoProfile{
+		Hosts:         p.Values("hosts"),
+		SRV:           p.Bool("srv"),
+		Username:      p["username"],
+		Password:      p["password"],
+		Database:      p["database"],
+		AuthSource:    p["auth_source"],
+		AuthMechanism: p["auth_mechanism"],
+		ReplicaSet:    p["replica_set"],
+		TLS:           p.Bool("tls"),
+		TLSCAFile:     p["tls_ca_file"],
+		Options:       p["options"],
+	}
+}
+
+var mechanisms = []string{"SCRAM-SHA-256", "SCRAM-SHA-1", "MONGODB-X509", "MONGODB-AWS", "PLAIN"}
+
+func (p mongoProfile) validate() error {
+	if len(p.Hosts) == 0 {
+		return exitcodes.New(exitcodes.InvalidArgs, "missing required flag: --hosts")
+	}
+	if p.SRV && (len(p.Hosts) != 1 || strings.Contains(p.Hosts[0], ":")) {
+		return exitcodes.New(exitcodes.InvalidArgs, "--srv takes exactly one hostname without a port")
+	}
+	if p.AuthMechanism != "" {
+		ok := false
+		for _, m := range mechanisms {
+			ok = ok || strings.EqualFold(m, p.AuthMechanism)
+		}
+		if !ok {
+			return exitcodes.New(exitcodes.InvalidArgs, "auth mechanism must be one of "+strings.Join(mechanisms, ", "))
+		}
+	}
+	if _, err := url.ParseQuery(p.Options); err != nil {
+		return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid --options: %v", err))
+	}
+	return nil
+}
+
+// buildURI renders the connection string, e.g.
+// mongodb://user:pass@h1:27017,h2:27017/app?authSource=admin&replicaSet=rs0
+func buildURI(p mongoProfile) string {
+	u := url.URL{
+		Scheme: "mongodb",
+		Host:   strings.Join(p.Hosts, ","),
+		Path:   "/" + p.Database,
+	}
+	if p.SRV {
+		u.Scheme = "mongodb+srv"
+	}
+	switch {
+	case p.Password != "":
+		u.User = url.UserPassword(p.Username, p.Password)
+	case p.Username != "":
+		u.User = url.User(p.Username)
+	}
+
+	q := url.Values{}
+	if p.AuthSource != "" {
+		q.Set("authSource", p.AuthSource)
+	}
+	if p.AuthMechanism != "" {
+		q.Set("authMechanism", strings.ToUpper(p.AuthMechanism))
+	}
+	if p.ReplicaSet != "" {
+		q.Set("replicaSet", p.ReplicaSet)
+	}
+	if p.TLS {
+		q.Set("tls", "true")
+	}
+	if p.TLSCAFile != "" {
+		q.Set("tlsCAFile", p.TLSCAFile)
+	}
+	query := q.Encode()
+	if p.Options != "" {
+		if query != "" {
+			query += "&"
+		}
+		query += p.Options
+	}
+	u.RawQuery = query
+	return u.String()
+}
+
+// ExportVars returns the MONGODB_* env map for a profile.
+func ExportVars(profile string) (map[string]string, error) {
+	return driver.ExportVars(profile)
+}
+
+func exportVars(p mongoProfile) map[string]string {
+	vars := map[string]string{
+		"MONGODB_URI": buildURI(p),
+	}
+	if p.Database != "" {
+		vars["MONGODB_DATABASE"] = p.Database
+	}
+	return vars
+}
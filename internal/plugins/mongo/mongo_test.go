@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+)
+
+func TestBuildURI(t *testing.T) {
+	tests := []struct {
+		name     string
+		profile  mongoProfile
+		expected string
+	}{
+		{
+			name:     "single host, no auth",
+			profile:  mongoProfile{Hosts: []string{"localhost:27017"}},
+			expected: "mongodb://localhost:27017/",
+		},
+		{
+			name: "replica set with auth",
+			profile: mongoProfile{
+				Hosts:    []string{"h1:27017", "h2:27017"},
+				Username: "app", Password: "p@ss", Database: "shop",
+				AuthSource: "admin", AuthMechanism: "scram-sha-256", ReplicaSet: "rs0",
+				TLS: true, TLSCAFile: "/etc/ca.pem", Options: "retryWrites=true&w=majority",
+			},
+			expected: "mongodb://app:p%40ss@h1:27017,h2:27017/shop?authMechanism=SCRAM-SHA-256&authSource=admin&replicaSet=rs0&tls=true&tlsCAFile=%2Fetc%2Fca.pem&retryWrites=true&w=majority",
+		},
+		{
+			name:     "srv",
+			profile:  mongoProfile{Hosts: []string{"cluster0.example.net"}, SRV: true, Username: "u", Password: "p"},
+			expected: "mongodb+srv://u:p@cluster0.example.net/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, buildURI(tt.profile))
+			// SRV URIs trigger a DNS lookup and tlsCAFile is read when parsed
+			if !tt.profile.SRV && tt.profile.TLSCAFile == "" {
+				require.NoError(t, options.Client().ApplyURI(tt.expected).Validate())
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	require.Error(t, mongoProfile{}.validate())
+	require.Error(t, mongoProfile{Hosts: []string{"a", "b"}, SRV: true}.validate())
+	require.Error(t, mongoProfile{Hosts: []string{"a:27017"}, SRV: true}.validate())
+	require.Error(t, mongoProfile{Hosts: []string{"a"}, AuthMechanism: "KERBEROS-ISH"}.validate())
+	require.NoError(t, mongoProfile{Hosts: []string{"a"}, AuthMechanism: "MONGODB-X509"}.validate())
+}
+
+func TestExportVars(t *testing.T) {
+	t.Setenv("RDV_MONGO_DIR", t.TempDir())
+
+	require.NoError(t, engine.SetConfig(driver, "dev", false, true, engine.Settings{"hosts": "localhost", "database": "app"}))
+
+	vars, err := ExportVars("dev")
+	require.NoError(t, err)
+	require.Equal(t, "mongodb://localhost/app", vars["MONGODB_URI"])
+	require.Equal(t, "app", vars["MONGODB_DATABASE"])
+
+	_, err = ExportVars("nope")
+	require.Error(t, err)
+}
+
+func TestCopiedProfileKeepsHosts(t *testing.T) {
+	t.Setenv("RDV_MONGO_DIR", t.TempDir())
+
+	require.NoError(t, engine.SetConfig(driver, "dev", false, true, engine.Settings{"hosts": "h1:27017,h2:27017", "replica_set": "rs0"}))
+	e, _ := plugin.LookupExporter("mongodb")
+	require.NoError(t, profilestore.Copy(e.Store, "dev", "ci", false))
+
+	vars, err := ExportVars("ci")
+	require.NoError(t, err)
+	require.Equal(t, "mongodb://h1:27017,h2:27017/?replicaSet=rs0", vars["MONGODB_URI"])
+}
@@ -0,0 +1,15 @@
+package mongo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// cfgPath returns ~/.config/rdv/mongo.yaml (or override via RDV_MONGO_DIR for tests)
+func cfgPath() string {
+	if v := os.Getenv("RDV_MONGO_DIR"); v != "" {
+		return filepath.Join(v, "mongo.yaml")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv", "mongo.yaml")
+}
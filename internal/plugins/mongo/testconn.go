@@ -0,0 +1,42 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	mongodrv "go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// testMongo connects with the profile's URI, runs {ping: 1} against the
+// admin database and reports the server version from buildInfo.
+func testMongo(ctx context.Context, p mongoProfile, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	client, err := mongodrv.Connect(options.Client().
+		ApplyURI(buildURI(p)).
+		SetServerSelectionTimeout(10 * time.Second))
+	if err != nil {
+		return fmt.Errorf("connect failed: %w", err)
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+
+	admin := client.Database("admin")
+	if err := admin.RunCommand(ctx, bson.D{{Key: "ping", Value: 1}}).Err(); err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+
+	var info struct {
+		Version string `bson:"version"`
+	}
+	if err := admin.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info); err != nil || info.Version == "" {
+		_, _ = fmt.Fprintln(out, "✅ MongoDB ping successful")
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "✅ MongoDB ping successful (server version %s)\n", info.Version)
+	return nil
+}
@@ -0,0 +1,135 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/shellquote"
+)
+
+// npmrc renders an .npmrc that reads the token from $NPM_TOKEN, so the
+// file itself holds no secret.
+func (n npmSettings) npmrc() string {
+	reg := n.registry()
+	var b strings.Builder
+	if n.Scope != "" {
+		fmt.Fprintf(&b, "%s:registry=%s\n", n.Scope, reg)
+	} else {
+		fmt.Fprintf(&b, "registry=%s\n", reg)
+	}
+	// npm keys auth by the registry URL without its scheme
+	host := strings.TrimPrefix(strings.TrimPrefix(reg, "https:"), "http:")
+	fmt.Fprintf(&b, "%s:_authToken=${NPM_TOKEN}\n", host)
+	b.WriteString("always-auth=true\n")
+	return b.String()
+}
+
+// settingsXML renders a Maven settings.xml holding only <servers>.
+func settingsXML(servers []mavenServer) ([]byte, error) {
+	type server struct {
+		ID       string `xml:"id"`
+		Username string `xml:"username"`
+		Password string `xml:"password"`
+	}
+	doc := struct {
+		XMLName xml.Name `xml:"settings"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		Servers []server `xml:"servers>server"`
+	}{XMLNS: "http://maven.apache.org/SETTINGS/1.0.0"}
+	for _, s := range servers {
+		doc.Servers = append(doc.Servers, server(s))
+	}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// netrc renders a single-machine .netrc entry for Go module fetches.
+func (g goSettings) netrc() string {
+	return fmt.Sprintf("machine %s\nlogin %s\npassword %s\n", g.Host, g.username(), g.Token)
+}
+
+// indexURL returns PIP_INDEX_URL with the PyPI credentials filled in when
+// the URL carries none.
+func (p pypiSettings) indexURL() string {
+	u, err := url.Parse(p.IndexURL)
+	if err != nil || u.User != nil || p.Password == "" {
+		return p.IndexURL
+	}
+	u.User = url.UserPassword(p.username(), p.Password)
+	return u.String()
+}
+
+// writeFiles renders every configured tool's files into dir and returns
+// the env vars pointing each tool at them, alongside the token vars.
+func (p pkgProfile) writeFiles(dir string) (map[string]string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	write := func(name string, b []byte) (string, error) {
+		path := filepath.Join(dir, name)
+		return path, os.WriteFile(path, b, 0o600)
+	}
+
+	vars := p.tokenVars()
+	if p.NPM != nil {
+		path, err := write(".npmrc", []byte(p.NPM.npmrc()))
+		if err != nil {
+			return nil, err
+		}
+		vars["NPM_CONFIG_USERCONFIG"] = path
+	}
+	if len(p.Maven) > 0 {
+		b, err := settingsXML(p.Maven)
+		if err != nil {
+			return nil, err
+		}
+		path, err := write("settings.xml", b)
+		if err != nil {
+			return nil, err
+		}
+		// As global settings, so the user's own ~/.m2/settings.xml (mirrors,
+		// profiles) still applies on top. `rdv exec` keeps any MAVEN_ARGS the
+		// child inherits in front of this.
+		vars["MAVEN_ARGS"] = "-gs " + shellquote.Sh(path)
+	}
+	if p.Go != nil && p.Go.Host != "" && p.Go.Token != "" {
+		path, err := write(".netrc", []byte(p.Go.netrc()))
+		if err != nil {
+			return nil, err
+		}
+		vars["NETRC"] = path
+	}
+	return vars, nil
+}
+
+// TempFiles writes the profile's tool configs to a fresh temp dir for a
+// child process, leaving the user's home untouched; cleanup removes it.
+func TempFiles(profile string) (env map[string]string, cleanup func(), err error) {
+	p, err := lookup(profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tempFiles(p)
+}
+
+func tempFiles(p pkgProfile) (env map[string]string, cleanup func(), err error) {
+	dir, err := os.MkdirTemp("", "rdv-pkg-")
+	if err != nil {
+		return nil, nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+	env, err = p.writeFiles(dir)
+	if err != nil {
+		cleanup()
+		return nil, nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return env, cleanup, nil
+}
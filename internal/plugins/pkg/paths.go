@@ -0,0 +1,26 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// baseDir returns ~/.config/rdv (or override via RDV_PKG_DIR for tests)
+func baseDir() string {
+	if v := os.Getenv("RDV_PKG_DIR"); v != "" {
+		return v
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv")
+}
+
+// cfgPath returns the profile store, ~/.config/rdv/pkg.yaml
+func cfgPath() string {
+	return filepath.Join(baseDir(), "pkg.yaml")
+}
+
+// exportDir holds the config files written by `rdv pkg export`,
+// ~/.config/rdv/pkg/<profile>/
+func exportDir(profile string) string {
+	return filepath.Join(baseDir(), "pkg", profile)
+}
@@ -0,0 +1,257 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+// ---------- plugin wiring ----------
+
+type pkgPlugin struct{}
+
+func (k *pkgPlugin) Name() string { return "pkg" }
+
+func (k *pkgPlugin) Register(root *cobra.Command) {
+	root.AddCommand(engine.Command(driver))
+}
+
+var driver = &engine.Driver{
+	Name:    "pkg",
+	Aliases: []string{"registry-tokens"},
+	Label:   "Package",
+	Related: []string{"NPM_TOKEN", "NODE_AUTH_TOKEN", "NPM_CONFIG_USERCONFIG", "NPM_CONFIG__AUTH*", "TWINE_*", "PIP_INDEX_URL", "PIP_EXTRA_INDEX_URL",
+		"GOPRIVATE", "GONOSUMDB", "GONOPROXY", "NETRC"},
+	Path: cfgPath,
+	Fields: []engine.Field{
+		{Key: "npm_token", Title: "npm token", Usage: "npm auth token", Secret: true, Optional: true},
+		{Key: "npm_registry", Title: "npm registry", Usage: "npm registry URL (default https://registry.npmjs.org/)", Example: "https://registry.npmjs.org/", Optional: true},
+		{Key: "npm_scope", Title: "npm scope", Usage: "only route this @scope to the registry", Example: "@acme", Optional: true},
+		{Key: "pypi_username", Title: "PyPI username", Usage: "PyPI / index username (default __token__)", Example: "__token__", Optional: true},
+		{Key: "pypi_password", Title: "PyPI token / password", Usage: "PyPI API token or password", Secret: true, Optional: true},
+		{Key: "pypi_repository_url", Title: "twine repository URL", Usage: "upload URL for twine (default PyPI)", Optional: true},
+		{Key: "pypi_index_url", Title: "pip index URL", Usage: "package index for pip, e.g. https://pypi.example.com/simple", Optional: true},
+		{Key: "maven_servers", Flag: "maven-server", Title: "Maven servers, id=user:password", Usage: "Maven server as id=username:password (repeatable)", Secret: true, Lines: true, Optional: true},
+		{Key: "go_private", Title: "GOPRIVATE patterns", Usage: "GOPRIVATE module patterns (comma-separated)", Example: "github.com/acme/*", List: true, Optional: true},
+		{Key: "go_host", Title: "Go .netrc host", Usage: "host to authenticate Go module fetches to via .netrc", Optional: true},
+		{Key: "go_username", Title: "Go .netrc username", Usage: ".netrc login (default oauth2)", Example: "oauth2", Optional: true},
+		{Key: "go_token", Title: "Go .netrc token", Usage: ".netrc password / token", Secret: true, Optional: true},
+	},
+	Summary: []string{"npm_registry", "pypi_index_url", "go_private"},
+	Help: map[string]string{
+		"":           "Manage package registry tokens (npm, PyPI, Maven, Go)",
+		"set-config": "Interactively set package registry tokens",
+		"export":     "Write tool configs for a profile and print token / config env vars",
+		"test-conn":  "Verify npm / PyPI tokens against their registries",
+	},
+	Secrets: []string{"NPM_TOKEN", "NODE_AUTH_TOKEN", "TWINE_PASSWORD", "PIP_INDEX_URL"},
+	Validate: func(p engine.Settings) error {
+		pp, err := profileOf(p)
+		if err != nil {
+			return err
+		}
+		return pp.validate()
+	},
+	Export: func(name string, p engine.Settings) (map[string]string, error) {
+		pp, err := profileOf(p)
+		if err != nil {
+			return nil, err
+		}
+		vars, err := pp.writeFiles(exportDir(name))
+		if err != nil {
+			return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		return vars, nil
+	},
+	Exec: func(_ string, p engine.Settings) (map[string]string, func(), error) {
+		pp, err := profileOf(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tempFiles(pp)
+	},
+	Test: func(ctx context.Context, p engine.Settings, out io.Writer) error {
+		pp, err := profileOf(p)
+		if err != nil {
+			return err
+		}
+		return testPkg(ctx, pp, out)
+	},
+	// Exported config files may contain tokens; remove them too.
+	Remove: func(name string) error {
+		if err := os.RemoveAll(exportDir(name)); err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	plugin.Register(&pkgPlugin{})
+	engine.Register(driver)
+}
+
+// ---------- data types ----------
+
+type npmSettings struct {
+	Registry string
+	Scope    string
+	Token    string
+}
+
+type pypiSettings struct {
+	Username      string
+	Password      string
+	RepositoryURL string
+	IndexURL      string
+}
+
+type mavenServer struct {
+	ID       string
+	Username string
+	Password string
+}
+
+type goSettings struct {
+	Private  []string
+	Host     string
+	Username string
+	Token    string
+}
+
+// pkgProfile is a resolved profile grouped by ecosystem; unused ones stay
+// nil.
+type pkgProfile struct {
+	NPM   *npmSettings
+	PyPI  *pypiSettings
+	Maven []mavenServer
+	Go    *goSettings
+}
+
+func (n npmSettings) registry() string {
+	if n.Registry == "" {
+		return "https://registry.npmjs.org/"
+	}
+	return strings.TrimRight(n.Registry, "/") + "/"
+}
+
+func (p pypiSettings) username() string {
+	if p.Username == "" {
+		return "__token__"
+	}
+	return p.Username
+}
+
+func (g goSettings) username() string {
+	if g.Username == "" {
+		return "oauth2"
+	}
+	return g.Username
+}
+
+// parseMavenServer parses id=username:password.
+func parseMavenServer(s string) (mavenServer, error) {
+	id, cred, ok := strings.Cut(s, "=")
+	user, pass, ok2 := strings.Cut(cred, ":")
+	if !ok || !ok2 || id == "" || user == "" {
+		return mavenServer{}, exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid --maven-server %q (want id=username:password)", s))
+	}
+	return mavenServer{ID: id, Username: user, Password: pass}, nil
+}
+
+// profileOf groups a resolved profile by ecosystem; sections with nothing
+// set stay nil.
+func profileOf(p engine.Settings) (pkgProfile, error) {
+	var out pkgProfile
+	npm := npmSettings{Registry: p["npm_registry"], Scope: p["npm_scope"], Token: p["npm_token"]}
+	if npm != (npmSettings{}) {
+		out.NPM = &npm
+	}
+	pypi := pypiSettings{Username: p["pypi_username"], Password: p["pypi_password"], RepositoryURL: p["pypi_repository_url"], IndexURL: p["pypi_index_url"]}
+	if pypi != (pypiSettings{}) {
+		out.PyPI = &pypi
+	}
+	for _, s := range p.Lines("maven_servers") {
+		m, err := parseMavenServer(s)
+		if err != nil {
+			return pkgProfile{}, err
+		}
+		out.Maven = append(out.Maven, m)
+	}
+	gos := goSettings{Private: p.Values("go_private"), Host: p["go_host"], Username: p["go_username"], Token: p["go_token"]}
+	if len(gos.Private) > 0 || gos.Host != "" || gos.Token != "" {
+		out.Go = &gos
+	}
+	return out, nil
+}
+
+// validate checks that each configured ecosystem is complete.
+func (p pkgProfile) validate() error {
+	switch {
+	case p.NPM == nil && p.PyPI == nil && len(p.Maven) == 0 && p.Go == nil:
+		return exitcodes.New(exitcodes.InvalidArgs, "nothing to save: pass --npm-token, --pypi-password, --maven-server or --go-private")
+	case p.NPM != nil && p.NPM.Token == "":
+		return exitcodes.New(exitcodes.InvalidArgs, "npm settings require --npm-token")
+	case p.NPM != nil && p.NPM.Scope != "" && !strings.HasPrefix(p.NPM.Scope, "@"):
+		return exitcodes.New(exitcodes.InvalidArgs, "--npm-scope must start with @")
+	case p.PyPI != nil && p.PyPI.Password == "" && p.PyPI.IndexURL == "":
+		return exitcodes.New(exitcodes.InvalidArgs, "PyPI settings require --pypi-password or --pypi-index-url")
+	case p.Go != nil && len(p.Go.Private) == 0:
+		return exitcodes.New(exitcodes.InvalidArgs, "Go settings require --go-private")
+	case p.Go != nil && (p.Go.Host == "") != (p.Go.Token == ""):
+		return exitcodes.New(exitcodes.InvalidArgs, "--go-host and --go-token go together")
+	}
+	return nil
+}
+
+// tokenVars are the env vars that need no files.
+func (p pkgProfile) tokenVars() map[string]string {
+	vars := map[string]string{}
+	if p.NPM != nil {
+		vars["NPM_TOKEN"] = p.NPM.Token
+		vars["NODE_AUTH_TOKEN"] = p.NPM.Token // actions/setup-node convention
+	}
+	if p.PyPI != nil {
+		if p.PyPI.Password != "" {
+			vars["TWINE_USERNAME"] = p.PyPI.username()
+			vars["TWINE_PASSWORD"] = p.PyPI.Password
+		}
+		if p.PyPI.RepositoryURL != "" {
+			vars["TWINE_REPOSITORY_URL"] = p.PyPI.RepositoryURL
+		}
+		if p.PyPI.IndexURL != "" {
+			vars["PIP_INDEX_URL"] = p.PyPI.indexURL()
+		}
+	}
+	if p.Go != nil {
+		patterns := strings.Join(p.Go.Private, ",")
+		vars["GOPRIVATE"] = patterns
+		vars["GONOSUMDB"] = patterns
+	}
+	return vars
+}
+
+// lookup resolves a single profile.
+func lookup(profile string) (pkgProfile, error) {
+	p, err := driver.Resolve(profile)
+	if err != nil {
+		return pkgProfile{}, err
+	}
+	return profileOf(p)
+}
+
+// ExportVars writes the profile's .npmrc / settings.xml / .netrc under the
+// rdv config dir and returns the token vars plus NPM_CONFIG_USERCONFIG,
+// MAVEN_ARGS and NETRC pointing at them. `rdv exec --pkg` uses TempFiles
+// instead.
+func ExportVars(profile string) (map[string]string, error) {
+	return driver.ExportVars(profile)
+}
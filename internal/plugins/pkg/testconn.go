@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// testPkg verifies the npm token (GET /-/whoami) and the pip index (GET
+// with the PyPI credentials). Maven servers and Go hosts have no generic
+// auth probe and are skipped.
+func testPkg(ctx context.Context, p pkgProfile, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	var errs []error
+	tested := false
+	if p.NPM != nil {
+		tested = true
+		if err := testNPM(ctx, *p.NPM, out); err != nil {
+			errs = append(errs, fmt.Errorf("npm: %w", err))
+		}
+	}
+	if p.PyPI != nil && p.PyPI.IndexURL != "" {
+		tested = true
+		if err := testPipIndex(ctx, *p.PyPI, out); err != nil {
+			errs = append(errs, fmt.Errorf("pip index: %w", err))
+		}
+	}
+	if !tested {
+		_, _ = fmt.Fprintln(out, "ℹ️  nothing to verify: only npm tokens and pip indexes are checked")
+	}
+	return errors.Join(errs...)
+}
+
+func testNPM(ctx context.Context, n npmSettings, out io.Writer) error {
+	u := n.registry() + "-/whoami"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+n.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s failed: %w", u, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	var who struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&who); err != nil {
+		return fmt.Errorf("GET %s: %w", u, err)
+	}
+	_, _ = fmt.Fprintf(out, "✅ npm token valid for %s on %s\n", who.Username, n.registry())
+	return nil
+}
+
+func testPipIndex(ctx context.Context, p pypiSettings, out io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.IndexURL, nil)
+	if err != nil {
+		return err
+	}
+	if p.Password != "" {
+		req.SetBasicAuth(p.username(), p.Password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s failed: %w", p.IndexURL, err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", p.IndexURL, resp.Status)
+	}
+	_, _ = fmt.Fprintf(out, "✅ pip index %s accepted credentials\n", p.IndexURL)
+	return nil
+}
@@ -0,0 +1,15 @@
+package redis
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// cfgPath returns ~/.config/rdv/redis.yaml (or override via RDV_REDIS_DIR for tests)
+func cfgPath() string {
+	if v := os.Getenv("RDV_REDIS_DIR"); v != "" {
+		return filepath.Join(v, "redis.yaml")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv", "redis.yaml")
+}
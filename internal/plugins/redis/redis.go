@@ -0,0 +1,184 @@
+package redis
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+// ---------- plugin wiring ----------
+
+type redisPlugin struct{}
+
+func (r *redisPlugin) Name() string { return "redis" }
+
+func (r *redisPlugin) Register(root *cobra.Command) {
+	root.AddCommand(engine.Command(driver))
+}
+
+const defaultPort = "6379"
+
+var driver = &engine.Driver{
+	Name:    "redis",
+	Aliases: []string{"valkey"},
+	Label:   "Redis",
+	Related: []string{"REDIS_*"},
+	Path:    cfgPath,
+	Fields: []engine.Field{
+		{Key: "host", Title: "Host (optional for sentinel/cluster)", Usage: "server host", Example: "localhost", Optional: true},
+		{Key: "port", Title: "Port", Usage: "server port", Default: defaultPort, Int: true},
+		{Key: "username", Title: "ACL username", Usage: "ACL username (Redis 6+)", Optional: true},
+		{Key: "password", Title: "Password", Usage: "password", Secret: true, Optional: true},
+		{Key: "db", Title: "DB index", Usage: "database index", Default: "0", Int: true},
+		{Key: "tls", Title: "Use TLS?", Usage: "connect with TLS (rediss://)", Bool: true},
+		{Key: "tls_ca_file", Title: "TLS CA file", Usage: "PEM CA bundle to verify the server", Optional: true, Path: true},
+		{Key: "tls_insecure", Title: "Skip TLS certificate verification?", Usage: "skip TLS certificate verification", Bool: true},
+		{Key: "sentinel_master", Title: "Sentinel master", Usage: "sentinel master name", Optional: true},
+		{Key: "sentinel_addrs", Title: "Sentinel addresses (comma-separated)", Usage: "sentinel host:port list (comma-separated)", List: true, Optional: true},
+		{Key: "cluster_addrs", Title: "Cluster addresses (comma-separated)", Usage: "cluster seed host:port list (comma-separated)", List: true, Optional: true},
+	},
+	Summary: []string{"host", "port", "username"},
+	Help: map[string]string{
+		"":          "Manage Redis / Valkey connection profiles",
+		"export":    "Print REDIS_URL and REDIS_* exports for a profile",
+		"test-conn": "AUTH and PING using a saved Redis profile",
+	},
+	Secrets:  []string{"REDIS_URL", "REDIS_PASSWORD"},
+	Validate: func(p engine.Settings) error { return profileOf(p).validate() },
+	Env:      func(p engine.Settings) map[string]string { return exportVars(profileOf(p)) },
+	Test: func(ctx context.Context, p engine.Settings, out io.Writer) error {
+		return testRedis(ctx, profileOf(p), out)
+	},
+}
+
+func init() {
+	plugin.Register(&redisPlugin{})
+	engine.Register(driver)
+}
+
+// ---------- data types ----------
+
+// redisProfile is a resolved profile in the shape the exporter and tester
+// work with.
+type redisProfile struct {
+	Host           string
+	Port           string
+	Username       string
+	Password       string
+	DB             int
+	TLS            bool
+	TLSCAFile      string
+	TLSInsecure    bool
+	SentinelMaster string
+	SentinelAddrs  []string
+	ClusterAddrs   []string
+}
+
+func profileOf(p engine.Settings) redisProfile {
+	return redisProfile{
+		Host:           p["host"],
+		Port:           p["port"],
+		Username:       p["username"],
+		Password:       p["password"],
+		DB:             p.Int("db"),
+		TLS:            p.Bool("tls"),
+		TLSCAFile:      p["tls_ca_file"],
+		TLSInsecure:    p.Bool("tls_insecure"),
+		SentinelMaster: p["sentinel_master"],
+		SentinelAddrs:  p.Values("sentinel_addrs"),
+		ClusterAddrs:   p.Values("cluster_addrs"),
+	}
+}
+
+// validate checks that p names at least one endpoint.
+func (p redisProfile) validate() error {
+	switch {
+	case p.SentinelMaster != "" && len(p.SentinelAddrs) == 0:
+		return exitcodes.New(exitcodes.InvalidArgs, "--sentinel-master requires --sentinel-addrs")
+	case len(p.SentinelAddrs) > 0 && p.SentinelMaster == "":
+		return exitcodes.New(exitcodes.InvalidArgs, "--sentinel-addrs requires --sentinel-master")
+	case p.Host == "" && len(p.SentinelAddrs) == 0 && len(p.ClusterAddrs) == 0:
+		return exitcodes.New(exitcodes.InvalidArgs, "missing required flags: --host (or --sentinel-addrs / --cluster-addrs)")
+	}
+	return nil
+}
+
+// redisURL renders redis[s]://[user[:password]@]host:port[/db].
+func redisURL(p redisProfile, addr string) string {
+	u := url.URL{Scheme: "redis", Host: addr}
+	if p.TLS {
+		u.Scheme = "rediss"
+	}
+	switch {
+	case p.Password != "":
+		u.User = url.UserPassword(p.Username, p.Password)
+	case p.Username != "":
+		u.User = url.User(p.Username)
+	}
+	if p.DB != 0 {
+		u.Path = "/" + strconv.Itoa(p.DB)
+	}
+	return u.String()
+}
+
+// primaryAddr is the address REDIS_URL points at: host:port, else the first
+// cluster seed. Sentinel-only profiles have none.
+func (p redisProfile) primaryAddr() string {
+	if p.Host != "" {
+		port := p.Port
+		if port == "" {
+			port = defaultPort
+		}
+		return net.JoinHostPort(p.Host, port)
+	}
+	if len(p.ClusterAddrs) > 0 {
+		return p.ClusterAddrs[0]
+	}
+	return ""
+}
+
+// ExportVars returns the REDIS_* env map for a profile.
+func ExportVars(profile string) (map[string]string, error) {
+	return driver.ExportVars(profile)
+}
+
+func exportVars(p redisProfile) map[string]string {
+	vars := map[string]string{
+		"REDIS_DB": strconv.Itoa(p.DB),
+	}
+	if addr := p.primaryAddr(); addr != "" {
+		host, port, _ := net.SplitHostPort(addr)
+		vars["REDIS_URL"] = redisURL(p, addr)
+		vars["REDIS_HOST"] = host
+		vars["REDIS_PORT"] = port
+	}
+	if p.Username != "" {
+		vars["REDIS_USERNAME"] = p.Username
+	}
+	if p.Password != "" {
+		vars["REDIS_PASSWORD"] = p.Password
+	}
+	if p.TLS {
+		vars["REDIS_TLS"] = "true"
+	}
+	if p.TLSCAFile != "" {
+		vars["REDIS_TLS_CA_FILE"] = p.TLSCAFile
+	}
+	if p.SentinelMaster != "" {
+		vars["REDIS_SENTINEL_MASTER"] = p.SentinelMaster
+		vars["REDIS_SENTINEL_ADDRS"] = strings.Join(p.SentinelAddrs, ",")
+	}
+	if len(p.ClusterAddrs) > 0 {
+		vars["REDIS_CLUSTER_ADDRS"] = strings.Join(p.ClusterAddrs, ",")
+	}
+	return vars
+}
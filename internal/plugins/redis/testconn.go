@@ -0,0 +1,224 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dialTimeout = 5 * time.Second
+
+// respConn is a tiny RESP2 client: enough for AUTH, SELECT, PING, INFO and
+// SENTINEL lookups without pulling in a full Redis library.
+type respConn struct {
+	c net.Conn
+	r *bufio.Reader
+}
+
+// dialRESP connects to addr; the connection gives up after dialTimeout or
+// when ctx is done, whichever comes first.
+func dialRESP(ctx context.Context, p redisProfile, addr string) (*respConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	d := &net.Dialer{}
+	var c net.Conn
+	var err error
+	if p.TLS {
+		var cfg *tls.Config
+		cfg, err = tlsConfig(p, addr)
+		if err != nil {
+			return nil, err
+		}
+		c, err = (&tls.Dialer{NetDialer: d, Config: cfg}).DialContext(ctx, "tcp", addr)
+	} else {
+		c, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dial %s failed: %w", addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	_ = c.SetDeadline(deadline)
+	return &respConn{c: c, r: bufio.NewReader(c)}, nil
+}
+
+func tlsConfig(p redisProfile, addr string) (*tls.Config, error) {
+	host, _, _ := net.SplitHostPort(addr)
+	cfg := &tls.Config{ServerName: host, InsecureSkipVerify: p.TLSInsecure} //nolint:gosec // opt-in via --tls-insecure
+	if p.TLSCAFile != "" {
+		pem, err := os.ReadFile(p.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", p.TLSCAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+func (rc *respConn) Close() error { return rc.c.Close() }
+
+// do sends one command and reads its reply.
+func (rc *respConn) do(args ...string) (any, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(rc.c, b.String()); err != nil {
+		return nil, err
+	}
+	return rc.read()
+}
+
+// serverError is a RESP "-ERR ..." reply.
+type serverError string
+
+func (e serverError) Error() string { return string(e) }
+
+func (rc *respConn) read() (any, error) {
+	line, err := rc.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, serverError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err // nil bulk string
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rc.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err // nil array
+		}
+		out := make([]any, n)
+		for i := range out {
+			if out[i], err = rc.read(); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unexpected reply %q", line)
+	}
+}
+
+// masterFromSentinels asks each sentinel in turn for the master's address.
+func masterFromSentinels(ctx context.Context, p redisProfile) (string, error) {
+	var errs []error
+	for _, addr := range p.SentinelAddrs {
+		rc, err := dialRESP(ctx, p, addr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		reply, err := rc.do("SENTINEL", "get-master-addr-by-name", p.SentinelMaster)
+		_ = rc.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+			continue
+		}
+		if hp, ok := reply.([]any); ok && len(hp) == 2 {
+			return net.JoinHostPort(fmt.Sprint(hp[0]), fmt.Sprint(hp[1])), nil
+		}
+		errs = append(errs, fmt.Errorf("%s: master %q unknown", addr, p.SentinelMaster))
+	}
+	return "", fmt.Errorf("no sentinel could resolve master %q: %w", p.SentinelMaster, errors.Join(errs...))
+}
+
+// testRedis authenticates, selects the db and PINGs the primary endpoint.
+func testRedis(ctx context.Context, p redisProfile, out io.Writer) error {
+	addr := p.primaryAddr()
+	if p.SentinelMaster != "" {
+		var err error
+		if addr, err = masterFromSentinels(ctx, p); err != nil {
+			return err
+		}
+	}
+
+	rc, err := dialRESP(ctx, p, addr)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	if p.Password != "" {
+		args := []string{"AUTH", p.Password}
+		if p.Username != "" {
+			args = []string{"AUTH", p.Username, p.Password}
+		}
+		if _, err := rc.do(args...); err != nil {
+			return fmt.Errorf("AUTH failed: %w", err)
+		}
+	}
+	if p.DB != 0 && len(p.ClusterAddrs) == 0 {
+		if _, err := rc.do("SELECT", strconv.Itoa(p.DB)); err != nil {
+			return fmt.Errorf("SELECT %d failed: %w", p.DB, err)
+		}
+	}
+	reply, err := rc.do("PING")
+	if err != nil {
+		return fmt.Errorf("PING failed: %w", err)
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected PING reply %v", reply)
+	}
+
+	msg := fmt.Sprintf("✅ Redis PONG from %s", addr)
+	if v := serverVersion(rc); v != "" {
+		msg += " (" + v + ")"
+	}
+	_, _ = fmt.Fprintln(out, msg)
+	return nil
+}
+
+// serverVersion reads redis_version/valkey_version from INFO; ACLs may
+// forbid INFO, in which case it returns "".
+func serverVersion(rc *respConn) string {
+	reply, err := rc.do("INFO", "server")
+	if err != nil {
+		return ""
+	}
+	info, _ := reply.(string)
+	fields := map[string]string{}
+	for _, line := range strings.Split(info, "\n") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(line), ":"); ok {
+			fields[k] = v
+		}
+	}
+	// Valkey also reports a redis_version for compatibility; prefer its own.
+	for _, key := range []string{"valkey_version", "redis_version"} {
+		if v := fields[key]; v != "" {
+			return key + " " + v
+		}
+	}
+	return ""
+}
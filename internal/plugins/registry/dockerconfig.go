@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// dockerHubAuthKey is the key docker uses in "auths" for Docker Hub.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// authKey is the "auths" key docker looks up for p's host.
+func (p registryProfile) authKey() string {
+	if isDockerHub(p.Host) {
+		return dockerHubAuthKey
+	}
+	return p.Host
+}
+
+// basicAuth is base64("user:password"), the docker "auth" field.
+func (p registryProfile) basicAuth() string {
+	return base64.StdEncoding.EncodeToString([]byte(p.Username + ":" + p.Password))
+}
+
+// readDockerConfig parses dir/config.json into a generic map so unknown
+// settings survive a rewrite. A missing file yields an empty map.
+func readDockerConfig(dir string) (map[string]any, error) {
+	cfg := map[string]any{}
+	b, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Join(dir, "config.json"), err)
+	}
+	return cfg, nil
+}
+
+// mergeAuth sets auths[host] for p in cfg. Credential helpers configured for
+// the same host would shadow the entry, so the matching credHelpers entry is
+// dropped; a global credsStore is left alone (see writeTempDockerConfig).
+func mergeAuth(cfg map[string]any, p registryProfile) {
+	auths, _ := cfg["auths"].(map[string]any)
+	if auths == nil {
+		auths = map[string]any{}
+	}
+	auths[p.authKey()] = map[string]any{"auth": p.basicAuth()}
+	cfg["auths"] = auths
+
+	if helpers, ok := cfg["credHelpers"].(map[string]any); ok {
+		delete(helpers, p.authKey())
+	}
+}
+
+func writeDockerConfig(dir string, cfg map[string]any) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(cfg, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "config.json"), append(out, '\n'), 0o600)
+}
+
+// WriteDockerAuth merges the profile's credentials into dir/config.json
+// (dir defaults to $DOCKER_CONFIG or ~/.docker) and returns the file path.
+func WriteDockerAuth(profile, dir string) (string, error) {
+	p, err := lookup(profile)
+	if err != nil {
+		return "", err
+	}
+	if dir == "" {
+		dir = dockerConfigDir()
+	}
+	cfg, err := readDockerConfig(dir)
+	if err != nil {
+		return "", exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	mergeAuth(cfg, p)
+	if err := writeDockerConfig(dir, cfg); err != nil {
+		return "", exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// TempDockerConfig writes a private DOCKER_CONFIG directory holding the
+// user's existing docker config plus the profile's auth entry, with
+// cli-plugins and contexts symlinked from the original directory. The global
+// credsStore is removed so docker reads the inline auth. The caller runs
+// cleanup once the child exits.
+func TempDockerConfig(profile string) (dir string, cleanup func(), err error) {
+	p, err := lookup(profile)
+	if err != nil {
+		return "", nil, err
+	}
+	return tempDockerConfig(p)
+}
+
+func tempDockerConfig(p registryProfile) (dir string, cleanup func(), err error) {
+	cfg, err := readDockerConfig(dockerConfigDir())
+	if err != nil {
+		return "", nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	delete(cfg, "credsStore")
+	mergeAuth(cfg, p)
+
+	dir, err = os.MkdirTemp("", "rdv-docker-")
+	if err != nil {
+		return "", nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+	if err := writeDockerConfig(dir, cfg); err != nil {
+		cleanup()
+		return "", nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	// Keep CLI plugins (buildx, compose) and contexts reachable.
+	for _, name := range []string{"cli-plugins", "contexts"} {
+		src := filepath.Join(dockerConfigDir(), name)
+		if _, err := os.Stat(src); err == nil {
+			_ = os.Symlink(src, filepath.Join(dir, name))
+		}
+	}
+	return dir, cleanup, nil
+}
+
+// execVars is what `rdv exec --registry` injects: the profile's variables
+// plus a TempDockerConfig as DOCKER_CONFIG.
+func execVars(p registryProfile) (map[string]string, func(), error) {
+	dir, cleanup, err := tempDockerConfig(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	env := exportVars(p)
+	env["DOCKER_CONFIG"] = dir
+	return env, cleanup, nil
+}
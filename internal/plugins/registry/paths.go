@@ -0,0 +1,30 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// baseDir returns ~/.config/rdv (or override via RDV_REGISTRY_DIR for tests)
+func baseDir() string {
+	if v := os.Getenv("RDV_REGISTRY_DIR"); v != "" {
+		return v
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv")
+}
+
+// cfgPath returns the profile store, ~/.config/rdv/registry.yaml
+func cfgPath() string {
+	return filepath.Join(baseDir(), "registry.yaml")
+}
+
+// dockerConfigDir is where the docker CLI reads config.json from:
+// $DOCKER_CONFIG, else ~/.docker.
+func dockerConfigDir() string {
+	if v := os.Getenv("DOCKER_CONFIG"); v != "" {
+		return v
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker")
+}
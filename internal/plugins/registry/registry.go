@@ -0,0 +1,176 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/logger"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+)
+
+// ---------- plugin wiring ----------
+
+type registryPlugin struct{}
+
+func (r *registryPlugin) Name() string { return "registry" }
+
+func (r *registryPlugin) Register(root *cobra.Command) {
+	registryCmd := engine.Command(driver)
+
+	// -------- docker-login ----------------
+	var loginName, dockerDir string
+	loginCmd := &cobra.Command{
+		Use:   "docker-login",
+		Short: "Merge the profile's auth into docker's config.json",
+		RunE:  func(cmd *cobra.Command, _ []string) error { return registryDockerLogin(loginName, dockerDir) },
+	}
+	loginCmd.Flags().StringVarP(&loginName, "profile", "p", "default", "profile name")
+	loginCmd.Flags().StringVar(&dockerDir, "docker-config", "", "docker config dir (default $DOCKER_CONFIG or ~/.docker)")
+
+	registryCmd.AddCommand(loginCmd)
+	root.AddCommand(registryCmd)
+}
+
+var driver = &engine.Driver{
+	Name:    "registry",
+	Label:   "registry",
+	Related: []string{"REGISTRY_*", "DOCKER_AUTH_CONFIG"},
+	Path:    cfgPath,
+	Fields: []engine.Field{
+		{Key: "host", Title: "Registry host", Usage: "registry host, e.g. ghcr.io or 123456789012.dkr.ecr.us-east-1.amazonaws.com", Example: "ghcr.io"},
+		{Key: "username", Title: "Username", Usage: "registry username"},
+		{Key: "password", Title: "Password / token", Usage: "password or access token", Secret: true},
+		{Key: "plain_http", Title: "Use plain HTTP (local registries)?", Usage: "talk to the registry over plain HTTP (local registries)", Bool: true},
+	},
+	Summary: []string{"host", "username"},
+	Help: map[string]string{
+		"":           "Manage container registry credentials",
+		"set-config": "Interactively set registry credentials",
+		"export":     "Print REGISTRY_* exports for a profile",
+		"test-conn":  "Authenticate against the registry /v2/ endpoint",
+	},
+	Secrets: []string{"REGISTRY_PASSWORD", "REGISTRY_AUTH"},
+	Validate: func(p engine.Settings) error {
+		rp := profileOf(p)
+		return rp.validate()
+	},
+	// Store --host as a bare host[:port].
+	Apply: func(_ string, _ *pflag.FlagSet, p engine.Settings) error {
+		if p["host"] == "" {
+			return nil
+		}
+		rp := registryProfile{Host: p["host"]}
+		rp.normalize()
+		p["host"] = rp.Host
+		if rp.PlainHTTP {
+			p["plain_http"] = "true"
+		}
+		return nil
+	},
+	Env: func(p engine.Settings) map[string]string { return exportVars(profileOf(p)) },
+	Exec: func(_ string, p engine.Settings) (map[string]string, func(), error) {
+		return execVars(profileOf(p))
+	},
+	Test: func(ctx context.Context, p engine.Settings, out io.Writer) error {
+		return testRegistry(ctx, profileOf(p), out)
+	},
+}
+
+func init() {
+	plugin.Register(&registryPlugin{})
+	engine.Register(driver)
+}
+
+// ---------- data types ----------
+
+// registryProfile is a resolved profile in the shape the exporter, docker
+// config writer and tester work with.
+type registryProfile struct {
+	Host      string
+	Username  string
+	Password  string
+	PlainHTTP bool
+}
+
+func profileOf(p engine.Settings) registryProfile {
+	rp := registryProfile{
+		Host:      p["host"],
+		Username:  p["username"],
+		Password:  p["password"],
+		PlainHTTP: p.Bool("plain_http"),
+	}
+	rp.normalize()
+	return rp
+}
+
+// isDockerHub reports whether host names Docker Hub.
+func isDockerHub(host string) bool {
+	switch host {
+	case "docker.io", "index.docker.io", "registry-1.docker.io":
+		return true
+	}
+	return false
+}
+
+// normalize reduces Host to a bare host[:port]; an http:// scheme turns on
+// PlainHTTP.
+func (p *registryProfile) normalize() {
+	host := strings.TrimSpace(p.Host)
+	if rest, ok := strings.CutPrefix(host, "http://"); ok {
+		host, p.PlainHTTP = rest, true
+	}
+	host = strings.TrimPrefix(host, "https://")
+	host, _, _ = strings.Cut(host, "/")
+	p.Host = host
+}
+
+// validate normalizes Host and checks required fields.
+func (p *registryProfile) validate() error {
+	p.normalize()
+	if p.Host == "" || p.Username == "" || p.Password == "" {
+		return exitcodes.New(exitcodes.InvalidArgs, "missing required flags: --host, --username, --password")
+	}
+	return nil
+}
+
+// ExportVars returns the REGISTRY_* env map for a profile.
+func ExportVars(profile string) (map[string]string, error) {
+	return driver.ExportVars(profile)
+}
+
+func exportVars(p registryProfile) map[string]string {
+	return map[string]string{
+		"REGISTRY_HOST":     p.Host,
+		"REGISTRY_USERNAME": p.Username,
+		"REGISTRY_PASSWORD": p.Password,
+		"REGISTRY_AUTH":     p.basicAuth(),
+	}
+}
+
+// lookup resolves a single profile.
+func lookup(profile string) (registryProfile, error) {
+	p, err := driver.Resolve(profile)
+	if err != nil {
+		return registryProfile{}, err
+	}
+	return profileOf(p), nil
+}
+
+/* ------------ command impls ------------ */
+
+func registryDockerLogin(name, dir string) error {
+	path, err := WriteDockerAuth(name, dir)
+	if err != nil {
+		return err
+	}
+	logger.L.Infow("registry auth written", "profile", name, "path", path)
+	fmt.Printf("✅ Stored credentials for profile %q in %s\n", name, path)
+	return nil
+}
@@ -0,0 +1,154 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+)
+
+// fakeRegistry is a distribution-style stand-in: /v2/ answers with a
+// Bearer challenge pointing at /token, which trades basic credentials for
+// a token.
+func fakeRegistry(t *testing.T, user, pass string) registryProfile {
+	t.Helper()
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != user || p != pass || r.URL.Query().Get("service") != "fake" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token":"t0k"}`))
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0k" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	p := registryProfile{Host: srv.URL, Username: user, Password: pass}
+	require.NoError(t, p.validate())
+	return p
+}
+
+func TestTestRegistryBearer(t *testing.T) {
+	p := fakeRegistry(t, "bot", "s3cret")
+	require.True(t, p.PlainHTTP)
+	require.NoError(t, testRegistry(t.Context(), p, io.Discard))
+
+	p.Password = "wrong"
+	require.ErrorContains(t, testRegistry(t.Context(), p, io.Discard), "401")
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example/token",service="registry.example",scope="repository:a/b:pull,push"`)
+	require.Equal(t, "bearer", scheme)
+	require.Equal(t, "https://auth.example/token", params["realm"])
+	require.Equal(t, "registry.example", params["service"])
+	require.Equal(t, "repository:a/b:pull,push", params["scope"])
+
+	scheme, params = parseChallenge(`Basic realm="Registry"`)
+	require.Equal(t, "basic", scheme)
+	require.Equal(t, "Registry", params["realm"])
+}
+
+func TestValidateNormalisesHost(t *testing.T) {
+	p := registryProfile{Host: "https://ghcr.io/", Username: "u", Password: "p"}
+	require.NoError(t, p.validate())
+	require.Equal(t, "ghcr.io", p.Host)
+	require.False(t, p.PlainHTTP)
+
+	require.Equal(t, dockerHubAuthKey, registryProfile{Host: "docker.io"}.authKey())
+	require.Error(t, (&registryProfile{Host: "ghcr.io"}).validate())
+}
+
+func TestDockerConfigMerge(t *testing.T) {
+	t.Setenv("RDV_REGISTRY_DIR", t.TempDir())
+	dockerDir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dockerDir)
+
+	existing := `{"auths":{"quay.io":{"auth":"eA=="}},"credsStore":"desktop","credHelpers":{"ghcr.io":"gh"},"psFormat":"table"}`
+	require.NoError(t, os.WriteFile(filepath.Join(dockerDir, "config.json"), []byte(existing), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dockerDir, "cli-plugins"), 0o700))
+	require.NoError(t, engine.SetConfig(driver, "gh", false, true, engine.Settings{"host": "ghcr.io", "username": "bot", "password": "tok"}))
+
+	read := func(dir string) map[string]any {
+		b, err := os.ReadFile(filepath.Join(dir, "config.json"))
+		require.NoError(t, err)
+		var cfg map[string]any
+		require.NoError(t, json.Unmarshal(b, &cfg))
+		return cfg
+	}
+
+	// temp DOCKER_CONFIG: inline auth wins, credsStore dropped, original untouched
+	dir, cleanup, err := TempDockerConfig("gh")
+	require.NoError(t, err)
+	cfg := read(dir)
+	require.NotContains(t, cfg, "credsStore")
+	require.Equal(t, "table", cfg["psFormat"])
+	auths := cfg["auths"].(map[string]any)
+	require.Contains(t, auths, "quay.io")
+	require.Equal(t, "Ym90OnRvaw==", auths["ghcr.io"].(map[string]any)["auth"])
+	target, err := os.Readlink(filepath.Join(dir, "cli-plugins"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dockerDir, "cli-plugins"), target)
+	require.Equal(t, existing, strings.TrimSpace(string(mustRead(t, filepath.Join(dockerDir, "config.json")))))
+	cleanup()
+	require.NoDirExists(t, dir)
+
+	// docker-login: merged in place, keeping credsStore
+	path, err := WriteDockerAuth("gh", "")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dockerDir, "config.json"), path)
+	cfg = read(dockerDir)
+	require.Equal(t, "desktop", cfg["credsStore"])
+	require.Empty(t, cfg["credHelpers"])
+	require.Contains(t, cfg["auths"].(map[string]any), "ghcr.io")
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return b
+}
+
+func TestExportVars(t *testing.T) {
+	t.Setenv("RDV_REGISTRY_DIR", t.TempDir())
+	require.NoError(t, engine.SetConfig(driver, "gh", false, true, engine.Settings{"host": "ghcr.io", "username": "bot", "password": "tok"}))
+
+	vars, err := ExportVars("gh")
+	require.NoError(t, err)
+	require.Equal(t, "ghcr.io", vars["REGISTRY_HOST"])
+	require.Equal(t, "bot", vars["REGISTRY_USERNAME"])
+	require.Equal(t, "tok", vars["REGISTRY_PASSWORD"])
+	require.Equal(t, "Ym90OnRvaw==", vars["REGISTRY_AUTH"])
+}
+
+func TestHostFlagIsNormalised(t *testing.T) {
+	t.Setenv("RDV_REGISTRY_DIR", t.TempDir())
+	in := engine.Settings{"host": "http://localhost:5000/v2/", "username": "u", "password": "p"}
+	require.NoError(t, driver.Apply("local", nil, in))
+	require.NoError(t, engine.SetConfig(driver, "local", false, true, in))
+
+	p, err := lookup("local")
+	require.NoError(t, err)
+	require.Equal(t, "localhost:5000", p.Host)
+	require.True(t, p.PlainHTTP)
+}
@@ -0,0 +1,170 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiBase is the registry's HTTP API root for p.
+func (p registryProfile) apiBase() string {
+	scheme := "https"
+	if p.PlainHTTP {
+		scheme = "http"
+	}
+	host := p.Host
+	if isDockerHub(host) {
+		host = "registry-1.docker.io"
+	}
+	return scheme + "://" + host
+}
+
+// parseChallenge splits a WWW-Authenticate header into its scheme and
+// parameters, e.g. `Bearer realm="https://auth",service="reg"`.
+func parseChallenge(h string) (scheme string, params map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(h), " ")
+	params = map[string]string{}
+	for rest != "" {
+		var kv string
+		// values are quoted and may contain commas
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.TrimSpace(strings.TrimLeft(key, ", "))
+		if strings.HasPrefix(after, `"`) {
+			end := strings.Index(after[1:], `"`)
+			if end < 0 {
+				kv, rest = after[1:], ""
+			} else {
+				kv, rest = after[1:end+1], after[end+2:]
+			}
+		} else {
+			kv, rest, _ = strings.Cut(after, ",")
+		}
+		params[strings.ToLower(key)] = kv
+	}
+	return strings.ToLower(scheme), params
+}
+
+// testRegistry probes GET /v2/ and answers a Basic or Bearer challenge with
+// the profile's credentials, following the distribution token flow.
+func testRegistry(ctx context.Context, p registryProfile, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	ping := p.apiBase() + "/v2/"
+	resp, err := get(ctx, ping, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		_, _ = fmt.Fprintf(out, "✅ Registry %s reachable (no auth required)\n", p.Host)
+		return nil
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("GET %s: unexpected status %s", ping, resp.Status)
+	}
+
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	var auth func(*http.Request)
+	switch scheme {
+	case "basic":
+		auth = func(r *http.Request) { r.SetBasicAuth(p.Username, p.Password) }
+	case "bearer":
+		token, err := fetchToken(ctx, p, params)
+		if err != nil {
+			return err
+		}
+		auth = func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	default:
+		return fmt.Errorf("GET %s: unsupported auth challenge %q", ping, resp.Header.Get("WWW-Authenticate"))
+	}
+
+	resp, err = get(ctx, ping, auth)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s with credentials: %s", ping, resp.Status)
+	}
+	_, _ = fmt.Fprintf(out, "✅ Registry %s accepted credentials for %s (%s auth)\n", p.Host, p.Username, scheme)
+	return nil
+}
+
+// fetchToken exchanges the profile's credentials at the challenge realm.
+func fetchToken(ctx context.Context, p registryProfile, params map[string]string) (string, error) {
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("bearer challenge without realm")
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid token realm %q: %w", realm, err)
+	}
+	q := u.Query()
+	if s := params["service"]; s != "" {
+		q.Set("service", s)
+	}
+	if s := params["scope"]; s != "" {
+		q.Set("scope", s)
+	}
+	u.RawQuery = q.Encode()
+
+	resp, err := get(ctx, u.String(), func(r *http.Request) {
+		if p.Username != "" || p.Password != "" {
+			r.SetBasicAuth(p.Username, p.Password)
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request to %s rejected: %s", u.Host, resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(resp.body, &body); err != nil {
+		return "", fmt.Errorf("token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("token response from %s carried no token", u.Host)
+}
+
+type response struct {
+	*http.Response
+	body []byte
+}
+
+// get performs a GET, reading (and closing) the body.
+func get(ctx context.Context, u string, auth func(*http.Request)) (*response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if auth != nil {
+		auth(req)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s failed: %w", u, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	return &response{Response: resp, body: b}, nil
+}
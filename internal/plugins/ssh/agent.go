@@ -0,0 +1,83 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// StartAgent serves the profile's key from a private in-process ssh-agent
+// on a socket in a fresh temp dir, alongside a generated ssh config and
+// known_hosts. It returns SSH_AUTH_SOCK and GIT_SSH_COMMAND for the child;
+// cleanup stops the agent and removes the directory.
+func StartAgent(profile string) (env map[string]string, cleanup func(), err error) {
+	p, err := lookup(profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return startAgent(profile, p)
+}
+
+func startAgent(profile string, p sshProfile) (env map[string]string, cleanup func(), err error) {
+	key, err := p.privateKey(true)
+	if err != nil {
+		return nil, nil, exitcodes.Wrap(exitcodes.InvalidArgs, err)
+	}
+
+	dir, err := os.MkdirTemp("", "rdv-ssh-")
+	if err != nil {
+		return nil, nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	gitSSH, err := p.writeFiles(dir, "")
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key, Comment: "rdv:" + profile}); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, exitcodes.Wrap(exitcodes.InvalidArgs, fmt.Errorf("failed to load key: %w", err))
+	}
+
+	sock := filepath.Join(dir, "agent.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, exitcodes.Wrap(exitcodes.ChildSpawnFailed, fmt.Errorf("failed to start ssh-agent: %w", err))
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					fmt.Fprintf(os.Stderr, "rdv: ssh-agent: %v\n", err)
+				}
+				return
+			}
+			go func() {
+				defer func() { _ = c.Close() }()
+				_ = agent.ServeAgent(keyring, c)
+			}()
+		}
+	}()
+
+	cleanup = func() {
+		_ = ln.Close()
+		<-done
+		_ = keyring.RemoveAll()
+		_ = os.RemoveAll(dir)
+	}
+	return map[string]string{
+		"SSH_AUTH_SOCK":   sock,
+		"GIT_SSH_COMMAND": gitSSH,
+	}, cleanup, nil
+}
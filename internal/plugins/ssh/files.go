@@ -0,0 +1,66 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yonasyiheyis/rdv/internal/shellquote"
+)
+
+// sshConfig renders an ssh_config Host block for the profile's alias,
+// followed by the user's own config so other hosts keep working.
+func (p sshProfile) sshConfig(identity string) string {
+	var b strings.Builder
+	if p.Host != "" {
+		fmt.Fprintf(&b, "Host %s\n", p.Host)
+		if p.HostName != "" {
+			fmt.Fprintf(&b, "  HostName %s\n", p.HostName)
+		}
+		if p.User != "" {
+			fmt.Fprintf(&b, "  User %s\n", p.User)
+		}
+		if p.Port != "" {
+			fmt.Fprintf(&b, "  Port %s\n", p.Port)
+		}
+		if identity != "" {
+			fmt.Fprintf(&b, "  IdentityFile %s\n  IdentitiesOnly yes\n", identity)
+		}
+	}
+	b.WriteString("Match all\n  Include ~/.ssh/config\n")
+	return b.String()
+}
+
+// knownHosts renders the profile's known_hosts entries.
+func (p sshProfile) knownHosts() string {
+	if len(p.KnownHosts) == 0 {
+		return ""
+	}
+	return strings.Join(p.KnownHosts, "\n") + "\n"
+}
+
+// writeFiles writes config and known_hosts into dir and returns the
+// GIT_SSH_COMMAND that uses them, with identity as the key when set.
+func (p sshProfile) writeFiles(dir, identity string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	cfg := filepath.Join(dir, "config")
+	if err := os.WriteFile(cfg, []byte(p.sshConfig(identity)), 0o600); err != nil {
+		return "", err
+	}
+
+	cmd := "ssh -F " + shellquote.Sh(cfg)
+	if len(p.KnownHosts) > 0 {
+		kh := filepath.Join(dir, "known_hosts")
+		if err := os.WriteFile(kh, []byte(p.knownHosts()), 0o600); err != nil {
+			return "", err
+		}
+		cmd += " -o UserKnownHostsFile=" + shellquote.Sh(kh) + " -o StrictHostKeyChecking=yes"
+	}
+	if identity != "" && p.Host == "" {
+		cmd += " -i " + shellquote.Sh(identity) + " -o IdentitiesOnly=yes"
+	}
+	return cmd, nil
+}
@@ -0,0 +1,26 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// baseDir returns ~/.config/rdv (or override via RDV_SSH_DIR for tests)
+func baseDir() string {
+	if v := os.Getenv("RDV_SSH_DIR"); v != "" {
+		return v
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv")
+}
+
+// cfgPath returns the profile store, ~/.config/rdv/ssh.yaml
+func cfgPath() string {
+	return filepath.Join(baseDir(), "ssh.yaml")
+}
+
+// exportDir holds the files written by `rdv ssh export`,
+// ~/.config/rdv/ssh/<profile>/
+func exportDir(profile string) string {
+	return filepath.Join(baseDir(), "ssh", profile)
+}
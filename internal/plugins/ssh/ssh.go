@@ -0,0 +1,247 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/yonasyiheyis/rdv/internal/cli"
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/plugin"
+	"github.com/yonasyiheyis/rdv/internal/profilestore"
+	"github.com/yonasyiheyis/rdv/internal/ui"
+)
+
+// ---------- plugin wiring ----------
+
+type sshPlugin struct{}
+
+func (s *sshPlugin) Name() string { return "ssh" }
+
+func (s *sshPlugin) Register(root *cobra.Command) {
+	root.AddCommand(engine.Command(driver))
+}
+
+// keyField holds the private key a profile saved with --embed-key owns,
+// usually passphrase-protected.
+const keyField = "key"
+
+var driver = &engine.Driver{
+	Name:    "ssh",
+	Label:   "SSH",
+	Related: []string{"SSH_AUTH_SOCK", "SSH_AGENT_PID", "GIT_SSH", "GIT_SSH_COMMAND"},
+	Path:    cfgPath,
+	Fields: []engine.Field{
+		{Key: "key_path", Title: "Private key path", Usage: "private key file to reference", Example: "~/.ssh/id_ed25519", Optional: true, Path: true, Replaces: []string{keyField}},
+		{Key: keyField, File: true, Replaces: []string{"key_path"}},
+		{Key: "passphrase", Title: "Passphrase", Usage: "key passphrase (omit to be prompted when needed)", Secret: true, Optional: true},
+		{Key: "known_hosts", Title: "known_hosts entries", Usage: "known_hosts line to trust (repeatable)", Lines: true, Optional: true},
+		{Key: "host", Title: "Host alias", Usage: "host alias used in ssh/git remotes, e.g. github.com or deploy-prod", Optional: true},
+		{Key: "hostname", Title: "Real hostname", Usage: "real host name when --host is an alias", Optional: true},
+		{Key: "user", Title: "Remote user", Usage: "remote user, e.g. git", Optional: true},
+		{Key: "port", Title: "Port", Usage: "remote port (default 22)", Optional: true, Int: true},
+	},
+	Summary: []string{"host", "hostname", "user"},
+	Help: map[string]string{
+		"":           "Manage SSH deploy key profiles",
+		"set-config": "Interactively set an SSH key profile",
+		"delete":     "Delete an SSH profile",
+		"export":     "Write ssh config/known_hosts for a profile and print GIT_SSH_COMMAND",
+		"test-conn":  "Load the key and authenticate to the profile host",
+	},
+	Validate: func(p engine.Settings) error {
+		if p["key_path"] != "" && p[keyField] != "" {
+			return exitcodes.New(exitcodes.InvalidArgs, "profile has both a key path and an embedded key")
+		}
+		sp := profileOf(p)
+		return sp.validate()
+	},
+	Flags: func(fs *pflag.FlagSet) {
+		fs.Bool("embed-key", false, "store a copy of --key-path with the profile instead of the path")
+		fs.String("known-hosts-file", "", "read known_hosts lines from this file")
+	},
+	Export: func(name string, p engine.Settings) (map[string]string, error) {
+		sp := profileOf(p)
+		cmd, err := sp.writeFiles(exportDir(name), sp.KeyPath)
+		if err != nil {
+			return nil, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		return map[string]string{"GIT_SSH_COMMAND": cmd}, nil
+	},
+	Exec: func(name string, p engine.Settings) (map[string]string, func(), error) {
+		return startAgent(name, profileOf(p))
+	},
+	Test: func(ctx context.Context, p engine.Settings, out io.Writer) error {
+		return testSSH(ctx, profileOf(p), out, engine.MayPrompt(ctx))
+	},
+	// Exported files sit next to the embedded key; remove them too.
+	Remove: func(name string) error {
+		if err := os.RemoveAll(exportDir(name)); err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	driver.Apply = applyInputs // set here: it refers back to driver
+	plugin.Register(&sshPlugin{})
+	engine.Register(driver)
+}
+
+// applyInputs reads --known-hosts-file and, with --embed-key, replaces the
+// key path by a copy of the key the profile owns.
+func applyInputs(name string, fs *pflag.FlagSet, p engine.Settings) error {
+	if file, _ := fs.GetString("known-hosts-file"); file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return exitcodes.Wrap(exitcodes.InvalidArgs, err)
+		}
+		lines := p.Lines("known_hosts")
+		for _, line := range strings.Split(string(b), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				lines = append(lines, line)
+			}
+		}
+		p["known_hosts"] = strings.Join(lines, "\n")
+	}
+	if embed, _ := fs.GetBool("embed-key"); embed && p["key_path"] != "" {
+		if err := profilestore.ValidName(name); err != nil {
+			return err
+		}
+		b, err := os.ReadFile(p["key_path"])
+		if err != nil {
+			return exitcodes.Wrap(exitcodes.InvalidArgs, err)
+		}
+		path := driver.FilePath(name, keyField)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		if err := os.WriteFile(path, b, 0o600); err != nil {
+			return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+		}
+		p[keyField], p["key_path"] = path, ""
+	}
+	return nil
+}
+
+// ---------- data types ----------
+
+// sshProfile is a resolved profile in the shape the exporter, agent and
+// tester work with. KeyPath is the referenced key or the profile's own copy.
+type sshProfile struct {
+	KeyPath    string
+	Passphrase string
+	KnownHosts []string
+	Host       string
+	HostName   string
+	User       string
+	Port       string
+}
+
+func profileOf(p engine.Settings) sshProfile {
+	sp := sshProfile{
+		KeyPath:    p["key_path"],
+		Passphrase: p["passphrase"],
+		KnownHosts: p.Lines("known_hosts"),
+		Host:       p["host"],
+		HostName:   p["hostname"],
+		User:       p["user"],
+		Port:       p["port"],
+	}
+	if p[keyField] != "" {
+		sp.KeyPath = p[keyField]
+	}
+	return sp
+}
+
+// validate checks that a key is set and that it parses.
+func (p *sshProfile) validate() error {
+	switch {
+	case p.KeyPath == "":
+		return exitcodes.New(exitcodes.InvalidArgs, "missing required flags: --key-path")
+	case p.HostName != "" && p.Host == "":
+		return exitcodes.New(exitcodes.InvalidArgs, "--hostname requires --host")
+	}
+	if p.Port != "" {
+		if _, err := strconv.Atoi(p.Port); err != nil {
+			return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid --port %q", p.Port))
+		}
+	}
+	if _, err := p.privateKey(false); err != nil {
+		var missing *gossh.PassphraseMissingError
+		if !errors.As(err, &missing) {
+			return exitcodes.Wrap(exitcodes.InvalidArgs, err)
+		}
+	}
+	return nil
+}
+
+// keyBytes returns the PEM/OpenSSH private key.
+func (p sshProfile) keyBytes() ([]byte, error) {
+	b, err := os.ReadFile(p.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	return b, nil
+}
+
+// privateKey parses the key, decrypting it with the stored passphrase or,
+// when prompt is set and a terminal is attached, one typed by the user.
+func (p sshProfile) privateKey(prompt bool) (any, error) {
+	b, err := p.keyBytes()
+	if err != nil {
+		return nil, err
+	}
+	key, err := gossh.ParseRawPrivateKey(b)
+	var missing *gossh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		return key, err
+	}
+
+	pass := p.Passphrase
+	if pass == "" {
+		if !prompt || !cli.IsInteractive() {
+			return nil, fmt.Errorf("key is passphrase-protected: set --passphrase or run interactively: %w", err)
+		}
+		form := ui.NewForm(huh.NewGroup(
+			huh.NewInput().Title("SSH key passphrase").EchoMode(huh.EchoModePassword).Value(&pass),
+		))
+		if err := form.Run(); err != nil {
+			return nil, err
+		}
+	}
+	key, err = gossh.ParseRawPrivateKeyWithPassphrase(b, []byte(pass))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key: %w", err)
+	}
+	return key, nil
+}
+
+// lookup resolves a single profile.
+func lookup(profile string) (sshProfile, error) {
+	p, err := driver.Resolve(profile)
+	if err != nil {
+		return sshProfile{}, err
+	}
+	return profileOf(p), nil
+}
+
+// ExportVars writes an ssh config and known_hosts for the profile under
+// the rdv config dir and returns GIT_SSH_COMMAND using them. Unlike
+// `rdv exec --ssh`, no agent is started, so ssh asks for the passphrase of
+// an encrypted key.
+func ExportVars(profile string) (map[string]string, error) {
+	return driver.ExportVars(profile)
+}
@@ -0,0 +1,198 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/yonasyiheyis/rdv/internal/engine"
+	"github.com/yonasyiheyis/rdv/internal/shellquote"
+)
+
+// writeKey writes a new ed25519 key, encrypted when passphrase is set.
+func writeKey(t *testing.T, passphrase string) (string, gossh.PublicKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = gossh.MarshalPrivateKey(priv, "")
+	} else {
+		block, err = gossh.MarshalPrivateKeyWithPassphrase(priv, "", []byte(passphrase))
+	}
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0o600))
+	sshPub, err := gossh.NewPublicKey(pub)
+	require.NoError(t, err)
+	return path, sshPub
+}
+
+// fakeSSHServer accepts public-key auth for user with authorized and
+// returns its address and known_hosts line.
+func fakeSSHServer(t *testing.T, user string, authorized gossh.PublicKey) (string, string) {
+	t.Helper()
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostKey, err := gossh.NewSignerFromKey(hostPriv)
+	require.NoError(t, err)
+
+	cfg := &gossh.ServerConfig{
+		PublicKeyCallback: func(c gossh.ConnMetadata, k gossh.PublicKey) (*gossh.Permissions, error) {
+			if c.User() == user && string(k.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	cfg.AddHostKey(hostKey)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn, chans, reqs, err := gossh.NewServerConn(c, cfg)
+				if err != nil {
+					_ = c.Close()
+					return
+				}
+				go gossh.DiscardRequests(reqs)
+				for ch := range chans {
+					_ = ch.Reject(gossh.Prohibited, "no shell")
+				}
+				_ = conn.Close()
+			}()
+		}
+	}()
+	addr := ln.Addr().String()
+	return addr, knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey.PublicKey())
+}
+
+func TestTestSSHHandshake(t *testing.T) {
+	keyPath, pub := writeKey(t, "")
+	addr, khLine := fakeSSHServer(t, "git", pub)
+	host, port, _ := net.SplitHostPort(addr)
+
+	p := sshProfile{KeyPath: keyPath, Host: "deploy", HostName: host, Port: port, User: "git", KnownHosts: []string{khLine}}
+	require.NoError(t, p.validate())
+	require.NoError(t, testSSH(t.Context(), p, io.Discard, false))
+
+	p.User = "root"
+	require.ErrorContains(t, testSSH(t.Context(), p, io.Discard, false), "unable to authenticate")
+
+	other, otherPub := writeKey(t, "")
+	p.User, p.KeyPath = "git", other
+	require.ErrorContains(t, testSSH(t.Context(), p, io.Discard, false), "unable to authenticate")
+
+	// a different host key for the same address is refused
+	p.KeyPath, p.KnownHosts = keyPath, []string{knownhosts.Line([]string{knownhosts.Normalize(addr)}, otherPub)}
+	require.ErrorContains(t, testSSH(t.Context(), p, io.Discard, false), "key mismatch")
+}
+
+func TestStartAgentEncryptedEmbeddedKey(t *testing.T) {
+	t.Setenv("RDV_SSH_DIR", t.TempDir())
+	keyPath, pub := writeKey(t, "hunter2")
+
+	fs := pflag.NewFlagSet("set-config", pflag.ContinueOnError)
+	driver.Flags(fs)
+	require.NoError(t, fs.Set("embed-key", "true"))
+	in := engine.Settings{"key_path": keyPath, "host": "github.com", "user": "git", "known_hosts": "github.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"}
+	require.NoError(t, applyInputs("deploy", fs, in))
+	require.NoError(t, engine.SetConfig(driver, "deploy", false, true, in))
+
+	fields, err := driver.Store().Get("deploy")
+	require.NoError(t, err)
+	require.Empty(t, fields["key_path"])
+	require.Equal(t, driver.FilePath("deploy", keyField), fields[keyField])
+	b, err := os.ReadFile(fields[keyField])
+	require.NoError(t, err)
+	require.Contains(t, string(b), "OPENSSH PRIVATE KEY")
+
+	// no passphrase stored and no terminal: refuse
+	_, _, err = StartAgent("deploy")
+	require.ErrorContains(t, err, "passphrase-protected")
+
+	require.NoError(t, engine.Modify(driver, "deploy", false, true, engine.Settings{"passphrase": "hunter2"}))
+
+	env, cleanup, err := StartAgent("deploy")
+	require.NoError(t, err)
+	sock := env["SSH_AUTH_SOCK"]
+
+	c, err := net.Dial("unix", sock)
+	require.NoError(t, err)
+	keys, err := agent.NewClient(c).List()
+	require.NoError(t, err)
+	_ = c.Close()
+	require.Len(t, keys, 1)
+	require.Equal(t, pub.Marshal(), keys[0].Marshal())
+	require.Equal(t, "rdv:deploy", keys[0].Comment)
+
+	require.Contains(t, env["GIT_SSH_COMMAND"], "StrictHostKeyChecking=yes")
+	dir := filepath.Dir(sock)
+	b, err = os.ReadFile(filepath.Join(dir, "config"))
+	require.NoError(t, err)
+	require.Contains(t, string(b), "Host github.com\n  User git\n")
+	require.NotContains(t, string(b), "IdentityFile")
+
+	cleanup()
+	require.NoDirExists(t, dir)
+}
+
+func TestExportVarsWritesIdentity(t *testing.T) {
+	t.Setenv("RDV_SSH_DIR", t.TempDir())
+	keyPath, _ := writeKey(t, "")
+	require.NoError(t, engine.SetConfig(driver, "ci", false, true, engine.Settings{"key_path": keyPath}))
+
+	vars, err := ExportVars("ci")
+	require.NoError(t, err)
+	require.Contains(t, vars["GIT_SSH_COMMAND"], "-i "+keyPath+" -o IdentitiesOnly=yes")
+	require.NotContains(t, vars["GIT_SSH_COMMAND"], "known_hosts")
+}
+
+func TestValidate(t *testing.T) {
+	require.Error(t, (&sshProfile{}).validate())
+	notKey := filepath.Join(t.TempDir(), "id")
+	require.NoError(t, os.WriteFile(notKey, []byte("not a key"), 0o600))
+	require.Error(t, (&sshProfile{KeyPath: notKey}).validate())
+	keyPath, _ := writeKey(t, "pw")
+	require.NoError(t, (&sshProfile{KeyPath: keyPath}).validate()) // encrypted is fine without passphrase
+	require.Error(t, (&sshProfile{KeyPath: keyPath, HostName: "x"}).validate())
+	require.Error(t, (&sshProfile{KeyPath: keyPath, Port: "ssh"}).validate())
+	require.Equal(t, "'a b'", shellquote.Sh("a b"))
+	require.Equal(t, "/tmp/x", shellquote.Sh("/tmp/x"))
+}
+
+func TestDeleteRemovesEmbeddedKeyAndExports(t *testing.T) {
+	t.Setenv("RDV_SSH_DIR", t.TempDir())
+	keyPath, _ := writeKey(t, "")
+	fs := pflag.NewFlagSet("set-config", pflag.ContinueOnError)
+	driver.Flags(fs)
+	require.NoError(t, fs.Set("embed-key", "true"))
+	in := engine.Settings{"key_path": keyPath}
+	require.NoError(t, applyInputs("ci", fs, in))
+	require.NoError(t, engine.SetConfig(driver, "ci", false, true, in))
+
+	vars, err := ExportVars("ci")
+	require.NoError(t, err)
+	require.Contains(t, vars["GIT_SSH_COMMAND"], "-i "+driver.FilePath("ci", keyField))
+
+	require.NoError(t, driver.Store().Delete("ci"))
+	require.NoDirExists(t, exportDir("ci"))
+}
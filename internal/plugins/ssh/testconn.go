@@ -0,0 +1,102 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// hostKeyCallback verifies against the profile's known_hosts entries, else
+// the user's ~/.ssh/known_hosts.
+func (p sshProfile) hostKeyCallback() (gossh.HostKeyCallback, error) {
+	if len(p.KnownHosts) > 0 {
+		f, err := os.CreateTemp("", "rdv-known-hosts-")
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = os.Remove(f.Name()) }()
+		if _, err := f.WriteString(p.knownHosts()); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		_ = f.Close()
+		return knownhosts.New(f.Name())
+	}
+	home, _ := os.UserHomeDir()
+	path := filepath.Join(home, ".ssh", "known_hosts")
+	if _, err := os.Stat(path); err != nil {
+		return nil, errors.New("no known_hosts entries in the profile and no ~/.ssh/known_hosts; add --known-hosts")
+	}
+	return knownhosts.New(path)
+}
+
+// testSSH loads (and decrypts) the key, asking for its passphrase only if
+// prompt is set; with a host configured it also completes an SSH handshake
+// and public-key authentication.
+func testSSH(ctx context.Context, p sshProfile, out io.Writer, prompt bool) error {
+	key, err := p.privateKey(prompt)
+	if err != nil {
+		return err
+	}
+	signer, err := gossh.NewSignerFromKey(key)
+	if err != nil {
+		return fmt.Errorf("unsupported key: %w", err)
+	}
+	fp := gossh.FingerprintSHA256(signer.PublicKey())
+	if p.Host == "" {
+		_, _ = fmt.Fprintf(out, "✅ SSH key loads (%s %s)\n", signer.PublicKey().Type(), fp)
+		return nil
+	}
+
+	hostKeys, err := p.hostKeyCallback()
+	if err != nil {
+		return err
+	}
+	user := p.User
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	addr := p.addr()
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("ssh %s@%s: %w", user, addr, err)
+	}
+	// gossh has no context: closing the connection aborts the handshake.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	c, chans, reqs, err := gossh.NewClientConn(conn, addr, &gossh.ClientConfig{
+		User:            user,
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(signer)},
+		HostKeyCallback: hostKeys,
+	})
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("ssh %s@%s: %w", user, addr, err)
+	}
+	_ = gossh.NewClient(c, chans, reqs).Close()
+	_, _ = fmt.Fprintf(out, "✅ %s accepted key %s for %s\n", addr, fp, user)
+	return nil
+}
+
+// addr is the dial address: HostName (else Host) and Port (else 22).
+func (p sshProfile) addr() string {
+	host := p.HostName
+	if host == "" {
+		host = p.Host
+	}
+	port := p.Port
+	if port == "" {
+		port = "22"
+	}
+	return net.JoinHostPort(host, port)
+}
@@ -0,0 +1,111 @@
+package profilestore
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	"github.com/yonasyiheyis/rdv/internal/logger"
+	iprint "github.com/yonasyiheyis/rdv/internal/print"
+)
+
+// Commands builds the copy, rename and diff sub-commands for s; label
+// names the plugin in help and messages, e.g. "AWS" or "PostgreSQL".
+func Commands(s Store, label string) []*cobra.Command {
+	// -------- copy ------------
+	var cpFrom, cpTo string
+	var cpForce bool
+	copyCmd := &cobra.Command{
+		Use:   "copy",
+		Short: fmt.Sprintf("Copy a %s profile to a new name", label),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := Copy(s, cpFrom, cpTo, cpForce); err != nil {
+				return err
+			}
+			logger.L.Infow("profile copied", "plugin", label, "from", cpFrom, "to", cpTo)
+			fmt.Printf("✅ Copied %s profile %q to %q\n", label, cpFrom, cpTo)
+			return nil
+		},
+	}
+	copyCmd.Flags().StringVar(&cpFrom, "from", "", "profile to copy")
+	copyCmd.Flags().StringVar(&cpTo, "to", "", "name of the new profile")
+	copyCmd.Flags().BoolVar(&cpForce, "force", false, "overwrite --to if it already exists")
+
+	// -------- rename ------------
+	var mvFrom, mvTo string
+	var mvForce bool
+	renameCmd := &cobra.Command{
+		Use:   "rename",
+		Short: fmt.Sprintf("Rename a %s profile", label),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := Rename(s, mvFrom, mvTo, mvForce); err != nil {
+				return err
+			}
+			logger.L.Infow("profile renamed", "plugin", label, "from", mvFrom, "to", mvTo)
+			fmt.Printf("✅ Renamed %s profile %q to %q\n", label, mvFrom, mvTo)
+			return nil
+		},
+	}
+	renameCmd.Flags().StringVar(&mvFrom, "from", "", "profile to rename")
+	renameCmd.Flags().StringVar(&mvTo, "to", "", "new name")
+	renameCmd.Flags().BoolVar(&mvForce, "force", false, "overwrite --to if it already exists")
+
+	// -------- diff ------------
+	var a, b string
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: fmt.Sprintf("Show which fields differ between two %s profiles (redacted)", label),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if a == "" || b == "" {
+				return exitcodes.New(exitcodes.InvalidArgs, "missing required flags: --a, --b")
+			}
+			return runDiff(s, a, b)
+		},
+	}
+	diffCmd.Flags().StringVar(&a, "a", "", "first profile")
+	diffCmd.Flags().StringVar(&b, "b", "", "second profile")
+
+	return []*cobra.Command{copyCmd, renameCmd, diffCmd}
+}
+
+func runDiff(s Store, a, b string) error {
+	diffs, err := Diff(s, a, b)
+	if err != nil {
+		return err
+	}
+	if iprint.JSON {
+		if diffs == nil {
+			diffs = []FieldDiff{}
+		}
+		if err := iprint.Out(map[string]any{
+			"a":         a,
+			"b":         b,
+			"identical": len(diffs) == 0,
+			"fields":    diffs,
+		}); err != nil {
+			return exitcodes.Wrap(exitcodes.JSONError, err)
+		}
+		return nil
+	}
+
+	if len(diffs) == 0 {
+		fmt.Printf("profiles %q and %q are identical\n", a, b)
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "FIELD\t%s\t%s\n", a, b)
+	for _, d := range diffs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Field, orUnset(d.A), orUnset(d.B))
+	}
+	return tw.Flush()
+}
+
+func orUnset(v string) string {
+	if v == "" {
+		return "(unset)"
+	}
+	return v
+}
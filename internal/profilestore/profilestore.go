@@ -0,0 +1,199 @@
+// Package profilestore gives each plugin's saved profiles a common shape,
+// flat field maps keyed by profile name, so copy, rename and diff are
+// written once however a plugin lays its profiles out on disk.
+package profilestore
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+	iprint "github.com/yonasyiheyis/rdv/internal/print"
+	"github.com/yonasyiheyis/rdv/internal/resolve"
+)
+
+// ExtendsField is the field naming the profile another one inherits from.
+// Rename rewrites it so children follow their renamed base.
+const ExtendsField = "extends"
+
+// Store is one plugin's set of saved profiles.
+type Store interface {
+	// Names lists the saved profiles.
+	Names() ([]string, error)
+	// Get returns a profile's stored (unresolved) fields. A missing profile
+	// is a ProfileNotFound error.
+	Get(name string) (map[string]string, error)
+	// Put saves fields as name, replacing whatever was stored there.
+	Put(name string, fields map[string]string) error
+	// Delete removes a profile and any files it owns.
+	Delete(name string) error
+	// Secret reports whether a field holds a credential diff must redact.
+	Secret(field string) bool
+	// Fields lists the fields a profile may hold. Profiles imported from
+	// someone else's bundle are cut down to these before they are saved.
+	Fields() []string
+}
+
+// FileStore is implemented by stores whose profiles own files referenced by
+// path, such as a key copied into the config dir. Backups carry the files'
+// content, and Put copies a file named by one of these fields into place
+// when it lives anywhere else.
+type FileStore interface {
+	Store
+	// FileFields lists the fields holding paths to owned files.
+	FileFields() []string
+}
+
+// OpenStore is implemented by stores whose profiles may hold fields beyond
+// Fields, such as a custom bundle's variables.
+type OpenStore interface {
+	Store
+	// Accepts reports whether key may be saved though Fields doesn't list it.
+	Accepts(key string) bool
+}
+
+// ValidName rejects profile names that could escape a store's directory or
+// break the file they are saved in: empty ones, ones holding a path
+// separator or "..", and ones with control characters.
+func ValidName(name string) error {
+	switch {
+	case name == "":
+		return exitcodes.New(exitcodes.InvalidArgs, "empty profile name")
+	case strings.ContainsAny(name, `/\`), strings.Contains(name, ".."),
+		strings.ContainsFunc(name, unicode.IsControl):
+		return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("invalid profile name %q", name))
+	}
+	return nil
+}
+
+// exists reports whether name is a saved profile of s.
+func exists(s Store, name string) (bool, error) {
+	names, err := s.Names()
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(names, name), nil
+}
+
+// checkTarget validates a from -> to move or copy before anything is written.
+func checkTarget(s Store, from, to string, force bool) error {
+	if from == "" || to == "" {
+		return exitcodes.New(exitcodes.InvalidArgs, "missing required flags: --from, --to")
+	}
+	if from == to {
+		return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("--from and --to are both %q", from))
+	}
+	if err := ValidName(to); err != nil {
+		return err
+	}
+	taken, err := exists(s, to)
+	if err != nil {
+		return err
+	}
+	if taken && !force {
+		return exitcodes.New(exitcodes.InvalidArgs, fmt.Sprintf("profile %q already exists (use --force to overwrite)", to))
+	}
+	return nil
+}
+
+// Copy saves profile from under the name to as well.
+func Copy(s Store, from, to string, force bool) error {
+	if err := checkTarget(s, from, to, force); err != nil {
+		return err
+	}
+	fields, err := s.Get(from)
+	if err != nil {
+		return err
+	}
+	return s.Put(to, fields)
+}
+
+// Rename moves profile from to to and points profiles extending from at
+// the new name.
+func Rename(s Store, from, to string, force bool) error {
+	if err := Copy(s, from, to, force); err != nil {
+		return err
+	}
+	if err := s.Delete(from); err != nil {
+		return err
+	}
+	names, err := s.Names()
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		fields, err := s.Get(n)
+		if err != nil {
+			return err
+		}
+		if fields[ExtendsField] != from {
+			continue
+		}
+		fields[ExtendsField] = to
+		if err := s.Put(n, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FieldDiff is one field whose stored value differs between two profiles.
+// Secret values are redacted; an unset field is "".
+type FieldDiff struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+	// Secret marks redacted values, which may look alike while differing.
+	Secret bool `json:"secret,omitempty"`
+}
+
+// Diff compares the stored fields of profiles a and b.
+func Diff(s Store, a, b string) ([]FieldDiff, error) {
+	fa, err := s.Get(a)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := s.Get(b)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]struct{}{}
+	for k := range fa {
+		keys[k] = struct{}{}
+	}
+	for k := range fb {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var out []FieldDiff
+	for _, k := range sorted {
+		va, vb := fa[k], fb[k]
+		if va == vb {
+			continue
+		}
+		d := FieldDiff{Field: k, A: va, B: vb}
+		if s.Secret(k) {
+			d.A, d.B, d.Secret = Redact(s, k, va), Redact(s, k, vb), true
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// Redact masks v if field is secret in s, unless it is only a ${VAR}
+// reference, as show does.
+func Redact(s Store, field, v string) string {
+	if !s.Secret(field) || resolve.IsEnvRef(v) {
+		return v
+	}
+	return iprint.Redact(v)
+}
@@ -0,0 +1,91 @@
+package profilestore
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// mem is an in-memory Store whose "password" field is secret.
+type mem map[string]map[string]string
+
+func (m mem) Names() ([]string, error) { return slices.Sorted(maps.Keys(m)), nil }
+
+func (m mem) Get(name string) (map[string]string, error) {
+	p, ok := m[name]
+	if !ok {
+		return nil, exitcodes.New(exitcodes.ProfileNotFound, "not found")
+	}
+	return maps.Clone(p), nil
+}
+
+func (m mem) Put(name string, fields map[string]string) error { m[name] = fields; return nil }
+func (m mem) Delete(name string) error                        { delete(m, name); return nil }
+func (m mem) Secret(field string) bool                        { return field == "password" }
+func (m mem) Fields() []string                                { return []string{"host", "password", ExtendsField} }
+
+func TestCopy(t *testing.T) {
+	s := mem{"dev": {"host": "localhost", "password": "devsecret"}, "prod": {"host": "db"}}
+
+	require.NoError(t, Copy(s, "dev", "staging", false))
+	require.Equal(t, s["dev"], s["staging"])
+
+	err := Copy(s, "dev", "prod", false)
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(err))
+	require.Equal(t, "db", s["prod"]["host"], "existing profile left alone without --force")
+	require.NoError(t, Copy(s, "dev", "prod", true))
+	require.Equal(t, "localhost", s["prod"]["host"])
+
+	require.Equal(t, exitcodes.ProfileNotFound, exitcodes.FromError(Copy(s, "nope", "x", false)))
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(Copy(s, "dev", "dev", true)))
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(Copy(s, "dev", "", false)))
+}
+
+func TestRenameFollowsExtends(t *testing.T) {
+	s := mem{
+		"base":  {"host": "db"},
+		"child": {ExtendsField: "base", "user": "ci"},
+		"other": {ExtendsField: "child"},
+	}
+	require.NoError(t, Rename(s, "base", "shared", false))
+	require.NotContains(t, s, "base")
+	require.Equal(t, map[string]string{"host": "db"}, s["shared"])
+	require.Equal(t, "shared", s["child"][ExtendsField])
+	require.Equal(t, "child", s["other"][ExtendsField])
+}
+
+func TestDiffRedactsSecrets(t *testing.T) {
+	s := mem{
+		"dev":     {"host": "localhost", "port": "5432", "password": "devsecret", "sslmode": "disable"},
+		"staging": {"host": "stg.internal", "port": "5432", "password": "${STG_PW}"},
+	}
+	diffs, err := Diff(s, "dev", "staging")
+	require.NoError(t, err)
+	require.Equal(t, []FieldDiff{
+		{Field: "host", A: "localhost", B: "stg.internal"},
+		{Field: "password", A: "de****et", B: "${STG_PW}", Secret: true},
+		{Field: "sslmode", A: "disable", B: ""},
+	}, diffs)
+
+	diffs, err = Diff(s, "dev", "dev")
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+
+	_, err = Diff(s, "dev", "nope")
+	require.Equal(t, exitcodes.ProfileNotFound, exitcodes.FromError(err))
+}
+
+func TestValidName(t *testing.T) {
+	for _, n := range []string{"dev", "staging-2", "team.prod"} {
+		require.NoError(t, ValidName(n), n)
+	}
+	for _, n := range []string{"", "a/b", `a\b`, "..", "../x", "a\nb"} {
+		require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(ValidName(n)), n)
+	}
+	s := mem{"dev": {"host": "localhost"}}
+	require.Equal(t, exitcodes.InvalidArgs, exitcodes.FromError(Copy(s, "dev", "../x", false)))
+}
@@ -0,0 +1,127 @@
+// Package resolve implements what plugins share when reading a saved
+// profile: `extends:` inheritance and value interpolation.
+package resolve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// ParentFunc returns the profile name extends (or "" for none). It reports
+// ProfileNotFound for names that don't exist.
+type ParentFunc func(name string) (string, error)
+
+// Chain returns name followed by the profiles it extends, nearest first.
+// A profile extending one that is missing is ProfileNotFound; a cycle is
+// ConfigReadWrite.
+func Chain(name string, parent ParentFunc) ([]string, error) {
+	chain := []string{name}
+	seen := map[string]bool{name: true}
+	for cur := name; ; {
+		next, err := parent(cur)
+		if err != nil {
+			if cur != name && exitcodes.FromError(err) == exitcodes.ProfileNotFound {
+				return nil, exitcodes.New(exitcodes.ProfileNotFound, fmt.Sprintf("profile %q extends unknown profile %q", chain[len(chain)-2], cur))
+			}
+			return nil, err
+		}
+		if next == "" {
+			return chain, nil
+		}
+		chain = append(chain, next)
+		if seen[next] {
+			return nil, exitcodes.New(exitcodes.ConfigReadWrite, "profile inheritance cycle: "+strings.Join(chain, " -> "))
+		}
+		seen[next] = true
+		cur = next
+	}
+}
+
+// InterpolateField is the field a profile sets to "true" to opt in to
+// interpolation. Values of profiles without it are used exactly as stored,
+// so a password that happens to contain "{{" or "${" keeps working.
+const InterpolateField = "interpolate"
+
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// IsEnvRef reports whether v is exactly one ${VAR} reference, which show
+// commands can print without redacting: it names a secret, it isn't one.
+func IsEnvRef(v string) bool {
+	loc := envRef.FindStringIndex(v)
+	return loc != nil && loc[0] == 0 && loc[1] == len(v)
+}
+
+// RootedInRef reports whether path starts with a ${VAR} element, whose value
+// decides where the path points, so it can't be made absolute when saved.
+func RootedInRef(path string) bool {
+	first, _, _ := strings.Cut(filepath.ToSlash(path), "/")
+	return IsEnvRef(first)
+}
+
+// Literal escapes v so Expand returns it unchanged. Resolving an extends
+// chain applies it to the values of profiles that don't opt in to
+// interpolation.
+func Literal(v string) string {
+	v = envRef.ReplaceAllString(v, "$$$0")
+	return strings.ReplaceAll(v, "{{", `{{"{{"}}`)
+}
+
+// envSub matches a ${VAR} reference or its $${VAR} escape.
+var envSub = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Expand interpolates a stored value of a profile that opted in (see
+// InterpolateField). Go template actions run first and
+// see the requested profile as {{ .profile }}, so a shared base can say
+// dbname: app_{{ .profile }}; ${VAR} references in the result are then
+// replaced from the environment (unset is an error). Environment values
+// are never parsed as templates. A literal "{{" is written {{"{{"}} and a
+// literal ${VAR} is written $${VAR}.
+func Expand(v, profile string) (string, error) {
+	if strings.Contains(v, "{{") {
+		t, err := template.New("value").Option("missingkey=error").Parse(v)
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, map[string]string{"profile": profile}); err != nil {
+			return "", err
+		}
+		v = b.String()
+	}
+
+	var missing []string
+	v = envSub.ReplaceAllStringFunc(v, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		name := envSub.FindStringSubmatch(ref)[1]
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return val
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return v, nil
+}
+
+// ExpandFields runs Expand over named values in place, reporting which
+// field of which profile failed.
+func ExpandFields(profile string, fields map[string]*string) error {
+	for key, v := range fields {
+		out, err := Expand(*v, profile)
+		if err != nil {
+			return exitcodes.New(exitcodes.ConfigReadWrite, fmt.Sprintf("profile %q field %s: %v", profile, key, err))
+		}
+		*v = out
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+func TestChain(t *testing.T) {
+	parents := map[string]string{"prod": "base", "staging": "base", "base": "", "a": "b", "b": "c", "c": "a", "orphan": "gone"}
+	parent := func(n string) (string, error) {
+		p, ok := parents[n]
+		if !ok {
+			return "", exitcodes.New(exitcodes.ProfileNotFound, "profile "+n+" not found")
+		}
+		return p, nil
+	}
+
+	chain, err := Chain("prod", parent)
+	require.NoError(t, err)
+	require.Equal(t, []string{"prod", "base"}, chain)
+
+	_, err = Chain("a", parent)
+	require.Equal(t, exitcodes.ConfigReadWrite, exitcodes.FromError(err))
+	require.ErrorContains(t, err, "a -> b -> c -> a")
+
+	_, err = Chain("orphan", parent)
+	require.ErrorContains(t, err, `profile "orphan" extends unknown profile "gone"`)
+
+	_, err = Chain("nope", parent)
+	require.ErrorContains(t, err, "profile nope not found")
+}
+
+func TestExpand(t *testing.T) {
+	t.Setenv("RDV_TEST_HOST", "db.acme")
+
+	v, err := Expand("${RDV_TEST_HOST}:5432/app_{{ .profile }}", "staging")
+	require.NoError(t, err)
+	require.Equal(t, "db.acme:5432/app_staging", v)
+
+	v, err = Expand("$NOT_A_REF {not} plain", "x")
+	require.NoError(t, err)
+	require.Equal(t, "$NOT_A_REF {not} plain", v)
+
+	_, err = Expand("${RDV_TEST_UNSET_VAR}", "x")
+	require.ErrorContains(t, err, "RDV_TEST_UNSET_VAR is not set")
+	_, err = Expand("{{ .other }}", "x")
+	require.Error(t, err)
+	_, err = Expand("{{ .profile", "x")
+	require.Error(t, err)
+}
+
+func TestExpandLiteralSecrets(t *testing.T) {
+	t.Setenv("RDV_TEST_PW", "a{{ .other }}b")
+	t.Setenv("NAME", "expanded")
+
+	v, err := Expand("${RDV_TEST_PW}", "x")
+	require.NoError(t, err)
+	require.Equal(t, "a{{ .other }}b", v, "env values aren't parsed as templates")
+
+	v, err = Expand(`p{{"{{"}}w$${NAME}!`, "x")
+	require.NoError(t, err)
+	require.Equal(t, "p{{w${NAME}!", v)
+
+	v, err = Expand("pw$${NAME}", "x")
+	require.NoError(t, err)
+	require.Equal(t, "pw${NAME}", v)
+}
+
+func TestIsEnvRef(t *testing.T) {
+	require.True(t, IsEnvRef("${PG_PASSWORD}"))
+	require.False(t, IsEnvRef("pw${PG_PASSWORD}"))
+	require.False(t, IsEnvRef("${A}${B}"))
+	require.False(t, IsEnvRef("hunter2"))
+}
+
+func TestLiteral(t *testing.T) {
+	t.Setenv("x", "expanded")
+	for _, v := range []string{"p{{w${x}", "$${x}", "a${x}c{{{", "plain", "${x}${x}"} {
+		out, err := Expand(Literal(v), "p")
+		require.NoError(t, err, v)
+		require.Equal(t, v, out)
+	}
+}
+
+func TestRootedInRef(t *testing.T) {
+	require.True(t, RootedInRef("${KEYS}/sa.json"))
+	require.True(t, RootedInRef("${KEY_FILE}"))
+	require.False(t, RootedInRef("keys/${ENV}.json"))
+	require.False(t, RootedInRef("/abs/sa.json"))
+}
@@ -0,0 +1,94 @@
+// Package shared remembers which saved profiles were imported from a shared
+// bundle, so rdv can refuse to re-share them or let them be edited in place
+// by accident.
+package shared
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yonasyiheyis/rdv/internal/exitcodes"
+)
+
+// path returns ~/.config/rdv/shared.yaml (or override via RDV_STATE_DIR for tests)
+func path() string {
+	if v := os.Getenv("RDV_STATE_DIR"); v != "" {
+		return filepath.Join(v, "shared.yaml")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "rdv", "shared.yaml")
+}
+
+// Mark records where a shared profile came from.
+type Mark struct {
+	Imported time.Time `yaml:"imported" json:"imported"`
+	// Bundle is the file name the profile was imported from.
+	Bundle string `yaml:"bundle,omitempty" json:"bundle,omitempty"`
+}
+
+// Marks maps target -> profile -> mark for every imported profile.
+type Marks map[string]map[string]Mark
+
+// Load reads the marks; a missing file marks nothing.
+func Load() (Marks, error) {
+	m := Marks{}
+	b, err := os.ReadFile(path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return m, exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return Marks{}, exitcodes.Wrap(exitcodes.ConfigReadWrite, fmt.Errorf("failed to parse %s: %w", path(), err))
+	}
+	if m == nil {
+		m = Marks{}
+	}
+	return m, nil
+}
+
+// Get returns the mark of target:profile, if it came from a bundle.
+func (m Marks) Get(target, profile string) (Mark, bool) {
+	mk, ok := m[target][profile]
+	return mk, ok
+}
+
+// Set marks target:profile as imported from bundle at at.
+func (m Marks) Set(target, profile, bundle string, at time.Time) {
+	if m[target] == nil {
+		m[target] = map[string]Mark{}
+	}
+	m[target][profile] = Mark{Imported: at.UTC().Truncate(time.Second), Bundle: bundle}
+}
+
+// Remove forgets target:profile, reporting whether it was marked.
+func (m Marks) Remove(target, profile string) bool {
+	if _, ok := m[target][profile]; !ok {
+		return false
+	}
+	delete(m[target], profile)
+	if len(m[target]) == 0 {
+		delete(m, target)
+	}
+	return true
+}
+
+// Save writes the marks.
+func (m Marks) Save() error {
+	if err := os.MkdirAll(filepath.Dir(path()), 0o700); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	out, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path(), out, 0o600); err != nil {
+		return exitcodes.Wrap(exitcodes.ConfigReadWrite, err)
+	}
+	return nil
+}
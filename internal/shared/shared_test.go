@@ -0,0 +1,31 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarksRoundTrip(t *testing.T) {
+	t.Setenv("RDV_STATE_DIR", t.TempDir())
+	m, err := Load()
+	require.NoError(t, err)
+	_, ok := m.Get("db.postgres", "staging")
+	require.False(t, ok)
+
+	m.Set("db.postgres", "staging", "shared.rdv", time.Now())
+	m.Set("github", "bot", "shared.rdv", time.Now())
+	require.NoError(t, m.Save())
+
+	m, err = Load()
+	require.NoError(t, err)
+	mk, ok := m.Get("db.postgres", "staging")
+	require.True(t, ok)
+	require.Equal(t, "shared.rdv", mk.Bundle)
+	require.False(t, mk.Imported.IsZero())
+
+	require.True(t, m.Remove("github", "bot"))
+	require.False(t, m.Remove("github", "bot"))
+	require.NotContains(t, m, "github", "empty targets are dropped")
+}
@@ -0,0 +1,20 @@
+// Package shellquote quotes strings for POSIX shells and fish.
+package shellquote
+
+import "strings"
+
+// Sh single-quotes s for sh/bash/zsh, leaving plain words alone.
+func Sh(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Fish single-quotes s for fish, where \ and ' are escaped inside quotes.
+func Fish(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~%") {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
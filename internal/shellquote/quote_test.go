@@ -0,0 +1,20 @@
+package shellquote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSh(t *testing.T) {
+	require.Equal(t, "/tmp/x", Sh("/tmp/x"))
+	require.Equal(t, "''", Sh(""))
+	require.Equal(t, "'a b'", Sh("a b"))
+	require.Equal(t, `'it'\''s $HOME'`, Sh("it's $HOME"))
+}
+
+func TestFish(t *testing.T) {
+	require.Equal(t, "plain", Fish("plain"))
+	require.Equal(t, "''", Fish(""))
+	require.Equal(t, `'it\'s \\ $HOME'`, Fish(`it's \ $HOME`))
+}